@@ -2,7 +2,9 @@ package electrodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -14,13 +16,18 @@ const (
 	MaxBatchGetItems = 100
 	// MaxBatchWriteItems is the maximum number of items in a batch write request
 	MaxBatchWriteItems = 25
+	// MaxTransactWriteItems is the maximum number of items in a transaction
+	MaxTransactWriteItems = 100
 )
 
 // BatchGetRequest represents a batch get request
 type BatchGetRequest struct {
-	entity *Entity
-	keys   []Keys
-	ctx    context.Context
+	entity     *Entity
+	keys       []Keys
+	ctx        context.Context
+	keysOnly   bool
+	consistent bool
+	err        error // deferred error from building the request, e.g. ConsistentRead
 }
 
 // BatchGet creates a new batch get request
@@ -32,8 +39,25 @@ func (e *Entity) BatchGet(keys []Keys) *BatchGetRequest {
 	}
 }
 
+// ConsistentRead makes the batch get strongly consistent. Batch gets always
+// read the table's primary key, so an entity without a primary index, whose
+// keys live only in global secondary indexes, is rejected when the request
+// runs.
+func (bgr *BatchGetRequest) ConsistentRead() *BatchGetRequest {
+	if bgr.entity.primaryIndex() == nil {
+		bgr.err = NewElectroError("InvalidOperation",
+			fmt.Sprintf("Consistent reads are not supported for entity '%s' without a primary index", bgr.entity.schema.Entity), nil)
+		return bgr
+	}
+	bgr.consistent = true
+	return bgr
+}
+
 // Go executes the batch get operation
 func (bgr *BatchGetRequest) Go() (*BatchGetResponse, error) {
+	if bgr.err != nil {
+		return nil, bgr.err
+	}
 	if bgr.entity.client == nil {
 		return nil, NewElectroError("NoClientProvided",
 			"No DynamoDB client was provided to the entity", nil)
@@ -54,6 +78,7 @@ func (bgr *BatchGetRequest) Go() (*BatchGetResponse, error) {
 	result := &BatchGetResponse{
 		Data:        make([]map[string]interface{}, 0),
 		Unprocessed: make([]Keys, 0),
+		request:     bgr,
 	}
 
 	// Process in batches of MaxBatchGetItems
@@ -71,11 +96,67 @@ func (bgr *BatchGetRequest) Go() (*BatchGetResponse, error) {
 
 		result.Data = append(result.Data, batchResult.Data...)
 		result.Unprocessed = append(result.Unprocessed, batchResult.Unprocessed...)
+		result.unprocessed = append(result.unprocessed, batchResult.unprocessed...)
+	}
+
+	return result, nil
+}
+
+// Retry re-submits only the keys DynamoDB left unprocessed and returns the
+// items they found, so callers can loop until Unprocessed is empty:
+//
+//	for len(resp.Unprocessed) > 0 {
+//		if resp, err = resp.Retry(ctx); err != nil { ... }
+//	}
+func (r *BatchGetResponse) Retry(ctx context.Context) (*BatchGetResponse, error) {
+	result := &BatchGetResponse{
+		Data:        make([]map[string]interface{}, 0),
+		Unprocessed: make([]Keys, 0),
+		request:     r.request,
+	}
+	if r.request == nil || len(r.unprocessed) == 0 {
+		return result, nil
+	}
+
+	tableName := NewParamsBuilder(r.request.entity).getTableName()
+	for i := 0; i < len(r.unprocessed); i += MaxBatchGetItems {
+		end := i + MaxBatchGetItems
+		if end > len(r.unprocessed) {
+			end = len(r.unprocessed)
+		}
+
+		batchResult, err := r.request.fetch(ctx, r.unprocessed[i:end], tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Data = append(result.Data, batchResult.Data...)
+		result.Unprocessed = append(result.Unprocessed, batchResult.Unprocessed...)
+		result.unprocessed = append(result.unprocessed, batchResult.unprocessed...)
 	}
 
 	return result, nil
 }
 
+// CountFound returns how many of the requested keys exist. Only the primary
+// key fields are projected, so item bodies are never transferred. Keys left
+// unprocessed by DynamoDB are not counted and are reported as an error
+// alongside the partial count.
+func (bgr *BatchGetRequest) CountFound() (int, error) {
+	counting := *bgr
+	counting.keysOnly = true
+
+	result, err := counting.Go()
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Unprocessed) > 0 {
+		return len(result.Data), NewElectroError("DynamoDBError",
+			fmt.Sprintf("%d keys were left unprocessed", len(result.Unprocessed)), nil)
+	}
+	return len(result.Data), nil
+}
+
 func (bgr *BatchGetRequest) executeBatch(keys []Keys, tableName string) (*BatchGetResponse, error) {
 	// Build keys for this batch
 	keyItems := make([]map[string]types.AttributeValue, 0, len(keys))
@@ -90,16 +171,36 @@ func (bgr *BatchGetRequest) executeBatch(keys []Keys, tableName string) (*BatchG
 		keyItems = append(keyItems, params["Key"].(map[string]types.AttributeValue))
 	}
 
-	// Execute batch get
+	return bgr.fetch(bgr.ctx, keyItems, tableName)
+}
+
+// fetch executes one BatchGetItem call for already composed keys
+func (bgr *BatchGetRequest) fetch(ctx context.Context, keyItems []map[string]types.AttributeValue, tableName string) (*BatchGetResponse, error) {
+	request := types.KeysAndAttributes{
+		Keys: keyItems,
+	}
+	if bgr.consistent {
+		request.ConsistentRead = boolPtr(true)
+	}
+	if bgr.keysOnly {
+		if index := bgr.entity.primaryIndex(); index != nil {
+			names := map[string]string{"#pk": index.PK.Field}
+			projection := "#pk"
+			if index.SK != nil {
+				names["#sk"] = index.SK.Field
+				projection += ", #sk"
+			}
+			request.ProjectionExpression = &projection
+			request.ExpressionAttributeNames = names
+		}
+	}
 	input := &dynamodb.BatchGetItemInput{
 		RequestItems: map[string]types.KeysAndAttributes{
-			tableName: {
-				Keys: keyItems,
-			},
+			tableName: request,
 		},
 	}
 
-	response, err := bgr.entity.client.BatchGetItem(bgr.ctx, input)
+	response, err := bgr.entity.client.BatchGetItem(ctx, input)
 	if err != nil {
 		return nil, NewElectroError("DynamoDBError", "Failed to execute BatchGetItem", err)
 	}
@@ -108,6 +209,7 @@ func (bgr *BatchGetRequest) executeBatch(keys []Keys, tableName string) (*BatchG
 	result := &BatchGetResponse{
 		Data:        make([]map[string]interface{}, 0),
 		Unprocessed: make([]Keys, 0),
+		request:     bgr,
 	}
 
 	if items, ok := response.Responses[tableName]; ok {
@@ -118,9 +220,11 @@ func (bgr *BatchGetRequest) executeBatch(keys []Keys, tableName string) (*BatchG
 				return nil, NewElectroError("UnmarshalError", "Failed to unmarshal response", err)
 			}
 
-			// Remove internal keys
-			executor := NewExecutionHelper(bgr.entity)
-			parsedItem = executor.removeInternalKeys(parsedItem)
+			// Format through the same read pipeline as Get
+			parsedItem, err = NewExecutionHelper(bgr.entity).formatItem(parsedItem, false)
+			if err != nil {
+				return nil, err
+			}
 
 			result.Data = append(result.Data, parsedItem)
 		}
@@ -128,6 +232,7 @@ func (bgr *BatchGetRequest) executeBatch(keys []Keys, tableName string) (*BatchG
 
 	// Handle unprocessed keys
 	if unprocessed, ok := response.UnprocessedKeys[tableName]; ok && len(unprocessed.Keys) > 0 {
+		result.unprocessed = unprocessed.Keys
 		for _, unprocessedKey := range unprocessed.Keys {
 			var parsedKey Keys
 			err = attributevalue.UnmarshalMap(unprocessedKey, &parsedKey)
@@ -145,10 +250,12 @@ func (bgr *BatchGetRequest) executeBatch(keys []Keys, tableName string) (*BatchG
 
 // BatchWriteRequest represents a batch write request
 type BatchWriteRequest struct {
-	entity  *Entity
-	puts    []Item
-	deletes []Keys
-	ctx     context.Context
+	entity      *Entity
+	puts        []Item
+	deletes     []Keys
+	ctx         context.Context
+	conditional bool              // set by WithConditions
+	conditioned []TransactionItem // writes added with PutIf and DeleteIf
 }
 
 // BatchWrite creates a new batch write request
@@ -173,8 +280,41 @@ func (bwr *BatchWriteRequest) Delete(keys []Keys) *BatchWriteRequest {
 	return bwr
 }
 
+// WithConditions allows PutIf and DeleteIf. BatchWriteItem cannot carry
+// conditions, so once any write has one the whole batch runs as
+// TransactWriteItems calls of up to MaxTransactWriteItems writes instead.
+// Each call is atomic on its own, but a failed call does not undo the ones
+// before it, and transactional writes cost twice the write capacity.
+func (bwr *BatchWriteRequest) WithConditions() *BatchWriteRequest {
+	bwr.conditional = true
+	return bwr
+}
+
+// PutIf adds a put that is only written if the condition holds
+func (bwr *BatchWriteRequest) PutIf(item Item, callback WhereCallback) *BatchWriteRequest {
+	bwr.conditioned = append(bwr.conditioned, bwr.entity.Put(item).Condition(callback).Commit())
+	return bwr
+}
+
+// DeleteIf adds a delete that only happens if the condition holds
+func (bwr *BatchWriteRequest) DeleteIf(keys Keys, callback WhereCallback) *BatchWriteRequest {
+	bwr.conditioned = append(bwr.conditioned, bwr.entity.Delete(keys).Condition(callback).Commit())
+	return bwr
+}
+
 // Go executes the batch write operation
 func (bwr *BatchWriteRequest) Go() (*BatchWriteResponse, error) {
+	if err := bwr.entity.checkWritable("batch write"); err != nil {
+		return nil, err
+	}
+	if len(bwr.conditioned) > 0 {
+		if !bwr.conditional {
+			return nil, NewElectroError("InvalidOperation",
+				"Conditional batch writes require WithConditions", nil)
+		}
+		return bwr.transact()
+	}
+
 	totalOps := len(bwr.puts) + len(bwr.deletes)
 	if totalOps == 0 {
 		return &BatchWriteResponse{}, nil
@@ -190,14 +330,18 @@ func (bwr *BatchWriteRequest) Go() (*BatchWriteResponse, error) {
 			"No DynamoDB client was provided to the entity", nil)
 	}
 
-	tableName := bwr.entity.config.Table
-	if tableName == nil {
-		tableName = &bwr.entity.schema.Table
+	writeRequests, err := bwr.writeRequests()
+	if err != nil {
+		return nil, err
 	}
 
-	// Build write requests
-	writeRequests := make([]types.WriteRequest, 0, totalOps)
-	builder := NewParamsBuilder(bwr.entity)
+	return writeBatch(bwr.ctx, bwr.entity, NewParamsBuilder(bwr.entity).getTableName(), writeRequests)
+}
+
+// writeRequests builds the batch's puts and deletes as write requests
+func (bwr *BatchWriteRequest) writeRequests() ([]types.WriteRequest, error) {
+	writeRequests := make([]types.WriteRequest, 0, len(bwr.puts)+len(bwr.deletes))
+	builder := NewParamsBuilder(bwr.entity).withContext(bwr.ctx)
 
 	// Add put requests
 	for _, item := range bwr.puts {
@@ -227,22 +371,75 @@ func (bwr *BatchWriteRequest) Go() (*BatchWriteResponse, error) {
 		})
 	}
 
-	// Execute batch write
+	return writeRequests, nil
+}
+
+// transact executes the batch as TransactWriteItems calls, so conditions
+// are honoured; nothing is left unprocessed
+func (bwr *BatchWriteRequest) transact() (*BatchWriteResponse, error) {
+	if bwr.entity.client == nil {
+		return nil, NewElectroError("NoClientProvided",
+			"No DynamoDB client was provided to the entity", nil)
+	}
+
+	writes := make([]TransactionItem, 0, len(bwr.puts)+len(bwr.deletes)+len(bwr.conditioned))
+	for _, item := range bwr.puts {
+		writes = append(writes, bwr.entity.Put(item).Commit())
+	}
+	for _, keys := range bwr.deletes {
+		writes = append(writes, bwr.entity.Delete(keys).Commit())
+	}
+	writes = append(writes, bwr.conditioned...)
+
+	// Build every item up front so an invalid write fails before any call
+	transactItems := make([]types.TransactWriteItem, 0, len(writes))
+	for _, write := range writes {
+		transactItem, err := write.BuildTransactItem()
+		if err != nil {
+			return nil, err
+		}
+		transactItems = append(transactItems, transactItem)
+	}
+
+	for i := 0; i < len(transactItems); i += MaxTransactWriteItems {
+		end := i + MaxTransactWriteItems
+		if end > len(transactItems) {
+			end = len(transactItems)
+		}
+
+		input := &dynamodb.TransactWriteItemsInput{
+			TransactItems: transactItems[i:end],
+		}
+		if _, err := bwr.entity.client.TransactWriteItems(bwr.ctx, input); err != nil {
+			var canceledErr *types.TransactionCanceledException
+			if errors.As(err, &canceledErr) {
+				return nil, NewElectroError("TransactionCanceled", "Transaction was canceled", err)
+			}
+			return nil, NewElectroError("TransactionError", "Transaction failed", err)
+		}
+	}
+
+	return &BatchWriteResponse{entity: bwr.entity}, nil
+}
+
+// writeBatch executes one BatchWriteItem call for an entity's write requests
+func writeBatch(ctx context.Context, entity *Entity, tableName string, writeRequests []types.WriteRequest) (*BatchWriteResponse, error) {
 	input := &dynamodb.BatchWriteItemInput{
 		RequestItems: map[string][]types.WriteRequest{
-			*tableName: writeRequests,
+			tableName: writeRequests,
 		},
 	}
 
-	response, err := bwr.entity.client.BatchWriteItem(bwr.ctx, input)
+	response, err := entity.client.BatchWriteItem(ctx, input)
 	if err != nil {
 		return nil, NewElectroError("DynamoDBError", "Failed to execute BatchWriteItem", err)
 	}
 
-	result := &BatchWriteResponse{}
+	result := &BatchWriteResponse{entity: entity}
 
 	// Handle unprocessed items
-	if unprocessed, ok := response.UnprocessedItems[*tableName]; ok && len(unprocessed) > 0 {
+	if unprocessed, ok := response.UnprocessedItems[tableName]; ok && len(unprocessed) > 0 {
+		result.unprocessed = unprocessed
 		result.Unprocessed.Puts = make([]Item, 0)
 		result.Unprocessed.Deletes = make([]Keys, 0)
 
@@ -273,6 +470,37 @@ func (bwr *BatchWriteRequest) Go() (*BatchWriteResponse, error) {
 	return result, nil
 }
 
+// Retry re-submits only the writes DynamoDB left unprocessed, so callers can
+// loop until Unprocessed is empty
+func (r *BatchWriteResponse) Retry(ctx context.Context) (*BatchWriteResponse, error) {
+	result := &BatchWriteResponse{entity: r.entity}
+	if r.entity == nil || len(r.unprocessed) == 0 {
+		return result, nil
+	}
+	if err := r.entity.checkWritable("batch write"); err != nil {
+		return nil, err
+	}
+
+	tableName := NewParamsBuilder(r.entity).getTableName()
+	for i := 0; i < len(r.unprocessed); i += MaxBatchWriteItems {
+		end := i + MaxBatchWriteItems
+		if end > len(r.unprocessed) {
+			end = len(r.unprocessed)
+		}
+
+		batchResult, err := writeBatch(ctx, r.entity, tableName, r.unprocessed[i:end])
+		if err != nil {
+			return nil, err
+		}
+
+		result.Unprocessed.Puts = append(result.Unprocessed.Puts, batchResult.Unprocessed.Puts...)
+		result.Unprocessed.Deletes = append(result.Unprocessed.Deletes, batchResult.Unprocessed.Deletes...)
+		result.unprocessed = append(result.unprocessed, batchResult.unprocessed...)
+	}
+
+	return result, nil
+}
+
 // BatchGetService creates a batch get request across multiple entities in a service
 type BatchGetService struct {
 	service  *Service
@@ -304,32 +532,163 @@ type BatchGetServiceResponse struct {
 	Unprocessed map[string][]Keys
 }
 
-// Go executes the batch get operation across entities
+// Go executes the batch get operation across entities. Keys of every entity
+// behind the same client are coalesced into BatchGetItem calls of up to
+// MaxBatchGetItems keys, and entities on different tables share a call with
+// one RequestItems entry per table. Items and unprocessed keys that no
+// entity on their table owns are collected under UnknownEntity.
 func (bgs *BatchGetService) Go() (*BatchGetServiceResponse, error) {
 	result := &BatchGetServiceResponse{
 		Data:        make(map[string][]map[string]interface{}),
 		Unprocessed: make(map[string][]Keys),
 	}
 
-	// Execute batch get for each entity
-	for entityName, keys := range bgs.requests {
+	// Compose keys grouped by client, visiting entities in name order so the
+	// batches are reproducible
+	groups := make(map[DynamoDBClient]*batchGetGroup)
+	var clients []DynamoDBClient
+
+	names := make([]string, 0, len(bgs.requests))
+	for name := range bgs.requests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, entityName := range names {
 		entity, err := bgs.service.Entity(entityName)
 		if err != nil {
 			return nil, err
 		}
+		if entity.client == nil {
+			return nil, NewElectroError("NoClientProvided",
+				"No DynamoDB client was provided to the entity", nil)
+		}
+		group, exists := groups[entity.client]
+		if !exists {
+			group = &batchGetGroup{tables: make(map[string][]*Entity)}
+			groups[entity.client] = group
+			clients = append(clients, entity.client)
+		}
+		result.Data[entityName] = make([]map[string]interface{}, 0)
 
-		entityResult, err := entity.BatchGet(keys).Go()
-		if err != nil {
+		builder := NewParamsBuilder(entity)
+		tableName := builder.getTableName()
+		group.tables[tableName] = append(group.tables[tableName], entity)
+
+		for _, keySet := range bgs.requests[entityName] {
+			params, err := builder.BuildGetItemParams(keySet, nil)
+			if err != nil {
+				return nil, err
+			}
+			group.keys = append(group.keys, batchKey{table: tableName, key: params["Key"].(map[string]types.AttributeValue)})
+		}
+	}
+
+	for _, client := range clients {
+		if err := bgs.send(result, client, groups[client]); err != nil {
 			return nil, err
 		}
+	}
+	return result, nil
+}
+
+// batchGetGroup holds the composed keys of the entities behind one client
+type batchGetGroup struct {
+	keys   []batchKey
+	tables map[string][]*Entity // entities by table, to attribute items
+}
+
+// batchKey is a composed key and the table it is read from
+type batchKey struct {
+	table string
+	key   map[string]types.AttributeValue
+}
+
+// send issues the BatchGetItem calls for one client's keys, attributing each
+// returned item and unprocessed key to its entity, or to UnknownEntity
+func (bgs *BatchGetService) send(result *BatchGetServiceResponse, client DynamoDBClient, group *batchGetGroup) error {
+	keys := group.keys
+	for start := 0; start < len(keys); start += MaxBatchGetItems {
+		end := start + MaxBatchGetItems
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		requestItems := make(map[string]types.KeysAndAttributes)
+		for _, key := range keys[start:end] {
+			request := requestItems[key.table]
+			request.Keys = append(request.Keys, key.key)
+			requestItems[key.table] = request
+		}
+
+		response, err := client.BatchGetItem(bgs.ctx, &dynamodb.BatchGetItemInput{RequestItems: requestItems})
+		if err != nil {
+			return NewElectroError("DynamoDBError", "Failed to execute BatchGetItem", err)
+		}
+
+		for tableName, items := range response.Responses {
+			for _, image := range items {
+				var parsedItem map[string]interface{}
+				if err := attributevalue.UnmarshalMap(image, &parsedItem); err != nil {
+					return NewElectroError("UnmarshalError", "Failed to unmarshal response", err)
+				}
 
-		result.Data[entityName] = entityResult.Data
-		if len(entityResult.Unprocessed) > 0 {
-			result.Unprocessed[entityName] = entityResult.Unprocessed
+				// Items no entity owns are returned unformatted
+				entity := imageOwner(group.tables[tableName], image)
+				if entity == nil {
+					result.Data[UnknownEntity] = append(result.Data[UnknownEntity], parsedItem)
+					continue
+				}
+				formatted, err := NewExecutionHelper(entity).formatItem(parsedItem, false)
+				if err != nil {
+					return err
+				}
+				name := entity.schema.Entity
+				result.Data[name] = append(result.Data[name], formatted)
+			}
+		}
+
+		for tableName, unprocessed := range response.UnprocessedKeys {
+			for _, image := range unprocessed.Keys {
+				var parsedKey Keys
+				if err := attributevalue.UnmarshalMap(image, &parsedKey); err != nil {
+					// If unmarshaling fails, append empty keys to preserve count
+					parsedKey = Keys{}
+				}
+				name := UnknownEntity
+				if entity := imageOwner(group.tables[tableName], image); entity != nil {
+					name = entity.schema.Entity
+				}
+				result.Unprocessed[name] = append(result.Unprocessed[name], parsedKey)
+			}
 		}
 	}
+	return nil
+}
 
-	return result, nil
+// UnknownEntity is the entity name under which service batch responses
+// collect items and keys that none of the entities sharing their table owns
+const UnknownEntity = ""
+
+// batchTarget is a client and table that service batch requests are sent to.
+// Entities on the same table behind different clients are batched apart.
+type batchTarget struct {
+	client DynamoDBClient
+	table  string
+}
+
+// imageOwner returns the entity among those sharing a table that owns a raw
+// item; a table used by a single entity needs no matching
+func imageOwner(entities []*Entity, image map[string]types.AttributeValue) *Entity {
+	if len(entities) == 1 {
+		return entities[0]
+	}
+	for _, entity := range entities {
+		if entity.OwnsImage(image) {
+			return entity
+		}
+	}
+	return nil
 }
 
 // BatchWriteService creates a batch write request across multiple entities in a service
@@ -376,7 +735,11 @@ type BatchWriteServiceResponse struct {
 	}
 }
 
-// Go executes the batch write operation across entities
+// Go executes the batch write operation across entities. Puts and deletes
+// of every entity sharing a table and client are coalesced into
+// BatchWriteItem calls of up to MaxBatchWriteItems requests, and unprocessed
+// requests are attributed back to the entity that owns them, or collected
+// under UnknownEntity when no entity on the table claims them.
 func (bws *BatchWriteService) Go() (*BatchWriteServiceResponse, error) {
 	result := &BatchWriteServiceResponse{
 		Unprocessed: make(map[string]struct {
@@ -385,45 +748,135 @@ func (bws *BatchWriteService) Go() (*BatchWriteServiceResponse, error) {
 		}),
 	}
 
-	// Execute batch write for each entity (puts)
-	for entityName, items := range bws.puts {
+	// Group write requests by client and table, visiting entities in name
+	// order so the batches are reproducible
+	type tableWrites struct {
+		entities []*Entity
+		requests []types.WriteRequest
+	}
+	groups := make(map[batchTarget]*tableWrites)
+	var targets []batchTarget
+
+	names := make([]string, 0, len(bws.puts)+len(bws.deletes))
+	seen := make(map[string]bool)
+	for name := range bws.puts {
+		names = append(names, name)
+		seen[name] = true
+	}
+	for name := range bws.deletes {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, entityName := range names {
 		entity, err := bws.service.Entity(entityName)
 		if err != nil {
 			return nil, err
 		}
-
-		entityResult, err := entity.BatchWrite().Put(items).Go()
-		if err != nil {
+		if err := bws.service.checkWritable(entity, "batch write"); err != nil {
 			return nil, err
 		}
+		if entity.client == nil {
+			return nil, NewElectroError("NoClientProvided",
+				"No DynamoDB client was provided to the entity", nil)
+		}
 
-		if len(entityResult.Unprocessed.Puts) > 0 || len(entityResult.Unprocessed.Deletes) > 0 {
-			result.Unprocessed[entityName] = entityResult.Unprocessed
+		builder := NewParamsBuilder(entity).withContext(bws.ctx)
+		target := batchTarget{client: entity.client, table: builder.getTableName()}
+		writes, exists := groups[target]
+		if !exists {
+			writes = &tableWrites{}
+			groups[target] = writes
+			targets = append(targets, target)
 		}
-	}
+		writes.entities = append(writes.entities, entity)
 
-	// Execute batch write for each entity (deletes)
-	for entityName, keys := range bws.deletes {
-		entity, err := bws.service.Entity(entityName)
-		if err != nil {
-			return nil, err
+		for _, item := range bws.puts[entityName] {
+			params, err := builder.BuildPutItemParams(item, nil)
+			if err != nil {
+				return nil, err
+			}
+			writes.requests = append(writes.requests, types.WriteRequest{
+				PutRequest: &types.PutRequest{
+					Item: params["Item"].(map[string]types.AttributeValue),
+				},
+			})
 		}
 
-		entityResult, err := entity.BatchWrite().Delete(keys).Go()
-		if err != nil {
-			return nil, err
+		for _, keys := range bws.deletes[entityName] {
+			params, err := builder.BuildDeleteItemParams(keys, nil)
+			if err != nil {
+				return nil, err
+			}
+			writes.requests = append(writes.requests, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{
+					Key: params["Key"].(map[string]types.AttributeValue),
+				},
+			})
 		}
+	}
 
-		if len(entityResult.Unprocessed.Puts) > 0 || len(entityResult.Unprocessed.Deletes) > 0 {
-			if existing, ok := result.Unprocessed[entityName]; ok {
-				existing.Puts = append(existing.Puts, entityResult.Unprocessed.Puts...)
-				existing.Deletes = append(existing.Deletes, entityResult.Unprocessed.Deletes...)
-				result.Unprocessed[entityName] = existing
-			} else {
-				result.Unprocessed[entityName] = entityResult.Unprocessed
+	for _, target := range targets {
+		writes := groups[target]
+		for start := 0; start < len(writes.requests); start += MaxBatchWriteItems {
+			end := start + MaxBatchWriteItems
+			if end > len(writes.requests) {
+				end = len(writes.requests)
+			}
+
+			input := &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]types.WriteRequest{
+					target.table: writes.requests[start:end],
+				},
+			}
+			response, err := target.client.BatchWriteItem(bws.ctx, input)
+			if err != nil {
+				return nil, NewElectroError("DynamoDBError", "Failed to execute BatchWriteItem", err)
+			}
+
+			for _, writeReq := range response.UnprocessedItems[target.table] {
+				bws.addUnprocessed(result, writes.entities, writeReq)
 			}
 		}
 	}
 
 	return result, nil
 }
+
+// addUnprocessed records an unprocessed write request against the entity
+// whose key layout it matches, or under UnknownEntity when none does
+func (bws *BatchWriteService) addUnprocessed(result *BatchWriteServiceResponse, entities []*Entity, writeReq types.WriteRequest) {
+	var image map[string]types.AttributeValue
+	if writeReq.PutRequest != nil {
+		image = writeReq.PutRequest.Item
+	} else if writeReq.DeleteRequest != nil {
+		image = writeReq.DeleteRequest.Key
+	} else {
+		return
+	}
+
+	name := UnknownEntity
+	if entity := imageOwner(entities, image); entity != nil {
+		name = entity.schema.Entity
+	}
+
+	unprocessed := result.Unprocessed[name]
+	if writeReq.PutRequest != nil {
+		var parsedItem Item
+		if err := attributevalue.UnmarshalMap(image, &parsedItem); err != nil {
+			// If unmarshaling fails, append empty item to preserve count
+			parsedItem = Item{}
+		}
+		unprocessed.Puts = append(unprocessed.Puts, parsedItem)
+	} else {
+		var parsedKey Keys
+		if err := attributevalue.UnmarshalMap(image, &parsedKey); err != nil {
+			// If unmarshaling fails, append empty keys to preserve count
+			parsedKey = Keys{}
+		}
+		unprocessed.Deletes = append(unprocessed.Deletes, parsedKey)
+	}
+	result.Unprocessed[name] = unprocessed
+}
@@ -1,7 +1,14 @@
 package electrodb
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func TestBatchGetRequest(t *testing.T) {
@@ -42,6 +49,228 @@ func TestBatchGetRequest(t *testing.T) {
 	}
 }
 
+func TestBatchGetCountFound(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "TestEntity",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	// Only ids 1 and 3 exist
+	existing := map[string]bool{"$testservice#id_1": true, "$testservice#id_3": true}
+	var projection string
+	client := &mockClient{
+		batchGetItem: func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+			request := input.RequestItems["TestTable"]
+			if request.ProjectionExpression != nil {
+				projection = *request.ProjectionExpression
+			}
+			found := make([]map[string]types.AttributeValue, 0)
+			for _, key := range request.Keys {
+				if existing[key["pk"].(*types.AttributeValueMemberS).Value] {
+					found = append(found, key)
+				}
+			}
+			return &dynamodb.BatchGetItemOutput{
+				Responses: map[string][]map[string]types.AttributeValue{"TestTable": found},
+			}, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	count, err := entity.BatchGet([]Keys{{"id": "1"}, {"id": "2"}, {"id": "3"}, {"id": "4"}}).CountFound()
+	if err != nil {
+		t.Fatalf("CountFound failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 keys found, got %d", count)
+	}
+	if projection != "#pk, #sk" {
+		t.Errorf("Expected key-only projection, got %q", projection)
+	}
+
+	// Regular batch gets still fetch whole items
+	projection = ""
+	if _, err := entity.BatchGet([]Keys{{"id": "1"}}).Go(); err != nil {
+		t.Fatalf("BatchGet failed: %v", err)
+	}
+	if projection != "" {
+		t.Errorf("Expected no projection for Go, got %q", projection)
+	}
+}
+
+func TestBatchGetConsistentRead(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "TestEntity",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	var consistent []*bool
+	client := &mockClient{
+		batchGetItem: func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+			consistent = append(consistent, input.RequestItems["TestTable"].ConsistentRead)
+			return &dynamodb.BatchGetItemOutput{}, nil
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	if _, err := entity.BatchGet([]Keys{{"id": "1"}}).ConsistentRead().Go(); err != nil {
+		t.Fatalf("BatchGet failed: %v", err)
+	}
+	if _, err := entity.BatchGet([]Keys{{"id": "1"}}).Go(); err != nil {
+		t.Fatalf("BatchGet failed: %v", err)
+	}
+	if len(consistent) != 2 || consistent[0] == nil || !*consistent[0] || consistent[1] != nil {
+		t.Errorf("Expected ConsistentRead only on the first request, got %v", consistent)
+	}
+
+	// An entity keyed only through a global secondary index cannot read
+	// consistently
+	gsiOnly := &Schema{
+		Service:    "TestService",
+		Entity:     "GSIEntity",
+		Table:      "TestTable",
+		Attributes: schema.Attributes,
+		Indexes: map[string]*IndexDefinition{
+			"byId": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"id"}},
+			},
+		},
+	}
+	gsiEntity, err := NewEntity(gsiOnly, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	_, err = gsiEntity.BatchGet([]Keys{{"id": "1"}}).ConsistentRead().Go()
+	var electroErr *ElectroError
+	if !errors.As(err, &electroErr) || electroErr.Code != ErrInvalidOperation {
+		t.Errorf("Expected InvalidOperation, got %v", err)
+	}
+}
+
+func TestBatchRetryUnprocessed(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "TestEntity",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	// Each call processes only the first request it receives
+	var getCalls, writeCalls []int
+	client := &mockClient{
+		batchGetItem: func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+			keys := input.RequestItems["TestTable"].Keys
+			getCalls = append(getCalls, len(keys))
+			output := &dynamodb.BatchGetItemOutput{
+				Responses: map[string][]map[string]types.AttributeValue{"TestTable": keys[:1]},
+			}
+			if len(keys) > 1 {
+				output.UnprocessedKeys = map[string]types.KeysAndAttributes{
+					"TestTable": {Keys: keys[1:]},
+				}
+			}
+			return output, nil
+		},
+		batchWriteItem: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			requests := input.RequestItems["TestTable"]
+			writeCalls = append(writeCalls, len(requests))
+			output := &dynamodb.BatchWriteItemOutput{}
+			if len(requests) > 1 {
+				output.UnprocessedItems = map[string][]types.WriteRequest{"TestTable": requests[1:]}
+			}
+			return output, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	ctx := context.Background()
+	getResp, err := entity.BatchGet([]Keys{{"id": "1"}, {"id": "2"}, {"id": "3"}}).Go()
+	if err != nil {
+		t.Fatalf("BatchGet failed: %v", err)
+	}
+	found := len(getResp.Data)
+	for len(getResp.Unprocessed) > 0 {
+		if getResp, err = getResp.Retry(ctx); err != nil {
+			t.Fatalf("Retry failed: %v", err)
+		}
+		found += len(getResp.Data)
+	}
+	if found != 3 {
+		t.Errorf("Expected 3 items after retries, got %d", found)
+	}
+	if fmt.Sprint(getCalls) != "[3 2 1]" {
+		t.Errorf("Expected retries to resend only unprocessed keys, got %v", getCalls)
+	}
+
+	writeResp, err := entity.BatchWrite().
+		Put([]Item{{"id": "1", "name": "a"}, {"id": "2", "name": "b"}}).
+		Delete([]Keys{{"id": "3"}}).
+		Go()
+	if err != nil {
+		t.Fatalf("BatchWrite failed: %v", err)
+	}
+	if len(writeResp.Unprocessed.Puts) != 1 || len(writeResp.Unprocessed.Deletes) != 1 {
+		t.Fatalf("Expected one put and one delete unprocessed, got %+v", writeResp.Unprocessed)
+	}
+	for len(writeResp.Unprocessed.Puts)+len(writeResp.Unprocessed.Deletes) > 0 {
+		if writeResp, err = writeResp.Retry(ctx); err != nil {
+			t.Fatalf("Retry failed: %v", err)
+		}
+	}
+	if fmt.Sprint(writeCalls) != "[3 2 1]" {
+		t.Errorf("Expected retries to resend only unprocessed writes, got %v", writeCalls)
+	}
+
+	// Nothing left to retry is a no-op
+	if _, err := writeResp.Retry(ctx); err != nil {
+		t.Errorf("Expected retry of a complete response to succeed, got %v", err)
+	}
+	if len(writeCalls) != 3 {
+		t.Errorf("Expected no call for an empty retry, got %d calls", len(writeCalls))
+	}
+}
+
 func TestBatchWriteRequest(t *testing.T) {
 	schema := &Schema{
 		Service: "TestService",
@@ -87,6 +316,95 @@ func TestBatchWriteRequest(t *testing.T) {
 	}
 }
 
+func TestBatchWriteWithConditions(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "TestEntity",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	var batchCalls int
+	var transactCalls [][]types.TransactWriteItem
+	client := &mockClient{
+		batchWriteItem: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			batchCalls++
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+		transactWriteItems: func(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			transactCalls = append(transactCalls, input.TransactItems)
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	notExists := func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return ops.NotExists(attrs["id"])
+	}
+
+	// Conditions need the mode to be enabled explicitly
+	_, err = entity.BatchWrite().PutIf(Item{"id": "1"}, notExists).Go()
+	var electroErr *ElectroError
+	if !errors.As(err, &electroErr) || electroErr.Code != ErrInvalidOperation {
+		t.Fatalf("Expected InvalidOperation without WithConditions, got %v", err)
+	}
+
+	// Without any condition the batch still uses BatchWriteItem
+	if _, err := entity.BatchWrite().WithConditions().Put([]Item{{"id": "1"}}).Go(); err != nil {
+		t.Fatalf("BatchWrite failed: %v", err)
+	}
+	if batchCalls != 1 || len(transactCalls) != 0 {
+		t.Fatalf("Expected an unconditioned batch to use BatchWriteItem, got %d batch and %d transact calls", batchCalls, len(transactCalls))
+	}
+
+	// Any condition routes every write through transactions, 100 at a time
+	puts := make([]Item, 0, 150)
+	for i := 0; i < 150; i++ {
+		puts = append(puts, Item{"id": fmt.Sprintf("p%d", i)})
+	}
+	resp, err := entity.BatchWrite().
+		WithConditions().
+		Put(puts).
+		PutIf(Item{"id": "new"}, notExists).
+		DeleteIf(Keys{"id": "old"}, func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return attrs["name"].Eq("stale")
+		}).
+		Go()
+	if err != nil {
+		t.Fatalf("Conditional BatchWrite failed: %v", err)
+	}
+	if batchCalls != 1 {
+		t.Errorf("Expected no further BatchWriteItem calls, got %d", batchCalls)
+	}
+	if len(transactCalls) != 2 || len(transactCalls[0]) != 100 || len(transactCalls[1]) != 52 {
+		t.Fatalf("Expected transactions of 100 and 52 items, got %d calls", len(transactCalls))
+	}
+	if len(resp.Unprocessed.Puts)+len(resp.Unprocessed.Deletes) != 0 {
+		t.Errorf("Expected nothing unprocessed, got %+v", resp.Unprocessed)
+	}
+
+	last := transactCalls[1]
+	put, del := last[len(last)-2].Put, last[len(last)-1].Delete
+	if put == nil || put.ConditionExpression == nil || !strings.Contains(*put.ConditionExpression, "attribute_not_exists") {
+		t.Errorf("Expected the conditioned put to carry its condition, got %+v", put)
+	}
+	if del == nil || del.ConditionExpression == nil {
+		t.Errorf("Expected the conditioned delete to carry its condition, got %+v", del)
+	}
+}
+
 func TestBatchWriteTooLarge(t *testing.T) {
 	schema := &Schema{
 		Service: "TestService",
@@ -210,6 +528,87 @@ func TestServiceBatchGet(t *testing.T) {
 	}
 }
 
+func TestServiceBatchGetMultiTable(t *testing.T) {
+	var inputs []*dynamodb.BatchGetItemInput
+	client := &mockClient{
+		batchGetItem: func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+			inputs = append(inputs, input)
+			output := &dynamodb.BatchGetItemOutput{
+				Responses:       make(map[string][]map[string]types.AttributeValue),
+				UnprocessedKeys: make(map[string]types.KeysAndAttributes),
+			}
+			for table, request := range input.RequestItems {
+				for _, key := range request.Keys {
+					if key["pk"].(*types.AttributeValueMemberS).Value == "$testservice#id_prod2" {
+						output.UnprocessedKeys[table] = types.KeysAndAttributes{Keys: []map[string]types.AttributeValue{key}}
+						continue
+					}
+					output.Responses[table] = append(output.Responses[table], key)
+				}
+			}
+			return output, nil
+		},
+	}
+
+	service := NewService("TestService", &ServiceConfig{Client: client})
+	for _, schema := range []*Schema{
+		{
+			Service: "TestService",
+			Entity:  "User",
+			Table:   "UserTable",
+			Attributes: map[string]*AttributeDefinition{
+				"id": {Type: AttributeTypeString, Required: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {PK: FacetDefinition{Field: "pk", Facets: []string{"id"}}},
+			},
+		},
+		{
+			Service: "TestService",
+			Entity:  "Product",
+			Table:   "ProductTable",
+			Attributes: map[string]*AttributeDefinition{
+				"id": {Type: AttributeTypeString, Required: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {PK: FacetDefinition{Field: "pk", Facets: []string{"id"}}},
+			},
+		},
+	} {
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	result, err := service.BatchGet().
+		Get("User", []Keys{{"id": "user1"}, {"id": "user2"}}).
+		Get("Product", []Keys{{"id": "prod1"}, {"id": "prod2"}}).
+		Go()
+	if err != nil {
+		t.Fatalf("BatchGet failed: %v", err)
+	}
+
+	if len(inputs) != 1 {
+		t.Fatalf("Expected a single BatchGetItem call, got %d", len(inputs))
+	}
+	if len(inputs[0].RequestItems) != 2 ||
+		len(inputs[0].RequestItems["UserTable"].Keys) != 2 ||
+		len(inputs[0].RequestItems["ProductTable"].Keys) != 2 {
+		t.Errorf("Expected keys grouped by table, got %+v", inputs[0].RequestItems)
+	}
+
+	if len(result.Data["User"]) != 2 || len(result.Data["Product"]) != 1 {
+		t.Errorf("Expected 2 users and 1 product, got %v", result.Data)
+	}
+	if len(result.Unprocessed["Product"]) != 1 || len(result.Unprocessed["User"]) != 0 {
+		t.Errorf("Expected one unprocessed product key, got %v", result.Unprocessed)
+	}
+}
+
 func TestServiceBatchWrite(t *testing.T) {
 	service := NewService("TestService", &ServiceConfig{
 		Table: stringPtr("TestTable"),
@@ -265,3 +664,236 @@ func TestServiceBatchWrite(t *testing.T) {
 		t.Errorf("Expected 1 delete key, got %d", len(batchWriteRequest.deletes["User"]))
 	}
 }
+
+func TestServiceBatchWriteCoalesces(t *testing.T) {
+	var calls [][]types.WriteRequest
+	client := &mockClient{
+		batchWriteItem: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			requests := input.RequestItems["TestTable"]
+			calls = append(calls, requests)
+
+			// Leave the first team put of the first call unprocessed
+			output := &dynamodb.BatchWriteItemOutput{}
+			if len(calls) == 1 {
+				for _, req := range requests {
+					if req.PutRequest != nil && strings.HasPrefix(req.PutRequest.Item["sk"].(*types.AttributeValueMemberS).Value, "$team") {
+						output.UnprocessedItems = map[string][]types.WriteRequest{"TestTable": {req}}
+						break
+					}
+				}
+			}
+			return output, nil
+		},
+	}
+
+	service := NewService("TestService", &ServiceConfig{
+		Client: client,
+		Table:  stringPtr("TestTable"),
+	})
+	for _, name := range []string{"User", "Team"} {
+		schema := &Schema{
+			Service: "TestService",
+			Entity:  name,
+			Table:   "TestTable",
+			Version: "1",
+			Attributes: map[string]*AttributeDefinition{
+				"id":   {Type: AttributeTypeString, Required: true},
+				"name": {Type: AttributeTypeString},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+				},
+			},
+		}
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	items := func(prefix string, n int) []Item {
+		result := make([]Item, n)
+		for i := range result {
+			result[i] = Item{"id": fmt.Sprintf("%s%d", prefix, i), "name": "n"}
+		}
+		return result
+	}
+
+	// A few writes per entity share one BatchWriteItem call
+	result, err := service.BatchWrite().
+		Put("User", items("u", 3)).
+		Delete("User", []Keys{{"id": "u9"}}).
+		Put("Team", items("t", 3)).
+		Delete("Team", []Keys{{"id": "t9"}}).
+		Go()
+	if err != nil {
+		t.Fatalf("BatchWrite failed: %v", err)
+	}
+	if len(calls) != 1 || len(calls[0]) != 8 {
+		t.Fatalf("Expected 1 BatchWriteItem call with 8 requests, got %d calls", len(calls))
+	}
+	team := result.Unprocessed["Team"]
+	if len(team.Puts) != 1 || team.Puts[0]["id"] != "t0" {
+		t.Errorf("Expected the unprocessed team put to be attributed to Team, got %v", result.Unprocessed)
+	}
+	if _, ok := result.Unprocessed["User"]; ok {
+		t.Errorf("Expected no unprocessed user writes, got %v", result.Unprocessed["User"])
+	}
+
+	// Larger batches are split at MaxBatchWriteItems
+	calls = nil
+	if _, err := service.BatchWrite().Put("User", items("u", 20)).Put("Team", items("t", 10)).Go(); err != nil {
+		t.Fatalf("BatchWrite failed: %v", err)
+	}
+	if len(calls) != 2 || len(calls[0]) != MaxBatchWriteItems || len(calls[1]) != 5 {
+		t.Errorf("Expected calls of %d and 5 requests, got %d calls", MaxBatchWriteItems, len(calls))
+	}
+}
+
+func TestServiceBatchWriteGroupsByClient(t *testing.T) {
+	calls := make(map[string][]types.WriteRequest)
+	newClient := func(name string, unprocessed []types.WriteRequest) *mockClient {
+		return &mockClient{
+			batchWriteItem: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+				calls[name] = append(calls[name], input.RequestItems["TestTable"]...)
+				return &dynamodb.BatchWriteItemOutput{
+					UnprocessedItems: map[string][]types.WriteRequest{"TestTable": unprocessed},
+				}, nil
+			},
+		}
+	}
+
+	// An unprocessed write neither entity behind the shared client owns
+	foreign := types.WriteRequest{PutRequest: &types.PutRequest{Item: map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "$otherservice#id_x"},
+		"sk": &types.AttributeValueMemberS{Value: "$other_1"},
+	}}}
+	shared := newClient("shared", []types.WriteRequest{foreign})
+	replica := newClient("replica", nil)
+
+	service := NewService("TestService", &ServiceConfig{Table: stringPtr("TestTable")})
+	for name, client := range map[string]*mockClient{"User": shared, "Team": shared, "Account": replica} {
+		schema := &Schema{
+			Service: "TestService",
+			Entity:  name,
+			Table:   "TestTable",
+			Version: "1",
+			Attributes: map[string]*AttributeDefinition{
+				"id": {Type: AttributeTypeString, Required: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+				},
+			},
+		}
+		entity, err := NewEntity(schema, &Config{Client: client})
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	result, err := service.BatchWrite().
+		Put("User", []Item{{"id": "u1"}}).
+		Put("Team", []Item{{"id": "t1"}}).
+		Put("Account", []Item{{"id": "a1"}}).
+		Go()
+	if err != nil {
+		t.Fatalf("BatchWrite failed: %v", err)
+	}
+
+	if len(calls["shared"]) != 2 || len(calls["replica"]) != 1 {
+		t.Fatalf("Expected each entity's writes to go through its own client, got %d shared and %d replica", len(calls["shared"]), len(calls["replica"]))
+	}
+	if sk := calls["replica"][0].PutRequest.Item["sk"].(*types.AttributeValueMemberS).Value; sk != "$account_1" {
+		t.Errorf("Expected the account put on the replica client, got %s", sk)
+	}
+
+	unknown, ok := result.Unprocessed[UnknownEntity]
+	if !ok || len(unknown.Puts) != 1 || unknown.Puts[0]["pk"] != "$otherservice#id_x" {
+		t.Errorf("Expected the unowned write under UnknownEntity, got %v", result.Unprocessed)
+	}
+}
+
+func TestServiceBatchGetGroupsByClient(t *testing.T) {
+	foreign := map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "$otherservice#id_x"},
+		"sk": &types.AttributeValueMemberS{Value: "$other_1"},
+	}
+	calls := make(map[string]int)
+	newClient := func(name string) *mockClient {
+		return &mockClient{
+			batchGetItem: func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+				calls[name] += len(input.RequestItems["TestTable"].Keys)
+				output := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{
+					"TestTable": input.RequestItems["TestTable"].Keys,
+				}}
+				// The shared client also returns an item and an unprocessed
+				// key that neither of its entities owns
+				if name == "shared" {
+					output.Responses["TestTable"] = append(output.Responses["TestTable"], foreign)
+					output.UnprocessedKeys = map[string]types.KeysAndAttributes{"TestTable": {Keys: []map[string]types.AttributeValue{foreign}}}
+				}
+				return output, nil
+			},
+		}
+	}
+	shared, replica := newClient("shared"), newClient("replica")
+
+	service := NewService("TestService", &ServiceConfig{Table: stringPtr("TestTable")})
+	for name, client := range map[string]*mockClient{"User": shared, "Team": shared, "Account": replica} {
+		schema := &Schema{
+			Service: "TestService",
+			Entity:  name,
+			Table:   "TestTable",
+			Version: "1",
+			Attributes: map[string]*AttributeDefinition{
+				"id": {Type: AttributeTypeString, Required: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+				},
+			},
+		}
+		entity, err := NewEntity(schema, &Config{Client: client})
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	result, err := service.BatchGet().
+		Get("User", []Keys{{"id": "u1"}}).
+		Get("Team", []Keys{{"id": "t1"}}).
+		Get("Account", []Keys{{"id": "a1"}}).
+		Go()
+	if err != nil {
+		t.Fatalf("BatchGet failed: %v", err)
+	}
+
+	if calls["shared"] != 2 || calls["replica"] != 1 {
+		t.Fatalf("Expected each entity's keys to go through its own client, got %v", calls)
+	}
+	if len(result.Data["Account"]) != 1 || len(result.Data["User"]) != 1 || len(result.Data["Team"]) != 1 {
+		t.Errorf("Expected one item per entity, got %v", result.Data)
+	}
+	if items := result.Data[UnknownEntity]; len(items) != 1 || items[0]["pk"] != "$otherservice#id_x" {
+		t.Errorf("Expected the unowned item under UnknownEntity, got %v", result.Data[UnknownEntity])
+	}
+	if keys := result.Unprocessed[UnknownEntity]; len(keys) != 1 || keys[0]["pk"] != "$otherservice#id_x" {
+		t.Errorf("Expected the unowned key under UnknownEntity, got %v", result.Unprocessed)
+	}
+}
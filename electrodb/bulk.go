@@ -0,0 +1,121 @@
+package electrodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// BulkResult reports the outcome of a bulk write
+type BulkResult struct {
+	Written     int // items put
+	Deleted     int // items deleted
+	Unprocessed struct {
+		Puts    []Item
+		Deletes []Keys
+	}
+	Attempts int // BatchWriteItem calls made, including retries
+}
+
+// BulkPut writes any number of items in BatchWriteItem calls of up to
+// MaxBatchWriteItems. Writes left unprocessed are re-sent with backoff while
+// Config.Retry allows more attempts; whatever remains is reported in
+// Unprocessed.
+func (e *Entity) BulkPut(ctx context.Context, items []Item) (*BulkResult, error) {
+	bwr := e.BatchWrite().Put(items)
+	bwr.ctx = ctx
+	return bwr.bulk()
+}
+
+// BulkDelete deletes any number of items, with the same batching and retries
+// as BulkPut
+func (e *Entity) BulkDelete(ctx context.Context, keys []Keys) (*BulkResult, error) {
+	bwr := e.BatchWrite().Delete(keys)
+	bwr.ctx = ctx
+	return bwr.bulk()
+}
+
+// bulk sends the batch's writes in chunks, retrying unprocessed writes in
+// rounds until none remain or Config.Retry attempts run out
+func (bwr *BatchWriteRequest) bulk() (*BulkResult, error) {
+	result := &BulkResult{}
+	if len(bwr.puts)+len(bwr.deletes) == 0 {
+		return result, nil
+	}
+	if err := bwr.entity.checkWritable("bulk write"); err != nil {
+		return nil, err
+	}
+
+	if bwr.entity.client == nil {
+		return nil, NewElectroError("NoClientProvided",
+			"No DynamoDB client was provided to the entity", nil)
+	}
+
+	pending, err := bwr.writeRequests()
+	if err != nil {
+		return nil, err
+	}
+
+	rounds := 1
+	var delay, maxDelay time.Duration
+	if cfg := bwr.entity.config.Retry; cfg != nil && cfg.MaxAttempts > 1 {
+		rounds, delay, maxDelay = cfg.MaxAttempts, cfg.BaseDelay, cfg.MaxDelay
+	}
+
+	tableName := NewParamsBuilder(bwr.entity).getTableName()
+	var last *BatchWriteResponse
+	for round := 1; ; round++ {
+		last = &BatchWriteResponse{entity: bwr.entity}
+		for i := 0; i < len(pending); i += MaxBatchWriteItems {
+			end := i + MaxBatchWriteItems
+			if end > len(pending) {
+				end = len(pending)
+			}
+
+			result.Attempts++
+			batchResult, err := writeBatch(bwr.ctx, bwr.entity, tableName, pending[i:end])
+			if err != nil {
+				return nil, err
+			}
+			last.Unprocessed.Puts = append(last.Unprocessed.Puts, batchResult.Unprocessed.Puts...)
+			last.Unprocessed.Deletes = append(last.Unprocessed.Deletes, batchResult.Unprocessed.Deletes...)
+			last.unprocessed = append(last.unprocessed, batchResult.unprocessed...)
+		}
+
+		pending = last.unprocessed
+		if len(pending) == 0 || round >= rounds {
+			break
+		}
+
+		select {
+		case <-bwr.ctx.Done():
+			return nil, NewElectroError("DynamoDBError", "Bulk write canceled", bwr.ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if maxDelay > 0 && delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+
+	result.Unprocessed.Puts = last.Unprocessed.Puts
+	result.Unprocessed.Deletes = last.Unprocessed.Deletes
+	unprocessedPuts, unprocessedDeletes := countWrites(pending)
+	result.Written = len(bwr.puts) - unprocessedPuts
+	result.Deleted = len(bwr.deletes) - unprocessedDeletes
+	return result, nil
+}
+
+// countWrites counts the puts and deletes among write requests
+func countWrites(requests []types.WriteRequest) (puts, deletes int) {
+	for _, request := range requests {
+		if request.PutRequest != nil {
+			puts++
+		}
+		if request.DeleteRequest != nil {
+			deletes++
+		}
+	}
+	return puts, deletes
+}
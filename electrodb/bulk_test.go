@@ -0,0 +1,82 @@
+package electrodb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestBulkResultCounts(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "TestEntity",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	// Each call leaves its last two requests unprocessed
+	var calls []int
+	client := &mockClient{
+		batchWriteItem: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			requests := input.RequestItems["TestTable"]
+			calls = append(calls, len(requests))
+			output := &dynamodb.BatchWriteItemOutput{}
+			if len(requests) > 2 {
+				output.UnprocessedItems = map[string][]types.WriteRequest{"TestTable": requests[len(requests)-2:]}
+			}
+			return output, nil
+		},
+	}
+
+	items := make([]Item, 30)
+	keys := make([]Keys, 30)
+	for i := range items {
+		items[i] = Item{"id": fmt.Sprint(i)}
+		keys[i] = Keys{"id": fmt.Sprint(i)}
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// Without retries, unprocessed writes are reported
+	result, err := entity.BulkPut(context.Background(), items)
+	if err != nil {
+		t.Fatalf("BulkPut failed: %v", err)
+	}
+	if result.Written != 26 || result.Deleted != 0 || len(result.Unprocessed.Puts) != 4 || result.Attempts != 2 {
+		t.Errorf("Expected 26 written, 4 unprocessed in 2 attempts, got %+v", result)
+	}
+	if fmt.Sprint(calls) != "[25 5]" {
+		t.Errorf("Expected chunks of 25, got %v", calls)
+	}
+
+	// With retries, unprocessed writes are re-sent until done
+	calls = nil
+	entity, err = NewEntity(schema, &Config{Client: client, Retry: &RetryConfig{MaxAttempts: 3}})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	result, err = entity.BulkDelete(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("BulkDelete failed: %v", err)
+	}
+	if result.Deleted != 30 || result.Written != 0 || len(result.Unprocessed.Deletes) != 0 || result.Attempts != 4 {
+		t.Errorf("Expected 30 deleted in 4 attempts, got %+v", result)
+	}
+	if fmt.Sprint(calls) != "[25 5 4 2]" {
+		t.Errorf("Expected retries to resend only unprocessed writes, got %v", calls)
+	}
+}
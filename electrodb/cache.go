@@ -0,0 +1,134 @@
+package electrodb
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// cachedRead is the serialized form of a cached Get or Query result: the raw
+// items, so the read pipeline still runs on every hit
+type cachedRead struct {
+	Items   []map[string]interface{} `json:"items"`
+	LastKey map[string]interface{}   `json:"lastKey,omitempty"`
+}
+
+// cacheEnabled reports whether a read may be served from the entity's cache;
+// consistent reads always go to DynamoDB
+func (eh *ExecutionHelper) cacheEnabled(consistent *bool) bool {
+	return eh.entity.config.Cache != nil && (consistent == nil || !*consistent)
+}
+
+// getCacheKey serializes the parameters of a GetItem request
+func getCacheKey(input *dynamodb.GetItemInput) (string, error) {
+	return cacheKey(map[string]interface{}{
+		"op":         "get",
+		"table":      stringPtrOrEmpty(input.TableName),
+		"key":        attributeMapToInterface(input.Key),
+		"projection": stringPtrOrEmpty(input.ProjectionExpression),
+		"names":      input.ExpressionAttributeNames,
+	})
+}
+
+// queryCacheKey serializes the parameters of a Query request
+func queryCacheKey(input *dynamodb.QueryInput) (string, error) {
+	params := map[string]interface{}{
+		"op":           "query",
+		"table":        stringPtrOrEmpty(input.TableName),
+		"index":        stringPtrOrEmpty(input.IndexName),
+		"keyCondition": stringPtrOrEmpty(input.KeyConditionExpression),
+		"filter":       stringPtrOrEmpty(input.FilterExpression),
+		"projection":   stringPtrOrEmpty(input.ProjectionExpression),
+		"names":        input.ExpressionAttributeNames,
+		"values":       attributeMapToInterface(input.ExpressionAttributeValues),
+		"startKey":     attributeMapToInterface(input.ExclusiveStartKey),
+		"scanForward":  input.ScanIndexForward,
+		"limit":        input.Limit,
+	}
+	return cacheKey(params)
+}
+
+// cacheKey serializes request parameters; json.Marshal orders map keys, so
+// identical parameters always produce the same key
+func cacheKey(params map[string]interface{}) (string, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// cachedItems looks up a cached read; an empty key never hits
+func (eh *ExecutionHelper) cachedItems(key string) (items []map[string]types.AttributeValue, lastKey map[string]types.AttributeValue, ok bool) {
+	if key == "" {
+		return nil, nil, false
+	}
+	data, found := eh.entity.config.Cache.Get(key)
+	if !found {
+		return nil, nil, false
+	}
+
+	var cached cachedRead
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, nil, false
+	}
+	for _, item := range cached.Items {
+		decoded, err := interfaceToAttributeMap(item)
+		if err != nil {
+			return nil, nil, false
+		}
+		items = append(items, decoded)
+	}
+	if cached.LastKey != nil {
+		decoded, err := interfaceToAttributeMap(cached.LastKey)
+		if err != nil {
+			return nil, nil, false
+		}
+		lastKey = decoded
+	}
+	return items, lastKey, true
+}
+
+// cacheSet stores a read for Config.CacheTTL. Results that cannot be
+// serialized are simply not cached.
+func (eh *ExecutionHelper) cacheSet(key string, items []map[string]types.AttributeValue, lastKey map[string]types.AttributeValue) {
+	cached := cachedRead{Items: make([]map[string]interface{}, 0, len(items))}
+	for _, item := range items {
+		cached.Items = append(cached.Items, attributeMapToInterface(item))
+	}
+	if len(lastKey) > 0 {
+		cached.LastKey = attributeMapToInterface(lastKey)
+	}
+
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	eh.entity.config.Cache.Set(key, data, eh.entity.config.CacheTTL)
+}
+
+// attributeMapToInterface converts an item to its typed JSON form
+func attributeMapToInterface(item map[string]types.AttributeValue) map[string]interface{} {
+	if item == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(item))
+	for name, value := range item {
+		result[name] = attributeValueToInterface(value)
+	}
+	return result
+}
+
+// interfaceToAttributeMap converts an item back from its typed JSON form
+func interfaceToAttributeMap(item map[string]interface{}) (map[string]types.AttributeValue, error) {
+	result := make(map[string]types.AttributeValue, len(item))
+	for name, value := range item {
+		av, err := interfaceToAttributeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = av
+	}
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package electrodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type memoryCache struct {
+	entries map[string][]byte
+	ttls    map[string]time.Duration
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	value, ok := c.entries[key]
+	return value, ok
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.entries[key] = value
+	c.ttls[key] = ttl
+}
+
+func TestReadCache(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"category":  {Type: AttributeTypeString, Required: true},
+			"productId": {Type: AttributeTypeString, Required: true},
+			"price":     {Type: AttributeTypeNumber},
+			"notes":     {Type: AttributeTypeString, Compress: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"category"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"productId"}},
+			},
+		},
+	}
+
+	notes, err := compressValue("fragile", AttributeTypeString)
+	if err != nil {
+		t.Fatalf("compressValue failed: %v", err)
+	}
+	stored := map[string]types.AttributeValue{
+		"pk":        &types.AttributeValueMemberS{Value: "$testservice#category_tools"},
+		"sk":        &types.AttributeValueMemberS{Value: "$product_1#productid_p1"},
+		"category":  &types.AttributeValueMemberS{Value: "tools"},
+		"productId": &types.AttributeValueMemberS{Value: "p1"},
+		"price":     &types.AttributeValueMemberN{Value: "12.5"},
+		"notes":     &types.AttributeValueMemberB{Value: notes},
+	}
+	var gets, queries int
+	client := &mockClient{
+		getItem: func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			gets++
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			queries++
+			return &dynamodb.QueryOutput{
+				Items:            []map[string]types.AttributeValue{stored},
+				LastEvaluatedKey: map[string]types.AttributeValue{"pk": stored["pk"], "sk": stored["sk"]},
+			}, nil
+		},
+	}
+
+	cache := newMemoryCache()
+	entity, err := NewEntity(schema, &Config{Client: client, Cache: cache, CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	first, err := entity.Query("primary").Query("tools").Go()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	second, err := entity.Query("primary").Query("tools").Go()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if queries != 1 {
+		t.Errorf("Expected the identical query to be served from the cache, got %d client calls", queries)
+	}
+	if len(second.Data) != 1 || second.Data[0]["notes"] != "fragile" || second.Data[0]["price"] != first.Data[0]["price"] {
+		t.Errorf("Expected the cached result to match the first, got %v and %v", first.Data, second.Data)
+	}
+	if second.Cursor == nil || *second.Cursor != *first.Cursor {
+		t.Errorf("Expected the cached cursor to match the first")
+	}
+
+	// Different parameters miss the cache
+	if _, err := entity.Query("primary").Query("garden").Go(); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if queries != 2 {
+		t.Errorf("Expected a different query to reach the client, got %d calls", queries)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := entity.Get(Keys{"category": "tools", "productId": "p1"}).Go(); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+	if gets != 1 {
+		t.Errorf("Expected the repeated Get to be served from the cache, got %d client calls", gets)
+	}
+
+	// Consistent reads always go to DynamoDB
+	consistent := &GetOptions{Consistent: boolPtr(true)}
+	for i := 0; i < 2; i++ {
+		if _, err := entity.Get(Keys{"category": "tools", "productId": "p1"}).Options(consistent).Go(); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+	if gets != 3 {
+		t.Errorf("Expected consistent reads to bypass the cache, got %d client calls", gets)
+	}
+
+	if len(cache.entries) != 3 {
+		t.Errorf("Expected 3 cached reads, got %d", len(cache.entries))
+	}
+	for key, ttl := range cache.ttls {
+		if ttl != time.Minute {
+			t.Errorf("Expected CacheTTL for %s, got %v", key, ttl)
+		}
+	}
+}
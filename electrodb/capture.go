@@ -0,0 +1,129 @@
+package electrodb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// CapturedRequest is a DynamoDB call recorded by a Capture
+type CapturedRequest struct {
+	// Operation is the DynamoDB operation name, e.g. "PutItem"
+	Operation string
+	// Input is the generated request, e.g. a *dynamodb.PutItemInput
+	Input interface{}
+}
+
+// Capture records the DynamoDB requests an entity generates instead of
+// sending them, so tests can assert on the exact inputs without a client.
+// Calls return empty outputs unless responses are queued with Replay. The
+// zero value is ready to use; Capture also implements DynamoDBClient, so it
+// can be passed as a service's Client.
+type Capture struct {
+	mu        sync.Mutex
+	requests  []CapturedRequest
+	responses map[string][]capturedResponse
+}
+
+type capturedResponse struct {
+	output interface{}
+	err    error
+}
+
+// Requests returns the recorded requests in call order
+func (c *Capture) Requests() []CapturedRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CapturedRequest(nil), c.requests...)
+}
+
+// Replay queues a canned response for the next call of operation. output
+// must be the operation's output type, e.g. *dynamodb.GetItemOutput, and is
+// ignored when err is set. Responses for an operation are used in order.
+func (c *Capture) Replay(operation string, output interface{}, err error) *Capture {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.responses == nil {
+		c.responses = make(map[string][]capturedResponse)
+	}
+	c.responses[operation] = append(c.responses[operation], capturedResponse{output: output, err: err})
+	return c
+}
+
+// Reset clears the recorded requests and any queued responses
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = nil
+	c.responses = nil
+}
+
+// captureCall records input and returns the next replayed response for the
+// operation, or an empty output
+func captureCall[Out any](c *Capture, operation string, input interface{}) (*Out, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, CapturedRequest{Operation: operation, Input: input})
+
+	queue := c.responses[operation]
+	if len(queue) == 0 {
+		return new(Out), nil
+	}
+	next := queue[0]
+	c.responses[operation] = queue[1:]
+	if next.err != nil {
+		return nil, next.err
+	}
+	output, ok := next.output.(*Out)
+	if !ok {
+		return nil, NewElectroError("InvalidOperation",
+			fmt.Sprintf("Replayed %s response is %T, expected %T", operation, next.output, output), nil)
+	}
+	return output, nil
+}
+
+func (c *Capture) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return captureCall[dynamodb.GetItemOutput](c, "GetItem", params)
+}
+
+func (c *Capture) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return captureCall[dynamodb.PutItemOutput](c, "PutItem", params)
+}
+
+func (c *Capture) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return captureCall[dynamodb.UpdateItemOutput](c, "UpdateItem", params)
+}
+
+func (c *Capture) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return captureCall[dynamodb.DeleteItemOutput](c, "DeleteItem", params)
+}
+
+func (c *Capture) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return captureCall[dynamodb.QueryOutput](c, "Query", params)
+}
+
+func (c *Capture) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return captureCall[dynamodb.ScanOutput](c, "Scan", params)
+}
+
+func (c *Capture) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return captureCall[dynamodb.BatchGetItemOutput](c, "BatchGetItem", params)
+}
+
+func (c *Capture) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return captureCall[dynamodb.BatchWriteItemOutput](c, "BatchWriteItem", params)
+}
+
+func (c *Capture) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return captureCall[dynamodb.TransactWriteItemsOutput](c, "TransactWriteItems", params)
+}
+
+func (c *Capture) TransactGetItems(ctx context.Context, params *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	return captureCall[dynamodb.TransactGetItemsOutput](c, "TransactGetItems", params)
+}
+
+func (c *Capture) ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error) {
+	return captureCall[dynamodb.ExecuteStatementOutput](c, "ExecuteStatement", params)
+}
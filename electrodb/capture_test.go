@@ -0,0 +1,133 @@
+package electrodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestCaptureRecordsRequests(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	capture := &Capture{}
+	entity, err := NewEntity(schema, &Config{Capture: capture})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	if _, err := entity.Put(Item{"id": "u1", "name": "Alice"}).Go(); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := entity.Update(Keys{"id": "u1"}).Set(map[string]interface{}{"name": "Bob"}).Go(); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	requests := capture.Requests()
+	if len(requests) != 2 || requests[0].Operation != "PutItem" || requests[1].Operation != "UpdateItem" {
+		t.Fatalf("Expected PutItem then UpdateItem, got %+v", requests)
+	}
+
+	put := requests[0].Input.(*dynamodb.PutItemInput)
+	if *put.TableName != "TestTable" {
+		t.Errorf("Expected TestTable, got %s", *put.TableName)
+	}
+	if pk, ok := put.Item["pk"].(*types.AttributeValueMemberS); !ok || pk.Value != "$testservice#id_u1" {
+		t.Errorf("Unexpected pk: %#v", put.Item["pk"])
+	}
+	if name, ok := put.Item["name"].(*types.AttributeValueMemberS); !ok || name.Value != "Alice" {
+		t.Errorf("Unexpected name: %#v", put.Item["name"])
+	}
+
+	update := requests[1].Input.(*dynamodb.UpdateItemInput)
+	if pk, ok := update.Key["pk"].(*types.AttributeValueMemberS); !ok || pk.Value != "$testservice#id_u1" {
+		t.Errorf("Unexpected key: %#v", update.Key)
+	}
+	if *update.UpdateExpression != "SET #attr0 = :val0" || update.ExpressionAttributeNames["#attr0"] != "name" {
+		t.Errorf("Unexpected update expression: %s %v", *update.UpdateExpression, update.ExpressionAttributeNames)
+	}
+	if name, ok := update.ExpressionAttributeValues[":val0"].(*types.AttributeValueMemberS); !ok || name.Value != "Bob" {
+		t.Errorf("Unexpected update value: %#v", update.ExpressionAttributeValues[":val0"])
+	}
+
+	capture.Reset()
+	if len(capture.Requests()) != 0 {
+		t.Errorf("Expected Reset to clear requests")
+	}
+}
+
+func TestCaptureReplay(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	failure := errors.New("boom")
+	capture := (&Capture{}).
+		Replay("GetItem", &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+			"pk":   &types.AttributeValueMemberS{Value: "$testservice#id_u1"},
+			"sk":   &types.AttributeValueMemberS{Value: "$user_1"},
+			"id":   &types.AttributeValueMemberS{Value: "u1"},
+			"name": &types.AttributeValueMemberS{Value: "Alice"},
+		}}, nil).
+		Replay("GetItem", nil, failure).
+		Replay("DeleteItem", &dynamodb.PutItemOutput{}, nil)
+	entity, err := NewEntity(schema, &Config{Capture: capture})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// Replayed responses are used in order
+	result, err := entity.Get(Keys{"id": "u1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if result.Data["name"] != "Alice" {
+		t.Errorf("Expected the replayed item, got %v", result.Data)
+	}
+	if _, err := entity.Get(Keys{"id": "u1"}).Go(); !errors.Is(err, failure) {
+		t.Errorf("Expected the replayed error, got %v", err)
+	}
+
+	// Once the queue is drained, calls get empty outputs
+	result, err = entity.Get(Keys{"id": "u1"}).Go()
+	if err != nil || result.Data != nil {
+		t.Errorf("Expected no item, got %v, %v", result, err)
+	}
+
+	// A response of the wrong type is reported
+	var electroErr *ElectroError
+	if _, err := entity.Delete(Keys{"id": "u1"}).Go(); !errors.As(err, &electroErr) {
+		t.Errorf("Expected an error for a mismatched replay, got %v", err)
+	}
+
+	if len(capture.Requests()) != 4 {
+		t.Errorf("Expected 4 recorded requests, got %d", len(capture.Requests()))
+	}
+}
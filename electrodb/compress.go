@@ -0,0 +1,139 @@
+package electrodb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// compressAttributes gzip-compresses the values of attributes marked
+// Compress, which are then stored as binary. Strings and []byte values are
+// compressed as-is; other values are JSON-encoded first.
+func compressAttributes(item Item, schema *Schema) (Item, error) {
+	var result Item
+	for attrName, attrDef := range schema.Attributes {
+		if !attrDef.Compress {
+			continue
+		}
+
+		value, exists := item[attrName]
+		if !exists || value == nil {
+			continue
+		}
+
+		compressed, err := compressValue(value, attrDef.Type)
+		if err != nil {
+			return nil, NewElectroError("MarshalError", fmt.Sprintf("Failed to compress attribute %s", attrName), err)
+		}
+
+		// Copy on first write so the caller's item is left untouched
+		if result == nil {
+			result = make(Item, len(item))
+			for k, v := range item {
+				result[k] = v
+			}
+		}
+		result[attrName] = compressed
+	}
+
+	if result == nil {
+		return item, nil
+	}
+	return result, nil
+}
+
+// decompressAttributes reverses compressAttributes when reading. Values that
+// are not gzip data (e.g. written before Compress was enabled) are left as-is.
+func decompressAttributes(item map[string]interface{}, schema *Schema) map[string]interface{} {
+	if item == nil {
+		return nil
+	}
+
+	for attrName, attrDef := range schema.Attributes {
+		if !attrDef.Compress {
+			continue
+		}
+
+		data, ok := item[attrName].([]byte)
+		if !ok {
+			continue
+		}
+
+		if value, err := decompressValue(data, attrDef.Type); err == nil {
+			item[attrName] = value
+		}
+	}
+
+	return item
+}
+
+// compressValue gzips a single value of the given attribute type
+func compressValue(value interface{}, attrType AttributeType) ([]byte, error) {
+	raw, err := encodeValue(value, attrType)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressValue gunzips a value back into the attribute's type
+func decompressValue(data []byte, attrType AttributeType) (interface{}, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(raw, attrType), nil
+}
+
+// encodeValue converts a value to bytes for compression or encryption.
+// String and Binary attribute values are used as-is; everything else is
+// JSON-encoded, strings included, so an Any or Enum value like "123" is not
+// read back as a number.
+func encodeValue(value interface{}, attrType AttributeType) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		if attrType == AttributeTypeString {
+			return []byte(v), nil
+		}
+	case []byte:
+		if attrType == AttributeTypeBinary {
+			return v, nil
+		}
+	}
+	return json.Marshal(value)
+}
+
+// decodeValue reverses encodeValue for the attribute's type
+func decodeValue(raw []byte, attrType AttributeType) interface{} {
+	switch attrType {
+	case AttributeTypeString:
+		return string(raw)
+	case AttributeTypeBinary:
+		return raw
+	}
+
+	// Other types were JSON-encoded; values written before strings were
+	// JSON-encoded too are not valid JSON and are returned as strings
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw)
+	}
+	return value
+}
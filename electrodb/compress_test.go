@@ -0,0 +1,134 @@
+package electrodb
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestCompressedAttributes(t *testing.T) {
+	var stored map[string]types.AttributeValue
+	client := &mockClient{
+		putItem: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			stored = input.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+	}
+
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Document",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"body":     {Type: AttributeTypeString, Compress: true},
+			"metadata": {Type: AttributeTypeMap, Compress: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	body := strings.Repeat(`{"line":"a fairly repetitive json blob"},`, 2000)
+	item := Item{
+		"id":       "doc-1",
+		"body":     body,
+		"metadata": map[string]interface{}{"author": "alice", "pages": float64(3)},
+	}
+	if _, err := entity.Put(item).Go(); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// Stored as smaller binary values
+	storedBody, ok := stored["body"].(*types.AttributeValueMemberB)
+	if !ok {
+		t.Fatalf("Expected body to be stored as binary, got %#v", stored["body"])
+	}
+	if len(storedBody.Value) >= len(body) {
+		t.Errorf("Expected compressed body (%d bytes) to be smaller than %d bytes", len(storedBody.Value), len(body))
+	}
+	if _, ok := stored["metadata"].(*types.AttributeValueMemberB); !ok {
+		t.Errorf("Expected metadata to be stored as binary, got %#v", stored["metadata"])
+	}
+
+	// Read back transparently
+	result, err := entity.Get(Keys{"id": "doc-1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if result.Data["body"] != body {
+		t.Error("Expected body to round-trip through compression")
+	}
+	metadata, ok := result.Data["metadata"].(map[string]interface{})
+	if !ok || metadata["author"] != "alice" || metadata["pages"] != float64(3) {
+		t.Errorf("Expected metadata to round-trip, got %#v", result.Data["metadata"])
+	}
+
+	// Update Set values are compressed too
+	params, err := entity.Update(Keys{"id": "doc-1"}).Set(map[string]interface{}{"body": "short"}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build update params: %v", err)
+	}
+	values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	found := false
+	for _, value := range values {
+		if _, ok := value.(*types.AttributeValueMemberB); ok {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the compressed body among update values, got %v", values)
+	}
+}
+
+func TestCompressedValuesKeepTheirType(t *testing.T) {
+	tests := []struct {
+		attrType AttributeType
+		value    interface{}
+	}{
+		{AttributeTypeAny, "123"},
+		{AttributeTypeAny, "true"},
+		{AttributeTypeAny, float64(123)},
+		{AttributeTypeEnum, "42"},
+		{AttributeTypeString, "null"},
+		{AttributeTypeBinary, []byte("raw")},
+	}
+
+	for _, test := range tests {
+		data, err := compressValue(test.value, test.attrType)
+		if err != nil {
+			t.Fatalf("Failed to compress %#v: %v", test.value, err)
+		}
+		value, err := decompressValue(data, test.attrType)
+		if err != nil {
+			t.Fatalf("Failed to decompress %#v: %v", test.value, err)
+		}
+		if fmt.Sprintf("%#v", value) != fmt.Sprintf("%#v", test.value) {
+			t.Errorf("%s: expected %#v to round-trip, got %#v", test.attrType, test.value, value)
+		}
+	}
+
+	// Strings compressed before they were JSON-encoded still read back
+	legacy, err := compressValue([]byte("hello"), AttributeTypeBinary)
+	if err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	if value, err := decompressValue(legacy, AttributeTypeAny); err != nil || value != "hello" {
+		t.Errorf("Expected a raw legacy string to read back as-is, got %#v, %v", value, err)
+	}
+}
@@ -0,0 +1,141 @@
+package electrodb
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+)
+
+// constraintPatterns caches compiled AttributeDefinition.Pattern expressions
+var constraintPatterns sync.Map
+
+// compilePattern returns the compiled form of a Pattern constraint
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := constraintPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	constraintPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// validateConstraints checks that declared constraints are consistent and
+// that every Pattern compiles
+func validateConstraints(schema *Schema) error {
+	for _, name := range sortedAttributeNames(schema.Attributes) {
+		attr := schema.Attributes[name]
+		if (attr.MinLength != nil && *attr.MinLength < 0) || (attr.MaxLength != nil && *attr.MaxLength < 0) {
+			return NewElectroError("InvalidSchema",
+				fmt.Sprintf("Attribute '%s' has a negative length constraint", name), nil)
+		}
+		if attr.MinLength != nil && attr.MaxLength != nil && *attr.MinLength > *attr.MaxLength {
+			return NewElectroError("InvalidSchema",
+				fmt.Sprintf("Attribute '%s' has MinLength greater than MaxLength", name), nil)
+		}
+		if attr.Min != nil && attr.Max != nil && *attr.Min > *attr.Max {
+			return NewElectroError("InvalidSchema",
+				fmt.Sprintf("Attribute '%s' has Min greater than Max", name), nil)
+		}
+		if attr.Pattern != "" {
+			if _, err := compilePattern(attr.Pattern); err != nil {
+				return NewElectroError("InvalidSchema",
+					fmt.Sprintf("Attribute '%s' has an invalid Pattern", name), err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkConstraints enforces the attribute's declarative constraints on a
+// value being written, before it is padded. Nil values are not checked;
+// Required covers them.
+func checkConstraints(name string, value interface{}, attr *AttributeDefinition) error {
+	if value == nil {
+		return nil
+	}
+
+	if attr.MinLength != nil || attr.MaxLength != nil {
+		length, ok := constraintLength(value)
+		if !ok {
+			return constraintViolation(name, "Length", fmt.Sprintf("a length constraint applies to strings, lists and sets, got %T", value))
+		}
+		if attr.MinLength != nil && length < *attr.MinLength {
+			return constraintViolation(name, "MinLength", fmt.Sprintf("length %d is less than %d", length, *attr.MinLength))
+		}
+		if attr.MaxLength != nil && length > *attr.MaxLength {
+			return constraintViolation(name, "MaxLength", fmt.Sprintf("length %d is greater than %d", length, *attr.MaxLength))
+		}
+	}
+
+	if attr.Min != nil || attr.Max != nil {
+		number, ok := constraintNumber(value)
+		if !ok {
+			return constraintViolation(name, "Range", fmt.Sprintf("Min and Max apply to numbers, got %T", value))
+		}
+		if attr.Min != nil && number < *attr.Min {
+			return constraintViolation(name, "Min", fmt.Sprintf("%v is less than %v", value, *attr.Min))
+		}
+		if attr.Max != nil && number > *attr.Max {
+			return constraintViolation(name, "Max", fmt.Sprintf("%v is greater than %v", value, *attr.Max))
+		}
+	}
+
+	if attr.Pattern != "" {
+		str, ok := value.(string)
+		if !ok {
+			return constraintViolation(name, "Pattern", fmt.Sprintf("Pattern applies to strings, got %T", value))
+		}
+		re, err := compilePattern(attr.Pattern)
+		if err != nil {
+			return NewElectroError("InvalidSchema",
+				fmt.Sprintf("Attribute '%s' has an invalid Pattern", name), err)
+		}
+		if !re.MatchString(str) {
+			return constraintViolation(name, "Pattern", fmt.Sprintf("%q does not match %s", str, attr.Pattern))
+		}
+	}
+
+	return nil
+}
+
+// constraintViolation reports a failed constraint; Details names the field
+// and, as its code, the constraint
+func constraintViolation(name, constraint, reason string) error {
+	message := fmt.Sprintf("Attribute '%s' violates %s: %s", name, constraint, reason)
+	err := NewElectroError("ConstraintViolation", message, nil)
+	err.Details = []FieldError{{Field: name, Code: constraint, Message: message}}
+	return err
+}
+
+// constraintLength returns the characters of a string or the elements of a
+// list or set
+func constraintLength(value interface{}) (int, bool) {
+	if str, ok := value.(string); ok {
+		return utf8.RuneCountInString(str), true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len(), true
+	}
+	return 0, false
+}
+
+// constraintNumber converts any Go numeric value to float64
+func constraintNumber(value interface{}) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
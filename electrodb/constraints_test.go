@@ -0,0 +1,128 @@
+package electrodb
+
+import (
+	"errors"
+	"testing"
+)
+
+func intPtr(i int) *int { return &i }
+
+func floatPtr(f float64) *float64 { return &f }
+
+func TestAttributeConstraints(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"username": {Type: AttributeTypeString, MinLength: intPtr(3), MaxLength: intPtr(8)},
+			"tags":     {Type: AttributeTypeList, MaxLength: intPtr(2)},
+			"age":      {Type: AttributeTypeNumber, Min: floatPtr(0), Max: floatPtr(150)},
+			"rank":     {Type: AttributeTypeNumber, Min: floatPtr(1), Padding: &PaddingConfig{Length: 4, Char: "0"}},
+			"zip":      {Type: AttributeTypeString, Pattern: `^[0-9]{5}$`},
+			"code":     {Type: AttributeTypeString, MaxLength: intPtr(3), Padding: &PaddingConfig{Length: 5, Char: "0"}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		attr       string
+		value      interface{}
+		constraint string // "" when the value is valid
+	}{
+		{"string within length", "username", "alice", ""},
+		{"multibyte string counts characters", "username", "ééé", ""},
+		{"string too short", "username", "al", "MinLength"},
+		{"string too long", "username", "alexander", "MaxLength"},
+		{"list within length", "tags", []string{"a", "b"}, ""},
+		{"list too long", "tags", []string{"a", "b", "c"}, "MaxLength"},
+		{"number within range", "age", 30, ""},
+		{"number below min", "age", -1, "Min"},
+		{"number above max", "age", 150.5, "Max"},
+		{"non-number", "age", "thirty", "Range"},
+		{"padded number within range", "rank", 7, ""},
+		{"padded number below min", "rank", 0, "Min"},
+		{"padded string checked before padding", "code", "ab", ""},
+		{"padded string too long", "code", "abcd", "MaxLength"},
+		{"string matching pattern", "zip", "12345", ""},
+		{"string not matching pattern", "zip", "1234a", "Pattern"},
+		{"nil is not checked", "zip", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, putErr := entity.Put(Item{"id": "u1", tt.attr: tt.value}).Params()
+			_, updateErr := entity.Update(Keys{"id": "u1"}).Set(map[string]interface{}{tt.attr: tt.value}).Params()
+			for op, err := range map[string]error{"put": putErr, "update": updateErr} {
+				if tt.constraint == "" {
+					if err != nil {
+						t.Errorf("%s: unexpected error: %v", op, err)
+					}
+					continue
+				}
+				var electroErr *ElectroError
+				if !errors.As(err, &electroErr) || electroErr.Code != ErrConstraintViolation {
+					t.Errorf("%s: expected ConstraintViolation, got %v", op, err)
+					continue
+				}
+				if len(electroErr.Details) != 1 || electroErr.Details[0].Field != tt.attr || electroErr.Details[0].Code != tt.constraint {
+					t.Errorf("%s: expected %s violation on %s, got %+v", op, tt.constraint, tt.attr, electroErr.Details)
+				}
+			}
+		})
+	}
+
+	// ValidateAll reports every violated constraint
+	err = entity.ValidateAll(Item{"id": "u1", "username": "al", "zip": "x"})
+	var electroErr *ElectroError
+	if !errors.As(err, &electroErr) || len(electroErr.Details) != 2 {
+		t.Fatalf("Expected two violations, got %v", err)
+	}
+	for _, detail := range electroErr.Details {
+		if detail.Code != ErrConstraintViolation {
+			t.Errorf("Expected ConstraintViolation details, got %+v", detail)
+		}
+	}
+}
+
+func TestAttributeConstraintSchemaValidation(t *testing.T) {
+	tests := map[string]*AttributeDefinition{
+		"negative length":      {Type: AttributeTypeString, MinLength: intPtr(-1)},
+		"min length above max": {Type: AttributeTypeString, MinLength: intPtr(5), MaxLength: intPtr(2)},
+		"min above max":        {Type: AttributeTypeNumber, Min: floatPtr(10), Max: floatPtr(1)},
+		"invalid pattern":      {Type: AttributeTypeString, Pattern: `([`},
+	}
+
+	for name, attr := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewEntity(&Schema{
+				Service: "TestService",
+				Entity:  "User",
+				Table:   "TestTable",
+				Attributes: map[string]*AttributeDefinition{
+					"id":    {Type: AttributeTypeString, Required: true},
+					"value": attr,
+				},
+				Indexes: map[string]*IndexDefinition{
+					"primary": {
+						PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+					},
+				},
+			}, nil)
+			var electroErr *ElectroError
+			if !errors.As(err, &electroErr) || electroErr.Code != ErrInvalidSchema {
+				t.Errorf("Expected InvalidSchema, got %v", err)
+			}
+		})
+	}
+}
@@ -113,8 +113,13 @@ func interfaceToAttributeValue(val interface{}) (types.AttributeValue, error) {
 	if n, ok := m["N"].(string); ok {
 		return &types.AttributeValueMemberN{Value: n}, nil
 	}
-	if b, ok := m["B"].([]byte); ok {
-		return &types.AttributeValueMemberB{Value: b}, nil
+	if b, ok := m["B"].(string); ok {
+		// encoding/json writes []byte as base64
+		decoded, err := base64.StdEncoding.DecodeString(b)
+		if err != nil {
+			return nil, NewElectroError("CursorDecodingError", "Invalid binary value", err)
+		}
+		return &types.AttributeValueMemberB{Value: decoded}, nil
 	}
 	if b, ok := m["BOOL"].(bool); ok {
 		return &types.AttributeValueMemberBOOL{Value: b}, nil
@@ -140,6 +145,18 @@ func interfaceToAttributeValue(val interface{}) (types.AttributeValue, error) {
 		}
 		return &types.AttributeValueMemberNS{Value: strSlice}, nil
 	}
+	if bs, ok := m["BS"].([]interface{}); ok {
+		byteSlices := make([][]byte, len(bs))
+		for i, v := range bs {
+			str, _ := v.(string)
+			decoded, err := base64.StdEncoding.DecodeString(str)
+			if err != nil {
+				return nil, NewElectroError("CursorDecodingError", "Invalid binary value", err)
+			}
+			byteSlices[i] = decoded
+		}
+		return &types.AttributeValueMemberBS{Value: byteSlices}, nil
+	}
 	if mapVal, ok := m["M"].(map[string]interface{}); ok {
 		attrMap := make(map[string]types.AttributeValue)
 		for k, v := range mapVal {
@@ -0,0 +1,116 @@
+package electrodb
+
+import (
+	"fmt"
+)
+
+// validateEncryption checks that attributes marked Encrypt have an encryptor
+// to use and are not key facets, whose plaintext would be composed into keys
+func validateEncryption(schema *Schema, config *Config) error {
+	facets := make(map[string]string)
+	for indexName, index := range schema.Indexes {
+		for _, facet := range index.PK.Facets {
+			facets[facet] = indexName
+		}
+		if index.SK != nil {
+			for _, facet := range index.SK.Facets {
+				facets[facet] = indexName
+			}
+		}
+	}
+
+	for _, attrName := range sortedAttributeNames(schema.Attributes) {
+		if !schema.Attributes[attrName].Encrypt {
+			continue
+		}
+		if config.Encryptor == nil {
+			return NewElectroError("InvalidSchema",
+				fmt.Sprintf("Attribute '%s' is encrypted but no Encryptor is configured", attrName), nil)
+		}
+		if indexName, isFacet := facets[attrName]; isFacet {
+			return NewElectroError("InvalidSchema",
+				fmt.Sprintf("Attribute '%s' is a facet of index '%s' and cannot be encrypted", attrName, indexName), nil)
+		}
+	}
+	return nil
+}
+
+// encryptAttributes encrypts the values of attributes marked Encrypt, which
+// are then stored as binary. It runs after compression, so a compressed
+// attribute is encrypted in its compressed form.
+func encryptAttributes(item Item, schema *Schema, encryptor Encryptor) (Item, error) {
+	var result Item
+	for attrName, attrDef := range schema.Attributes {
+		if !attrDef.Encrypt {
+			continue
+		}
+
+		value, exists := item[attrName]
+		if !exists || value == nil {
+			continue
+		}
+
+		// Compressed values are already gzip bytes
+		encodeType := attrDef.Type
+		if attrDef.Compress {
+			encodeType = AttributeTypeBinary
+		}
+		plaintext, err := encodeValue(value, encodeType)
+		if err != nil {
+			return nil, NewElectroError("MarshalError", fmt.Sprintf("Failed to encode attribute %s", attrName), err)
+		}
+		ciphertext, err := encryptor.Encrypt(attrName, plaintext)
+		if err != nil {
+			return nil, NewElectroError("EncryptionError", fmt.Sprintf("Failed to encrypt attribute %s", attrName), err)
+		}
+
+		// Copy on first write so the caller's item is left untouched
+		if result == nil {
+			result = make(Item, len(item))
+			for k, v := range item {
+				result[k] = v
+			}
+		}
+		result[attrName] = ciphertext
+	}
+
+	if result == nil {
+		return item, nil
+	}
+	return result, nil
+}
+
+// decryptAttributes reverses encryptAttributes when reading. Non-binary
+// values (e.g. written before Encrypt was enabled) are left as-is; binary
+// values that fail to decrypt fail with DecryptionFailed rather than
+// returning ciphertext as the attribute's value.
+func decryptAttributes(item map[string]interface{}, schema *Schema, encryptor Encryptor) (map[string]interface{}, error) {
+	if item == nil || encryptor == nil {
+		return item, nil
+	}
+
+	for attrName, attrDef := range schema.Attributes {
+		if !attrDef.Encrypt {
+			continue
+		}
+
+		ciphertext, ok := item[attrName].([]byte)
+		if !ok {
+			continue
+		}
+
+		plaintext, err := encryptor.Decrypt(attrName, ciphertext)
+		if err != nil {
+			return nil, NewElectroError("DecryptionFailed", fmt.Sprintf("Failed to decrypt attribute %s", attrName), err)
+		}
+
+		// Compressed values stay binary for decompressAttributes
+		if attrDef.Compress {
+			item[attrName] = plaintext
+		} else {
+			item[attrName] = decodeValue(plaintext, attrDef.Type)
+		}
+	}
+
+	return item, nil
+}
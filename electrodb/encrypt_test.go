@@ -0,0 +1,172 @@
+package electrodb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// xorEncryptor is a reversible stand-in for a real cipher; it binds the
+// attribute name by prefixing it to the ciphertext
+type xorEncryptor struct{}
+
+func (xorEncryptor) Encrypt(attribute string, plaintext []byte) ([]byte, error) {
+	out := []byte(attribute + ":")
+	for _, b := range plaintext {
+		out = append(out, b^0x5a)
+	}
+	return out, nil
+}
+
+func (xorEncryptor) Decrypt(attribute string, ciphertext []byte) ([]byte, error) {
+	prefix := []byte(attribute + ":")
+	if !bytes.HasPrefix(ciphertext, prefix) {
+		return nil, errors.New("ciphertext bound to another attribute")
+	}
+	out := make([]byte, 0, len(ciphertext)-len(prefix))
+	for _, b := range ciphertext[len(prefix):] {
+		out = append(out, b^0x5a)
+	}
+	return out, nil
+}
+
+func TestEncryptedAttributes(t *testing.T) {
+	var stored map[string]types.AttributeValue
+	client := &mockClient{
+		putItem: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			stored = input.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+	}
+
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Account",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":     {Type: AttributeTypeString, Required: true},
+			"ssn":    {Type: AttributeTypeString, Encrypt: true},
+			"limits": {Type: AttributeTypeMap, Encrypt: true, Compress: true},
+			"apiKey": {Type: AttributeTypeString, Encrypt: true, Hidden: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	if _, err := NewEntity(schema, &Config{Client: client}); err == nil {
+		t.Fatal("Expected encrypted attributes without an Encryptor to be rejected")
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client, Encryptor: xorEncryptor{}})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	_, err = entity.Put(Item{
+		"id":     "acct-1",
+		"ssn":    "123-45-6789",
+		"limits": map[string]interface{}{"daily": float64(500)},
+		"apiKey": "sk_live_abc",
+	}).Go()
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	for _, name := range []string{"ssn", "limits", "apiKey"} {
+		ciphertext, ok := stored[name].(*types.AttributeValueMemberB)
+		if !ok {
+			t.Fatalf("Expected %s to be stored as binary, got %T", name, stored[name])
+		}
+		if bytes.Contains(ciphertext.Value, []byte("123-45-6789")) || bytes.Contains(ciphertext.Value, []byte("sk_live_abc")) {
+			t.Errorf("Expected %s to be stored without plaintext", name)
+		}
+	}
+
+	resp, err := entity.Get(Keys{"id": "acct-1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if resp.Data["ssn"] != "123-45-6789" {
+		t.Errorf("Expected decrypted ssn, got %v", resp.Data["ssn"])
+	}
+	limits, ok := resp.Data["limits"].(map[string]interface{})
+	if !ok || limits["daily"] != float64(500) {
+		t.Errorf("Expected decrypted and decompressed limits, got %v", resp.Data["limits"])
+	}
+	if _, ok := resp.Data["apiKey"]; ok {
+		t.Error("Expected the hidden encrypted attribute not to be returned")
+	}
+
+	facetSchema := *schema
+	facetSchema.Attributes = map[string]*AttributeDefinition{
+		"id": {Type: AttributeTypeString, Required: true, Encrypt: true},
+	}
+	if _, err := NewEntity(&facetSchema, &Config{Encryptor: xorEncryptor{}}); err == nil {
+		t.Error("Expected an encrypted facet to be rejected")
+	}
+}
+
+func TestDecryptionFailure(t *testing.T) {
+	var stored map[string]types.AttributeValue
+	client := &mockClient{
+		putItem: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			stored = input.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+	}
+
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Account",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":    {Type: AttributeTypeString, Required: true},
+			"ssn":   {Type: AttributeTypeString, Encrypt: true},
+			"score": {Type: AttributeTypeAny, Encrypt: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client, Encryptor: xorEncryptor{}})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if _, err := entity.Put(Item{"id": "acct-1", "ssn": "123-45-6789", "score": "42"}).Go(); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// An Any string keeps its type through encryption
+	resp, err := entity.Get(Keys{"id": "acct-1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if resp.Data["score"] != "42" {
+		t.Errorf("Expected score to read back as the string \"42\", got %#v", resp.Data["score"])
+	}
+
+	// Ciphertext bound to another attribute fails instead of being returned
+	stored["ssn"] = stored["score"]
+	var electroErr *ElectroError
+	if _, err := entity.Get(Keys{"id": "acct-1"}).Go(); !errors.As(err, &electroErr) || electroErr.Code != ErrDecryptionFailed {
+		t.Errorf("Expected DecryptionFailed, got %v", err)
+	}
+}
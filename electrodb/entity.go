@@ -3,14 +3,32 @@ package electrodb
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/execute008/goelectrodb/electrodb/internal"
 )
 
 // Entity represents a DynamoDB entity with schema and operations
 type Entity struct {
-	schema *Schema
-	config *Config
-	client DynamoDBClient
-	query  map[string]QueryBuilder
+	schema       *Schema
+	config       *Config
+	client       DynamoDBClient
+	keyTemplates map[string]indexKeyTemplates // by index name, see newKeyTemplates
+
+	// ownConfig is the entity's own configuration; Service.Join merges
+	// service settings onto a copy of it and never modifies it
+	ownConfig *Config
+}
+
+// indexKeyTemplates are the precomputed key layouts of one index; sk is nil
+// for an index without a sort key
+type indexKeyTemplates struct {
+	pk *internal.KeyTemplate
+	sk *internal.KeyTemplate
 }
 
 // NewEntity creates a new Entity instance
@@ -27,21 +45,112 @@ func NewEntity(schema *Schema, config *Config) (*Entity, error) {
 		config = &Config{}
 	}
 
-	entity := &Entity{
-		schema: schema,
-		config: config,
-		client: config.Client,
-		query:  make(map[string]QueryBuilder),
+	if err := validateEncryption(schema, config); err != nil {
+		return nil, err
 	}
 
-	// Initialize query builders for each index
-	for accessPattern, index := range schema.Indexes {
-		entity.query[accessPattern] = newQueryBuilder(entity, accessPattern, index)
+	entity := &Entity{
+		schema:    schema,
+		ownConfig: config,
 	}
+	entity.useConfig(config)
+	entity.keyTemplates = entity.newKeyTemplates()
 
 	return entity, nil
 }
 
+// newKeyTemplates precomputes the key layout of every index, so composing
+// keys on write does not rebuild labels for each item
+func (e *Entity) newKeyTemplates() map[string]indexKeyTemplates {
+	templates := make(map[string]indexKeyTemplates, len(e.schema.Indexes))
+	for name, index := range e.schema.Indexes {
+		templates[name] = e.newIndexKeyTemplates(index)
+	}
+	return templates
+}
+
+func (e *Entity) newIndexKeyTemplates(index *IndexDefinition) indexKeyTemplates {
+	delimiters := e.keyDelimiters()
+	t := indexKeyTemplates{pk: internal.NewKeyTemplate(index.PK.Facets, index.PK.Casing, delimiters)}
+	if index.SK != nil {
+		t.sk = internal.NewKeyTemplate(index.SK.Facets, index.SK.Casing, delimiters)
+	}
+	return t
+}
+
+// now returns the current time from the configured Clock
+func (e *Entity) now() time.Time {
+	if e.config.Clock == nil {
+		return time.Now()
+	}
+	return e.config.Clock.Now()
+}
+
+// timeFormat returns the configured time.Time serialization format
+func (e *Entity) timeFormat() TimeFormat {
+	if e.config.TimeFormat == "" {
+		return TimeFormatRFC3339
+	}
+	return e.config.TimeFormat
+}
+
+// checkWritable fails with ReadOnlyMode when the entity, or the service it
+// last joined, is read-only
+func (e *Entity) checkWritable(operation string) error {
+	if e.config.ReadOnly {
+		return readOnlyEntityError(e, operation)
+	}
+	return nil
+}
+
+func readOnlyEntityError(e *Entity, operation string) error {
+	return NewElectroError("ReadOnlyMode",
+		fmt.Sprintf("Entity '%s' is read-only; %s is not allowed", e.schema.Entity, operation), nil)
+}
+
+// keyDelimiters returns the delimiters used to compose this entity's keys
+func (e *Entity) keyDelimiters() internal.Delimiters {
+	if e.schema.Delimiters == nil {
+		return internal.DefaultDelimiters
+	}
+	return internal.Delimiters{
+		Prefix: e.schema.Delimiters.Prefix,
+		Facet:  e.schema.Delimiters.Facet,
+		Value:  e.schema.Delimiters.Value,
+		Escape: e.schema.Delimiters.Escape,
+	}.OrDefault()
+}
+
+// newConditionBuilder returns a condition builder for the entity. Besides
+// the attributes, its callbacks can reference the index key fields, e.g.
+// attrs["sk"].Begins("$order_1") to scope a conditional overwrite.
+func (e *Entity) newConditionBuilder() *ConditionBuilder {
+	cb := NewConditionBuilder(e.schema.Attributes)
+	cb.builder.timeFormat = e.timeFormat()
+	cb.builder.keyFields = e.indexKeyFields()
+	return cb
+}
+
+// indexKeyFields returns the key fields of every index, in name order
+func (e *Entity) indexKeyFields() []string {
+	seen := make(map[string]bool)
+	var fields []string
+	for _, index := range e.schema.Indexes {
+		candidates := []string{index.PK.Field}
+		if index.SK != nil {
+			candidates = append(candidates, index.SK.Field)
+		}
+		for _, field := range candidates {
+			if field != "" && !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
 // validateSchema validates the entity schema
 func validateSchema(schema *Schema) error {
 	if schema.Service == "" {
@@ -83,7 +192,31 @@ func validateSchema(schema *Schema) error {
 		}
 	}
 
-	return nil
+	// Aliases must not shadow attributes or each other
+	aliases := make(map[string]string)
+	for name, attr := range schema.Attributes {
+		for _, alias := range attr.Aliases {
+			if _, exists := schema.Attributes[alias]; exists {
+				return NewElectroError("InvalidSchema",
+					fmt.Sprintf("Alias '%s' of attribute '%s' is itself an attribute", alias, name), nil)
+			}
+			if other, exists := aliases[alias]; exists {
+				return NewElectroError("InvalidSchema",
+					fmt.Sprintf("Alias '%s' is declared by both '%s' and '%s'", alias, other, name), nil)
+			}
+			aliases[alias] = name
+		}
+	}
+
+	if err := validateGenerators(schema); err != nil {
+		return err
+	}
+
+	if err := validateConstraints(schema); err != nil {
+		return err
+	}
+
+	return validateTTLConfig(schema)
 }
 
 // Get retrieves an item by its key
@@ -104,6 +237,60 @@ func (e *Entity) Put(item Item) *PutOperation {
 	}
 }
 
+// PutStruct puts a Go struct, converted to an Item using its `dynamodbav`
+// struct tags (see attributevalue.MarshalMap), through the same validation,
+// transform and key pipeline as Put. Conversion errors are returned by Go or
+// Params.
+func (e *Entity) PutStruct(v interface{}) *PutOperation {
+	item, err := structToItem(v)
+	return &PutOperation{
+		entity: e,
+		item:   item,
+		ctx:    context.Background(),
+		err:    err,
+	}
+}
+
+// structToItem converts a tagged struct into an Item
+func structToItem(v interface{}) (Item, error) {
+	av, err := attributevalue.MarshalMap(v)
+	if err != nil {
+		return nil, NewElectroError("MarshalError", "Failed to marshal struct", err)
+	}
+	var item Item
+	if err := attributevalue.UnmarshalMap(av, &item); err != nil {
+		return nil, NewElectroError("MarshalError", "Failed to convert struct to item", err)
+	}
+	return item, nil
+}
+
+// itemToStruct decodes a formatted item into out, a pointer to a struct
+// tagged like those accepted by PutStruct
+func itemToStruct(item map[string]interface{}, out interface{}) error {
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return NewElectroError("MarshalError", "Failed to marshal item", err)
+	}
+	if err := attributevalue.UnmarshalMap(av, out); err != nil {
+		return NewElectroError("UnmarshalError", "Failed to decode item into struct", err)
+	}
+	return nil
+}
+
+// GetStruct gets an item and decodes it into out, a pointer to a tagged
+// struct, after read transforms and hidden attribute filtering. It reports
+// whether the item was found; out is left untouched when it was not.
+func (e *Entity) GetStruct(keys Keys, out interface{}) (bool, error) {
+	result, err := e.Get(keys).Go()
+	if err != nil {
+		return false, err
+	}
+	if result.Data == nil {
+		return false, nil
+	}
+	return true, itemToStruct(result.Data, out)
+}
+
 // Create creates a new item (fails if exists)
 func (e *Entity) Create(item Item) *PutOperation {
 	op := &PutOperation{
@@ -154,6 +341,60 @@ func (e *Entity) Patch(keys Keys) *UpdateOperation {
 	return e.Update(keys)
 }
 
+// Diff returns an update that turns the stored item before into after:
+// attributes whose value changed are set and attributes after no longer holds
+// are removed. Undeclared attributes, ReadOnly attributes and index facets
+// are skipped, and the keys come from before's primary key facets. Numbers
+// compare by value, so an int and the float64 read back for it are equal.
+func (e *Entity) Diff(before, after Item) *UpdateOperation {
+	keys := Keys{}
+	facets := make(map[string]bool)
+	for _, index := range e.schema.Indexes {
+		indexFacets := index.PK.Facets
+		if index.SK != nil {
+			indexFacets = append(append([]string{}, indexFacets...), index.SK.Facets...)
+		}
+		for _, facet := range indexFacets {
+			facets[facet] = true
+			if value, ok := before[facet]; ok && index.Index == nil {
+				keys[facet] = value
+			}
+		}
+	}
+
+	update := e.Update(keys)
+	var removed []string
+	for _, name := range sortedAttributeNames(e.schema.Attributes) {
+		if facets[name] || e.schema.Attributes[name].ReadOnly {
+			continue
+		}
+		oldValue, hadValue := before[name]
+		newValue, hasValue := after[name]
+		hadValue = hadValue && oldValue != nil
+		hasValue = hasValue && newValue != nil
+		switch {
+		case hasValue && (!hadValue || !sameValue(oldValue, newValue)):
+			update.setOps[name] = newValue
+		case hadValue && !hasValue:
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) > 0 {
+		update.Remove(removed)
+	}
+	return update
+}
+
+// sameValue reports whether two attribute values are equal, comparing
+// numbers by value regardless of their Go type
+func sameValue(a, b interface{}) bool {
+	if x, ok := constraintNumber(a); ok {
+		y, ok := constraintNumber(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
 // Upsert creates or updates an item using UpdateItem
 // Unlike Upsert(), which uses Put, this uses Update which allows partial updates
 // This will create the item if it doesn't exist, or update specific attributes if it does
@@ -184,12 +425,28 @@ func (e *Entity) Scan() *ScanOperation {
 	}
 }
 
-// Query returns a query builder for the specified access pattern
+// Query returns a query builder for the specified access pattern.
+// A fresh builder is created on every call, so a single Entity can be
+// shared across goroutines without queries leaking state into each other.
 func (e *Entity) Query(accessPattern string) QueryBuilder {
-	if qb, exists := e.query[accessPattern]; exists {
-		return qb
+	index, exists := e.schema.Indexes[accessPattern]
+	if !exists {
+		return nil
 	}
-	return nil
+	return newQueryBuilder(e, accessPattern, index)
+}
+
+// Validate checks an item against the schema without building params or writing.
+// It runs the required-attribute, key facet, type, enum and custom validations
+// and returns the first violation found, or nil if the item is valid.
+func (e *Entity) Validate(item Item) error {
+	return NewValidator(e).ValidateItem(item)
+}
+
+// ValidateAll is like Validate but reports every violation at once in a
+// ValidationFailed error whose Details list each offending field.
+func (e *Entity) ValidateAll(item Item) error {
+	return NewValidator(e).ValidateItemAll(item)
 }
 
 // Schema returns the entity schema
@@ -197,6 +454,25 @@ func (e *Entity) Schema() *Schema {
 	return e.schema
 }
 
+// WithClient attaches a DynamoDB client to the entity, e.g. one created at
+// runtime for an entity defined at package init. The entity's config is
+// copied first, so other entities sharing the same Config are unaffected.
+func (e *Entity) WithClient(client DynamoDBClient) *Entity {
+	e.ownConfig = e.ownConfig.WithClient(client)
+	e.useConfig(e.config.WithClient(client))
+	return e
+}
+
+// useConfig makes config the entity's effective configuration; a Capture
+// takes the place of the client
+func (e *Entity) useConfig(config *Config) {
+	e.config = config
+	e.client = config.Client
+	if config.Capture != nil {
+		e.client = config.Capture
+	}
+}
+
 // GetOperation represents a get operation
 type GetOperation struct {
 	entity  *Entity
@@ -205,6 +481,12 @@ type GetOperation struct {
 	ctx     context.Context
 }
 
+// Options sets get options
+func (g *GetOperation) Options(opts *GetOptions) *GetOperation {
+	g.options = opts
+	return g
+}
+
 // Go executes the get operation
 func (g *GetOperation) Go() (*GetResponse, error) {
 	executor := NewExecutionHelper(g.entity)
@@ -224,26 +506,81 @@ type PutOperation struct {
 	options          *PutOptions
 	ctx              context.Context
 	conditionBuilder *ConditionBuilder
+	err              error // deferred error from building the item, e.g. PutStruct
+}
+
+// Options sets put options
+func (p *PutOperation) Options(opts *PutOptions) *PutOperation {
+	p.options = opts
+	return p
+}
+
+// WithContext sets the context the put runs with; attribute defaults set with
+// DefaultFromContext are evaluated with it
+func (p *PutOperation) WithContext(ctx context.Context) *PutOperation {
+	p.ctx = ctx
+	return p
 }
 
 // Condition adds a condition expression to the put operation
 func (p *PutOperation) Condition(callback WhereCallback) *PutOperation {
-	cb := NewConditionBuilder(p.entity.schema.Attributes)
+	cb := p.entity.newConditionBuilder()
 	cb.Where(callback)
 	p.conditionBuilder = cb
 	return p
 }
 
+// IfNewer makes the put overwrite a stored item only when the incoming value
+// of attr is greater than the stored one, so replayed or out-of-order events
+// cannot overwrite newer data; new items are always written. The put fails
+// with a conditional check failure when the stored item is as new or newer.
+// It replaces any condition set with Condition.
+func (p *PutOperation) IfNewer(attr string) *PutOperation {
+	if _, exists := p.entity.schema.Attributes[attr]; !exists {
+		p.err = NewElectroError("UnknownAttribute", fmt.Sprintf("Attribute '%s' is not defined in the schema", attr), nil)
+		return p
+	}
+	incoming, ok := p.item[attr]
+	if !ok || incoming == nil {
+		p.err = NewElectroError("MissingAttribute", fmt.Sprintf("IfNewer requires a value for '%s'", attr), nil)
+		return p
+	}
+	index := p.entity.primaryIndex()
+	if index == nil {
+		p.err = NewElectroError("InvalidSchema", "No primary index found", nil)
+		return p
+	}
+
+	// Compare against the value as it is stored, e.g. a padded number
+	// stored as a string
+	stored := ApplyPadding(ApplyNormalization(Item{attr: incoming}, p.entity.schema), p.entity.schema)[attr]
+
+	return p.Condition(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return ops.NotExists(ops.Path(index.PK.Field)) + " OR " + attrs[attr].Lt(stored)
+	})
+}
+
 // Go executes the put operation
 func (p *PutOperation) Go() (*PutResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
 	executor := NewExecutionHelper(p.entity)
-	return executor.ExecutePutItem(p.ctx, p.item, p.options)
+	return executor.ExecutePutItem(p.ctx, p.item, p.options, p.conditionBuilder)
 }
 
 // Params returns the DynamoDB parameters without executing
 func (p *PutOperation) Params() (map[string]interface{}, error) {
-	builder := NewParamsBuilder(p.entity)
-	return builder.BuildPutItemParams(p.item, p.options)
+	if p.err != nil {
+		return nil, p.err
+	}
+	builder := NewParamsBuilder(p.entity).withContext(p.ctx)
+	params, err := builder.BuildPutItemParams(p.item, p.options)
+	if err != nil {
+		return nil, err
+	}
+	applyCondition(params, p.conditionBuilder)
+	return params, nil
 }
 
 // UpdateOperation represents an update operation
@@ -258,11 +595,25 @@ type UpdateOperation struct {
 	prependOps       map[string]interface{}
 	subtractOps      map[string]interface{}
 	dataOps          map[string]interface{} // For removing specific values from lists/maps
+	changeOps        map[string]interface{} // Set values that must differ from the stored ones
+	guardKeys        bool                   // condition on the stored key facets, see GuardKeys
 	options          *UpdateOptions
 	ctx              context.Context
 	conditionBuilder *ConditionBuilder
+	valuesCondition  UpdateConditionCallback // built when the update runs, see ConditionWithValues
 }
 
+// UpdateValues holds the values an update is about to write, keyed by
+// attribute name, as passed to Set and Add
+type UpdateValues struct {
+	Set map[string]interface{}
+	Add map[string]interface{}
+}
+
+// UpdateConditionCallback builds an update condition that can reference the
+// update's pending values
+type UpdateConditionCallback func(attrs map[string]*AttributeRef, ops *OperationBuilder, values UpdateValues) string
+
 // Set sets an attribute value
 func (u *UpdateOperation) Set(updates map[string]interface{}) *UpdateOperation {
 	for key, value := range updates {
@@ -271,6 +622,119 @@ func (u *UpdateOperation) Set(updates map[string]interface{}) *UpdateOperation {
 	return u
 }
 
+// SetIfChanged sets attribute values only if at least one of them differs
+// from the stored item. The update is conditioned on any attribute being
+// different or missing, so an unchanged item fails the conditional check
+// instead of being rewritten (and having its updatedAt bumped); set
+// UpdateOptions.SkipUnchanged to treat that failure as a no-op. Values are
+// compared in their stored form, after normalization, padding, Set
+// transforms and compression.
+func (u *UpdateOperation) SetIfChanged(updates map[string]interface{}) *UpdateOperation {
+	if u.changeOps == nil {
+		u.changeOps = make(map[string]interface{})
+	}
+	for key, value := range updates {
+		u.setOps[key] = value
+		u.changeOps[key] = value
+	}
+	return u
+}
+
+// GuardKeys conditions the update on the stored item's primary key facet
+// attributes still equalling the values in the update's keys, so an item
+// whose facet attributes have drifted from its key is not updated
+func (u *UpdateOperation) GuardKeys() *UpdateOperation {
+	u.guardKeys = true
+	return u
+}
+
+// condition returns the update's condition, including the change detection
+// added by SetIfChanged and the key guard added by GuardKeys
+func (u *UpdateOperation) condition() *ConditionBuilder {
+	base := u.conditionBuilder
+	if u.valuesCondition != nil {
+		values := UpdateValues{Set: copyOps(u.setOps), Add: copyOps(u.addOps)}
+		base = u.entity.newConditionBuilder()
+		base.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return u.valuesCondition(attrs, ops, values)
+		})
+	}
+	if len(u.changeOps) == 0 && !u.guardKeys {
+		return base
+	}
+
+	var cb *ConditionBuilder
+	if base != nil {
+		cb = base.clone()
+	} else {
+		cb = u.entity.newConditionBuilder()
+	}
+
+	if len(u.changeOps) > 0 {
+		names := make([]string, 0, len(u.changeOps))
+		for name := range u.changeOps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		stored := u.storedChangeValues()
+		ops := &OperationBuilder{builder: cb.builder}
+		changes := make([]string, len(names))
+		for i, name := range names {
+			attr := &AttributeRef{builder: cb.builder, name: name}
+			changes[i] = fmt.Sprintf("(%s OR %s)", attr.Ne(stored[name]), ops.NotExists(attr))
+		}
+		cb.builder.AddExpression("(" + strings.Join(changes, " OR ") + ")")
+	}
+
+	if u.guardKeys {
+		if guards := u.keyGuards(cb); len(guards) > 0 {
+			cb.builder.AddExpression(strings.Join(guards, " AND "))
+		}
+	}
+	return cb
+}
+
+// storedChangeValues returns the SetIfChanged values as the update writes
+// them (normalized, padded, Set transformed and compressed), so they compare
+// against the stored attributes. Encrypted attributes are not comparable and
+// always count as changed. A value that fails to compress is left as-is; the
+// update itself then fails with the same error.
+func (u *UpdateOperation) storedChangeValues() map[string]interface{} {
+	schema := u.entity.schema
+	values := ApplyPadding(ApplyNormalization(Item(copyOps(u.changeOps)), schema), schema)
+	values, _, _ = NewValidator(u.entity).ApplySetTransformations(values, nil, nil)
+	if compressed, err := compressAttributes(values, schema); err == nil {
+		values = compressed
+	}
+	return values
+}
+
+// keyGuards returns an equality condition for each primary key facet in the
+// update's keys, comparing against the value as it is stored
+func (u *UpdateOperation) keyGuards(cb *ConditionBuilder) []string {
+	index := u.entity.primaryIndex()
+	if index == nil {
+		return nil
+	}
+	facets := append([]string{}, index.PK.Facets...)
+	if index.SK != nil {
+		facets = append(facets, index.SK.Facets...)
+	}
+
+	stored := ApplyPadding(ApplyNormalization(Item(u.keys), u.entity.schema), u.entity.schema)
+	var guards []string
+	for _, facet := range facets {
+		value, ok := stored[facet]
+		if !ok {
+			continue
+		}
+		attr := &AttributeRef{builder: cb.builder, name: facet}
+		guards = append(guards, attr.Eq(value))
+	}
+	return guards
+}
+
 // Add adds to an attribute (for numbers and sets)
 func (u *UpdateOperation) Add(updates map[string]interface{}) *UpdateOperation {
 	for key, value := range updates {
@@ -300,6 +764,12 @@ func (u *UpdateOperation) Remove(attributes []string) *UpdateOperation {
 	return u
 }
 
+// RemoveAttributes removes each named attribute, like Remove. Removed
+// attributes must be declared, and may not be key facets or required.
+func (u *UpdateOperation) RemoveAttributes(attributes ...string) *UpdateOperation {
+	return u.Remove(attributes)
+}
+
 // Append appends values to a list attribute
 // Uses DynamoDB's list_append function
 func (u *UpdateOperation) Append(updates map[string]interface{}) *UpdateOperation {
@@ -338,22 +808,54 @@ func (u *UpdateOperation) Data(updates map[string]interface{}) *UpdateOperation
 
 // Condition adds a condition expression to the update operation
 func (u *UpdateOperation) Condition(callback WhereCallback) *UpdateOperation {
-	cb := NewConditionBuilder(u.entity.schema.Attributes)
+	cb := u.entity.newConditionBuilder()
 	cb.Where(callback)
 	u.conditionBuilder = cb
+	u.valuesCondition = nil
+	return u
+}
+
+// ConditionWithValues adds a condition expression that can compare stored
+// attributes with the values the update sets or adds. The callback runs when
+// the update is executed, so it sees every Set and Add call regardless of
+// order; passing a pending value to an operation binds it as a value
+// placeholder:
+//
+//	entity.Update(keys).Set(map[string]interface{}{"price": 12}).
+//		ConditionWithValues(func(attrs map[string]*AttributeRef, ops *OperationBuilder, values UpdateValues) string {
+//			return attrs["price"].Lt(values.Set["price"])
+//		})
+func (u *UpdateOperation) ConditionWithValues(callback UpdateConditionCallback) *UpdateOperation {
+	u.valuesCondition = callback
+	u.conditionBuilder = nil
+	return u
+}
+
+// Options sets update options
+func (u *UpdateOperation) Options(opts *UpdateOptions) *UpdateOperation {
+	u.options = opts
 	return u
 }
 
 // Go executes the update operation
 func (u *UpdateOperation) Go() (*UpdateResponse, error) {
+	if u.options != nil && u.options.SkipUnchanged && (u.conditionBuilder != nil || u.valuesCondition != nil || u.guardKeys) {
+		return nil, NewElectroError("InvalidOperation",
+			"SkipUnchanged cannot be combined with Condition, ConditionWithValues or GuardKeys", nil)
+	}
 	executor := NewExecutionHelper(u.entity)
-	return executor.ExecuteUpdateItem(u.ctx, u.keys, u.setOps, u.addOps, u.delOps, u.remOps, u.appendOps, u.prependOps, u.subtractOps, u.dataOps, u.options)
+	return executor.ExecuteUpdateItem(u.ctx, u.keys, u.setOps, u.addOps, u.delOps, u.remOps, u.appendOps, u.prependOps, u.subtractOps, u.dataOps, u.options, u.condition())
 }
 
 // Params returns the DynamoDB parameters without executing
 func (u *UpdateOperation) Params() (map[string]interface{}, error) {
 	builder := NewParamsBuilder(u.entity)
-	return builder.BuildUpdateItemParams(u.keys, u.setOps, u.addOps, u.delOps, u.remOps, u.appendOps, u.prependOps, u.subtractOps, u.dataOps, u.options)
+	params, err := builder.BuildUpdateItemParams(u.keys, u.setOps, u.addOps, u.delOps, u.remOps, u.appendOps, u.prependOps, u.subtractOps, u.dataOps, u.options)
+	if err != nil {
+		return nil, err
+	}
+	applyCondition(params, u.condition())
+	return params, nil
 }
 
 // DeleteOperation represents a delete operation
@@ -367,7 +869,7 @@ type DeleteOperation struct {
 
 // Condition adds a condition expression to the delete operation
 func (d *DeleteOperation) Condition(callback WhereCallback) *DeleteOperation {
-	cb := NewConditionBuilder(d.entity.schema.Attributes)
+	cb := d.entity.newConditionBuilder()
 	cb.Where(callback)
 	d.conditionBuilder = cb
 	return d
@@ -400,23 +902,5 @@ func (s *ScanOperation) Go() (*ScanResponse, error) {
 
 // Params returns the DynamoDB parameters without executing
 func (s *ScanOperation) Params() (map[string]interface{}, error) {
-	tableName := s.entity.config.Table
-	if tableName == nil {
-		tableName = &s.entity.schema.Table
-	}
-
-	params := map[string]interface{}{
-		"TableName": *tableName,
-	}
-
-	if s.options != nil {
-		if s.options.Limit != nil {
-			params["Limit"] = *s.options.Limit
-		}
-		if s.options.Cursor != nil {
-			params["ExclusiveStartKey"] = *s.options.Cursor
-		}
-	}
-
-	return params, nil
+	return NewParamsBuilder(s.entity).BuildScanParams(s.options)
 }
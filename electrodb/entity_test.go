@@ -1,7 +1,12 @@
 package electrodb
 
 import (
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 // TestNewEntity tests basic entity creation
@@ -344,6 +349,78 @@ func TestEntityGet(t *testing.T) {
 	}
 }
 
+// TestEntityGetIdentifierOverride tests reading an item written under another
+// version prefix
+func TestEntityGetIdentifierOverride(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Version: "2",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	// Only the item written by version 1 exists
+	legacy := map[string]types.AttributeValue{
+		"pk":   &types.AttributeValueMemberS{Value: "$testservice#id_u1"},
+		"sk":   &types.AttributeValueMemberS{Value: "$user_1"},
+		"id":   &types.AttributeValueMemberS{Value: "u1"},
+		"name": &types.AttributeValueMemberS{Value: "Ada"},
+	}
+	var requested []string
+	client := &mockClient{
+		getItem: func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			sk := input.Key["sk"].(*types.AttributeValueMemberS).Value
+			requested = append(requested, sk)
+			if sk == "$user_1" {
+				return &dynamodb.GetItemOutput{Item: legacy}, nil
+			}
+			return &dynamodb.GetItemOutput{}, nil
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	current, err := entity.Get(Keys{"id": "u1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if current.Data != nil {
+		t.Errorf("Expected no item under the current version, got %v", current.Data)
+	}
+
+	old, err := entity.Get(Keys{"id": "u1"}).Options(&GetOptions{IdentifierOverride: &KeyIdentity{Version: "1"}}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if old.Data == nil || old.Data["name"] != "Ada" {
+		t.Errorf("Expected the legacy item, got %v", old.Data)
+	}
+	if len(requested) != 2 || requested[0] != "$user_2" || requested[1] != "$user_1" {
+		t.Errorf("Expected sort keys $user_2 then $user_1, got %v", requested)
+	}
+
+	// The entity name can be overridden too
+	params, err := entity.Get(Keys{"id": "u1"}).Options(&GetOptions{IdentifierOverride: &KeyIdentity{Entity: "Member", Version: "1"}}).Params()
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+	if sk := params["Key"].(map[string]types.AttributeValue)["sk"].(*types.AttributeValueMemberS).Value; sk != "$member_1" {
+		t.Errorf("Expected sort key $member_1, got %q", sk)
+	}
+}
+
 // TestEntityUpdate tests update operation creation
 func TestEntityUpdate(t *testing.T) {
 	schema := &Schema{
@@ -427,3 +504,284 @@ func TestEntityDelete(t *testing.T) {
 		t.Fatal("Expected params to be non-nil")
 	}
 }
+
+func TestEntityWithClient(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	_, err = entity.Get(Keys{"id": "1"}).Go()
+	if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != ErrNoClientProvided {
+		t.Fatalf("Expected NoClientProvided before attaching a client, got %v", err)
+	}
+
+	client := &mockClient{
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+				"id":   &types.AttributeValueMemberS{Value: "1"},
+				"name": &types.AttributeValueMemberS{Value: "alice"},
+			}}, nil
+		},
+	}
+
+	if entity.WithClient(client) != entity {
+		t.Error("Expected WithClient to return the entity")
+	}
+
+	result, err := entity.Get(Keys{"id": "1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed after attaching a client: %v", err)
+	}
+	if result.Data["name"] != "alice" {
+		t.Errorf("Expected item from attached client, got %v", result.Data)
+	}
+
+	// The config is copied, so a shared Config is not mutated
+	shared := &Config{}
+	other, err := NewEntity(schema, shared)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	other.WithClient(client)
+	if shared.Client != nil {
+		t.Error("Expected shared config to be left untouched")
+	}
+}
+
+func TestEntityPutStruct(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"userId": {Type: AttributeTypeString, Required: true},
+			"email":  {Type: AttributeTypeString, Required: true},
+			"age":    {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"userId"}},
+			},
+			"byEmail": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"email"}},
+			},
+		},
+	}
+
+	type user struct {
+		ID    string `dynamodbav:"userId"`
+		Email string `dynamodbav:"email,omitempty"`
+		Age   int    `dynamodbav:"age,omitempty"`
+	}
+
+	var stored map[string]types.AttributeValue
+	client := &mockClient{
+		putItem: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			stored = input.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	if _, err := entity.PutStruct(user{ID: "u1", Email: "a@example.com", Age: 30}).Go(); err != nil {
+		t.Fatalf("PutStruct failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"userId": "u1",
+		"email":  "a@example.com",
+		"pk":     "$testservice#userid_u1",
+		"gsi1pk": "$testservice#email_a@example.com",
+	}
+	for field, value := range expected {
+		if got, ok := stored[field].(*types.AttributeValueMemberS); !ok || got.Value != value {
+			t.Errorf("Expected %s=%s, got %#v", field, value, stored[field])
+		}
+	}
+	if age, ok := stored["age"].(*types.AttributeValueMemberN); !ok || age.Value != "30" {
+		t.Errorf("Expected age 30, got %#v", stored["age"])
+	}
+
+	// Structs run through validation like maps do
+	if _, err := entity.PutStruct(user{ID: "u2"}).Params(); err == nil {
+		t.Error("Expected missing required email to fail validation")
+	}
+
+	// Values that are not structs or maps fail to convert
+	if _, err := entity.PutStruct(42).Params(); err == nil {
+		t.Error("Expected a non-struct value to fail")
+	}
+}
+
+func TestEntityGetStructRoundTrip(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"userId":   {Type: AttributeTypeString, Required: true},
+			"name":     {Type: AttributeTypeString, Get: func(v interface{}) interface{} { return v.(string) + "!" }},
+			"age":      {Type: AttributeTypeNumber},
+			"password": {Type: AttributeTypeString, Hidden: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"userId"}},
+			},
+		},
+	}
+
+	type user struct {
+		ID       string `dynamodbav:"userId"`
+		Name     string `dynamodbav:"name"`
+		Age      int    `dynamodbav:"age"`
+		Password string `dynamodbav:"password,omitempty"`
+	}
+
+	var stored map[string]types.AttributeValue
+	client := &mockClient{
+		putItem: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			stored = input.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItem: func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			if input.Key["pk"].(*types.AttributeValueMemberS).Value != stored["pk"].(*types.AttributeValueMemberS).Value {
+				return &dynamodb.GetItemOutput{}, nil
+			}
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+		query: func(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{stored}}, nil
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	if _, err := entity.PutStruct(user{ID: "u1", Name: "alice", Age: 30, Password: "secret"}).Go(); err != nil {
+		t.Fatalf("PutStruct failed: %v", err)
+	}
+
+	var got user
+	found, err := entity.GetStruct(Keys{"userId": "u1"}, &got)
+	if err != nil {
+		t.Fatalf("GetStruct failed: %v", err)
+	}
+	expected := user{ID: "u1", Name: "alice!", Age: 30}
+	if !found || got != expected {
+		t.Errorf("Expected %+v (found), got %+v (found=%v)", expected, got, found)
+	}
+
+	found, err = entity.GetStruct(Keys{"userId": "missing"}, &got)
+	if err != nil || found {
+		t.Errorf("Expected a missing item to report not found, got found=%v err=%v", found, err)
+	}
+
+	users, cursor, err := QueryInto[user](entity.Query("primary").Query("u1"))
+	if err != nil {
+		t.Fatalf("QueryInto failed: %v", err)
+	}
+	if len(users) != 1 || users[0] != expected || cursor != nil {
+		t.Errorf("Expected [%+v] without cursor, got %+v (cursor %v)", expected, users, cursor)
+	}
+}
+
+func TestEntityDiff(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":        {Type: AttributeTypeString, Required: true},
+			"orgId":     {Type: AttributeTypeString},
+			"name":      {Type: AttributeTypeString},
+			"email":     {Type: AttributeTypeString},
+			"age":       {Type: AttributeTypeNumber},
+			"nickname":  {Type: AttributeTypeString},
+			"tags":      {Type: AttributeTypeList},
+			"createdBy": {Type: AttributeTypeString, ReadOnly: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"byOrg": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"orgId"}},
+			},
+		},
+	}
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	before := Item{
+		"id":        "u1",
+		"orgId":     "acme",
+		"name":      "Alice",
+		"email":     "alice@example.com",
+		"age":       float64(30),
+		"nickname":  "al",
+		"tags":      []interface{}{"a", "b"},
+		"createdBy": "admin",
+		"unknown":   "x",
+	}
+	after := Item{
+		"id":        "u1",
+		"orgId":     "other",
+		"name":      "Alicia",
+		"email":     "alice@example.com",
+		"age":       30,
+		"tags":      []interface{}{"a", "b"},
+		"createdBy": "someone",
+		"unknown":   "y",
+	}
+
+	params, err := entity.Diff(before, after).Params()
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+
+	// Only name is set and nickname removed; unchanged values, the facets
+	// orgId and id, the ReadOnly createdBy and undeclared attributes are not
+	// touched
+	expression := params["UpdateExpression"].(string)
+	names := params["ExpressionAttributeNames"].(map[string]string)
+	var touched []string
+	for _, name := range names {
+		touched = append(touched, name)
+	}
+	sort.Strings(touched)
+	if strings.Join(touched, ",") != "name,nickname" {
+		t.Errorf("Expected only name and nickname in the update, got %v (%s)", touched, expression)
+	}
+	if !strings.HasPrefix(expression, "SET ") || !strings.Contains(expression, " REMOVE ") {
+		t.Errorf("Expected a SET and a REMOVE clause, got %s", expression)
+	}
+	key := params["Key"].(map[string]types.AttributeValue)
+	if pk := key["pk"].(*types.AttributeValueMemberS).Value; pk != "$testservice#id_u1" {
+		t.Errorf("Expected keys from the stored item, got %s", pk)
+	}
+}
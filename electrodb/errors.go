@@ -0,0 +1,50 @@
+package electrodb
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// throttlingErrorCodes are the AWS error codes reported when DynamoDB
+// rejects a request for exceeding capacity or request rate limits
+var throttlingErrorCodes = map[string]bool{
+	"ProvisionedThroughputExceededException": true,
+	"RequestLimitExceeded":                   true,
+	"ThrottlingException":                    true,
+}
+
+// awsErrorType returns the AWS error code carried by err, or "" if err does
+// not wrap an AWS API error
+func awsErrorType(err error) string {
+	var coded interface{ ErrorCode() string }
+	if err != nil && errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return ""
+}
+
+// IsThrottled reports whether err was caused by DynamoDB throttling the
+// request
+func IsThrottled(err error) bool {
+	var throughput *types.ProvisionedThroughputExceededException
+	var requestLimit *types.RequestLimitExceeded
+	if errors.As(err, &throughput) || errors.As(err, &requestLimit) {
+		return true
+	}
+	return throttlingErrorCodes[awsErrorType(err)]
+}
+
+// IsResourceNotFound reports whether err was caused by a table or index that
+// does not exist
+func IsResourceNotFound(err error) bool {
+	var notFound *types.ResourceNotFoundException
+	return errors.As(err, &notFound) || awsErrorType(err) == "ResourceNotFoundException"
+}
+
+// IsConditionalCheckFailed reports whether err was caused by a write's
+// condition expression evaluating to false
+func IsConditionalCheckFailed(err error) bool {
+	var conditionFailed *types.ConditionalCheckFailedException
+	return errors.As(err, &conditionFailed) || awsErrorType(err) == "ConditionalCheckFailedException"
+}
@@ -0,0 +1,64 @@
+package electrodb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// codedError is an AWS-style API error known only by its code
+type codedError string
+
+func (e codedError) Error() string     { return string(e) }
+func (e codedError) ErrorCode() string { return string(e) }
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name              string
+		cause             error
+		awsType           string
+		throttled         bool
+		notFound          bool
+		conditionalFailed bool
+	}{
+		{"provisioned throughput", &types.ProvisionedThroughputExceededException{}, "ProvisionedThroughputExceededException", true, false, false},
+		{"request limit", &types.RequestLimitExceeded{}, "RequestLimitExceeded", true, false, false},
+		{"throttling code", codedError("ThrottlingException"), "ThrottlingException", true, false, false},
+		{"resource not found", &types.ResourceNotFoundException{}, "ResourceNotFoundException", false, true, false},
+		{"conditional check", &types.ConditionalCheckFailedException{}, "ConditionalCheckFailedException", false, false, true},
+		{"wrapped conditional check", fmt.Errorf("operation error: %w", &types.ConditionalCheckFailedException{}), "ConditionalCheckFailedException", false, false, true},
+		{"internal server error", &types.InternalServerError{}, "InternalServerError", false, false, false},
+		{"plain error", errors.New("boom"), "", false, false, false},
+		{"no cause", nil, "", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewElectroError("DynamoDBError", "Failed to execute PutItem", tt.cause)
+			if err.AWSErrorType != tt.awsType {
+				t.Errorf("Expected AWSErrorType %q, got %q", tt.awsType, err.AWSErrorType)
+			}
+			if IsThrottled(err) != tt.throttled {
+				t.Errorf("Expected IsThrottled %v", tt.throttled)
+			}
+			if IsResourceNotFound(err) != tt.notFound {
+				t.Errorf("Expected IsResourceNotFound %v", tt.notFound)
+			}
+			if IsConditionalCheckFailed(err) != tt.conditionalFailed {
+				t.Errorf("Expected IsConditionalCheckFailed %v", tt.conditionalFailed)
+			}
+			if tt.cause != nil && !errors.Is(err, tt.cause) {
+				t.Errorf("Expected the error to unwrap to its cause")
+			}
+		})
+	}
+
+	// The concrete AWS type is reachable through the ElectroError
+	var throughput *types.ProvisionedThroughputExceededException
+	err := NewElectroError("DynamoDBError", "Failed to execute Query", &types.ProvisionedThroughputExceededException{})
+	if !errors.As(err, &throughput) {
+		t.Errorf("Expected errors.As to find the wrapped AWS error")
+	}
+}
@@ -2,6 +2,7 @@ package electrodb
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -29,6 +30,9 @@ func (eh *ExecutionHelper) ExecuteGetItem(ctx context.Context, keys Keys, option
 	if err != nil {
 		return nil, err
 	}
+	if err := auditExpressions(params); err != nil {
+		return nil, err
+	}
 
 	// Convert to DynamoDB GetItemInput
 	input := &dynamodb.GetItemInput{
@@ -38,12 +42,43 @@ func (eh *ExecutionHelper) ExecuteGetItem(ctx context.Context, keys Keys, option
 
 	if projExpr, ok := params["ProjectionExpression"].(string); ok && projExpr != "" {
 		input.ProjectionExpression = &projExpr
+		input.ExpressionAttributeNames, _ = params["ExpressionAttributeNames"].(map[string]string)
+	}
+
+	if consistent, ok := params["ConsistentRead"].(bool); ok {
+		input.ConsistentRead = &consistent
+	}
+
+	// Serve repeated eventually consistent reads from the cache
+	var cacheKey string
+	if eh.cacheEnabled(input.ConsistentRead) {
+		if cacheKey, err = getCacheKey(input); err != nil {
+			cacheKey = ""
+		}
 	}
 
 	// Execute
-	result, err := eh.entity.client.GetItem(ctx, input)
-	if err != nil {
-		return nil, NewElectroError("DynamoDBError", "Failed to execute GetItem", err)
+	var result *dynamodb.GetItemOutput
+	if items, _, ok := eh.cachedItems(cacheKey); ok {
+		result = &dynamodb.GetItemOutput{}
+		if len(items) > 0 {
+			result.Item = items[0]
+		}
+	} else {
+		err = eh.retry(ctx, func() (err error) {
+			result, err = eh.entity.client.GetItem(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, NewElectroError("DynamoDBError", "Failed to execute GetItem", err)
+		}
+		if cacheKey != "" {
+			var items []map[string]types.AttributeValue
+			if result.Item != nil {
+				items = append(items, result.Item)
+			}
+			eh.cacheSet(cacheKey, items, nil)
+		}
 	}
 
 	// Parse response
@@ -55,43 +90,63 @@ func (eh *ExecutionHelper) ExecuteGetItem(ctx context.Context, keys Keys, option
 		}
 	}
 
-	// Remove internal keys if not raw mode
+	// Format the item unless raw mode was requested
 	if options == nil || !options.Raw {
-		item = eh.removeInternalKeys(item)
-		// Remove padding
-		item = RemovePadding(item, eh.entity.schema)
-		// Apply Get transformations and filter hidden attributes
-		validator := NewValidator(eh.entity)
-		item = validator.TransformForRead(item)
+		if item, err = eh.formatItem(item, options != nil && options.IncludeKeys); err != nil {
+			return nil, err
+		}
+	} else {
+		item = eh.removeNoRead(item)
 	}
 
 	return &GetResponse{Data: item}, nil
 }
 
 // ExecutePutItem executes a PutItem operation
-func (eh *ExecutionHelper) ExecutePutItem(ctx context.Context, item Item, options *PutOptions) (*PutResponse, error) {
+func (eh *ExecutionHelper) ExecutePutItem(ctx context.Context, item Item, options *PutOptions, condition *ConditionBuilder) (*PutResponse, error) {
+	if err := eh.entity.checkWritable("put"); err != nil {
+		return nil, err
+	}
 	if eh.entity.client == nil {
 		return nil, NewElectroError("NoClientProvided", "No DynamoDB client was provided to the entity", nil)
 	}
 
-	builder := NewParamsBuilder(eh.entity)
+	item, err := eh.beforeWrite("put", item)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := NewParamsBuilder(eh.entity).withContext(ctx)
 	params, err := builder.BuildPutItemParams(item, options)
 	if err != nil {
 		return nil, err
 	}
+	applyCondition(params, condition)
+	if err := auditExpressions(params); err != nil {
+		return nil, err
+	}
 
 	// Convert to DynamoDB PutItemInput
 	input := &dynamodb.PutItemInput{
 		TableName: stringPtr(params["TableName"].(string)),
 		Item:      params["Item"].(map[string]types.AttributeValue),
 	}
+	if expr, ok := params["ConditionExpression"].(string); ok {
+		input.ConditionExpression = &expr
+		input.ExpressionAttributeNames = params["ExpressionAttributeNames"].(map[string]string)
+		input.ExpressionAttributeValues = params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	}
 
 	if returnValues, ok := params["ReturnValues"].(string); ok {
 		input.ReturnValues = types.ReturnValue(returnValues)
 	}
 
 	// Execute
-	result, err := eh.entity.client.PutItem(ctx, input)
+	var result *dynamodb.PutItemOutput
+	err = eh.retry(ctx, func() (err error) {
+		result, err = eh.entity.client.PutItem(ctx, input)
+		return err
+	})
 	if err != nil {
 		return nil, NewElectroError("DynamoDBError", "Failed to execute PutItem", err)
 	}
@@ -105,17 +160,32 @@ func (eh *ExecutionHelper) ExecutePutItem(ctx context.Context, item Item, option
 		}
 	}
 
-	// Remove internal keys if not raw mode
+	// Format returned attributes unless raw mode was requested
 	if options == nil || !options.Raw {
-		responseItem = eh.removeInternalKeys(responseItem)
-		// Remove padding
-		responseItem = RemovePadding(responseItem, eh.entity.schema)
-		// Apply Get transformations and filter hidden attributes
-		validator := NewValidator(eh.entity)
-		responseItem = validator.TransformForRead(responseItem)
+		if responseItem, err = eh.formatItem(responseItem, false); err != nil {
+			return nil, err
+		}
+	} else {
+		responseItem = eh.removeNoRead(responseItem)
 	}
 
-	return &PutResponse{Data: responseItem}, nil
+	resp := &PutResponse{
+		Data:   responseItem,
+		Keys:   eh.keyFields(input.Item),
+		entity: eh.entity,
+		key:    eh.primaryKey(input.Item),
+	}
+	eh.afterWrite("put", resp)
+	return resp, nil
+}
+
+// Reload issues a strongly consistent Get of the item just put and returns
+// it formatted, whatever ReturnValues the put asked for
+func (r *PutResponse) Reload(ctx context.Context) (map[string]interface{}, error) {
+	if r.entity == nil {
+		return nil, NewElectroError("InvalidOperation", "Response was not produced by a put", nil)
+	}
+	return NewExecutionHelper(r.entity).reloadItem(ctx, r.key)
 }
 
 // ExecuteUpdateItem executes an UpdateItem operation
@@ -131,16 +201,30 @@ func (eh *ExecutionHelper) ExecuteUpdateItem(
 	subtractOps map[string]interface{},
 	dataOps map[string]interface{},
 	options *UpdateOptions,
+	condition *ConditionBuilder,
 ) (*UpdateResponse, error) {
+	if err := eh.entity.checkWritable("update"); err != nil {
+		return nil, err
+	}
 	if eh.entity.client == nil {
 		return nil, NewElectroError("NoClientProvided", "No DynamoDB client was provided to the entity", nil)
 	}
 
+	updated, err := eh.beforeWrite("update", Item(setOps))
+	if err != nil {
+		return nil, err
+	}
+	setOps = updated
+
 	builder := NewParamsBuilder(eh.entity)
 	params, err := builder.BuildUpdateItemParams(keys, setOps, addOps, delOps, remOps, appendOps, prependOps, subtractOps, dataOps, options)
 	if err != nil {
 		return nil, err
 	}
+	applyCondition(params, condition)
+	if err := auditExpressions(params); err != nil {
+		return nil, err
+	}
 
 	// Convert to DynamoDB UpdateItemInput
 	input := &dynamodb.UpdateItemInput{
@@ -151,10 +235,21 @@ func (eh *ExecutionHelper) ExecuteUpdateItem(
 		ExpressionAttributeValues: params["ExpressionAttributeValues"].(map[string]types.AttributeValue),
 		ReturnValues:              types.ReturnValue(params["ReturnValues"].(string)),
 	}
+	if expr, ok := params["ConditionExpression"].(string); ok {
+		input.ConditionExpression = &expr
+	}
 
 	// Execute
-	result, err := eh.entity.client.UpdateItem(ctx, input)
+	var result *dynamodb.UpdateItemOutput
+	err = eh.retry(ctx, func() (err error) {
+		result, err = eh.entity.client.UpdateItem(ctx, input)
+		return err
+	})
 	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if options != nil && options.SkipUnchanged && errors.As(err, &conditionFailed) {
+			return &UpdateResponse{Unchanged: true, entity: eh.entity, key: input.Key}, nil
+		}
 		return nil, NewElectroError("DynamoDBError", "Failed to execute UpdateItem", err)
 	}
 
@@ -167,30 +262,52 @@ func (eh *ExecutionHelper) ExecuteUpdateItem(
 		}
 	}
 
-	// Remove internal keys if not raw mode
+	// Format returned attributes unless raw mode was requested
 	if options == nil || !options.Raw {
-		responseItem = eh.removeInternalKeys(responseItem)
-		// Remove padding
-		responseItem = RemovePadding(responseItem, eh.entity.schema)
-		// Apply Get transformations and filter hidden attributes
-		validator := NewValidator(eh.entity)
-		responseItem = validator.TransformForRead(responseItem)
+		if responseItem, err = eh.formatItem(responseItem, false); err != nil {
+			return nil, err
+		}
+	} else {
+		responseItem = eh.removeNoRead(responseItem)
 	}
 
-	return &UpdateResponse{Data: responseItem}, nil
+	resp := &UpdateResponse{Data: responseItem, entity: eh.entity, key: input.Key}
+	eh.afterWrite("update", resp)
+	return resp, nil
+}
+
+// Reload issues a strongly consistent Get of the item just updated and
+// returns it formatted, whatever ReturnValues the update asked for
+func (r *UpdateResponse) Reload(ctx context.Context) (map[string]interface{}, error) {
+	if r.entity == nil {
+		return nil, NewElectroError("InvalidOperation", "Response was not produced by an update", nil)
+	}
+	return NewExecutionHelper(r.entity).reloadItem(ctx, r.key)
 }
 
 // ExecuteDeleteItem executes a DeleteItem operation
 func (eh *ExecutionHelper) ExecuteDeleteItem(ctx context.Context, keys Keys, options *DeleteOptions) (*DeleteResponse, error) {
+	if err := eh.entity.checkWritable("delete"); err != nil {
+		return nil, err
+	}
 	if eh.entity.client == nil {
 		return nil, NewElectroError("NoClientProvided", "No DynamoDB client was provided to the entity", nil)
 	}
 
+	updated, err := eh.beforeWrite("delete", Item(keys))
+	if err != nil {
+		return nil, err
+	}
+	keys = Keys(updated)
+
 	builder := NewParamsBuilder(eh.entity)
 	params, err := builder.BuildDeleteItemParams(keys, options)
 	if err != nil {
 		return nil, err
 	}
+	if err := auditExpressions(params); err != nil {
+		return nil, err
+	}
 
 	// Convert to DynamoDB DeleteItemInput
 	input := &dynamodb.DeleteItemInput{
@@ -203,7 +320,11 @@ func (eh *ExecutionHelper) ExecuteDeleteItem(ctx context.Context, keys Keys, opt
 	}
 
 	// Execute
-	result, err := eh.entity.client.DeleteItem(ctx, input)
+	var result *dynamodb.DeleteItemOutput
+	err = eh.retry(ctx, func() (err error) {
+		result, err = eh.entity.client.DeleteItem(ctx, input)
+		return err
+	})
 	if err != nil {
 		return nil, NewElectroError("DynamoDBError", "Failed to execute DeleteItem", err)
 	}
@@ -217,17 +338,34 @@ func (eh *ExecutionHelper) ExecuteDeleteItem(ctx context.Context, keys Keys, opt
 		}
 	}
 
-	// Remove internal keys if not raw mode
+	// Format returned attributes unless raw mode was requested
 	if options == nil || !options.Raw {
-		responseItem = eh.removeInternalKeys(responseItem)
-		// Remove padding
-		responseItem = RemovePadding(responseItem, eh.entity.schema)
-		// Apply Get transformations and filter hidden attributes
-		validator := NewValidator(eh.entity)
-		responseItem = validator.TransformForRead(responseItem)
+		if responseItem, err = eh.formatItem(responseItem, false); err != nil {
+			return nil, err
+		}
+	} else {
+		responseItem = eh.removeNoRead(responseItem)
 	}
 
-	return &DeleteResponse{Data: responseItem}, nil
+	resp := &DeleteResponse{Data: responseItem}
+	eh.afterWrite("delete", resp)
+	return resp, nil
+}
+
+// beforeWrite runs the configured BeforeWrite hook, if any, on a copy of the
+// item, so a hook that mutates it cannot change the operation it came from
+func (eh *ExecutionHelper) beforeWrite(op string, item Item) (Item, error) {
+	if eh.entity.config.BeforeWrite == nil {
+		return item, nil
+	}
+	return eh.entity.config.BeforeWrite(op, Item(copyOps(item)))
+}
+
+// afterWrite runs the configured AfterWrite hook, if any
+func (eh *ExecutionHelper) afterWrite(op string, resp interface{}) {
+	if eh.entity.config.AfterWrite != nil {
+		eh.entity.config.AfterWrite(op, resp)
+	}
 }
 
 // ExecuteQuery executes a Query operation
@@ -249,6 +387,9 @@ func (eh *ExecutionHelper) ExecuteQuery(
 	if err != nil {
 		return nil, err
 	}
+	if err := auditExpressions(params); err != nil {
+		return nil, err
+	}
 
 	// Convert to DynamoDB QueryInput
 	input := &dynamodb.QueryInput{
@@ -265,10 +406,18 @@ func (eh *ExecutionHelper) ExecuteQuery(
 		input.FilterExpression = &filterExpr
 	}
 
+	if projExpr, ok := params["ProjectionExpression"].(string); ok && projExpr != "" {
+		input.ProjectionExpression = &projExpr
+	}
+
 	if exprAttrNames, ok := params["ExpressionAttributeNames"].(map[string]string); ok {
 		input.ExpressionAttributeNames = exprAttrNames
 	}
 
+	if consistent, ok := params["ConsistentRead"].(bool); ok {
+		input.ConsistentRead = &consistent
+	}
+
 	if options != nil {
 		if options.Limit != nil {
 			input.Limit = options.Limit
@@ -276,7 +425,7 @@ func (eh *ExecutionHelper) ExecuteQuery(
 		if scanForward, ok := params["ScanIndexForward"].(bool); ok {
 			input.ScanIndexForward = &scanForward
 		}
-		if options.Cursor != nil {
+		if options.Cursor != nil && !options.IgnoreCursor {
 			exclusiveStartKey, err := decodeCursor(*options.Cursor)
 			if err != nil {
 				return nil, err
@@ -286,14 +435,29 @@ func (eh *ExecutionHelper) ExecuteQuery(
 	}
 
 	// Execute
-	result, err := eh.entity.client.Query(ctx, input)
-	if err != nil {
-		return nil, NewElectroError("DynamoDBError", "Failed to execute Query", err)
+	var cacheKey string
+	if eh.cacheEnabled(input.ConsistentRead) {
+		if cacheKey, err = queryCacheKey(input); err != nil {
+			cacheKey = ""
+		}
+	}
+
+	var result *dynamodb.QueryOutput
+	if items, lastKey, ok := eh.cachedItems(cacheKey); ok {
+		result = &dynamodb.QueryOutput{Items: items, Count: int32(len(items)), LastEvaluatedKey: lastKey}
+	} else {
+		result, err = eh.entity.client.Query(ctx, input)
+		if err != nil {
+			return nil, NewElectroError("DynamoDBError", "Failed to execute Query", err)
+		}
+		if cacheKey != "" {
+			eh.cacheSet(cacheKey, result.Items, result.LastEvaluatedKey)
+		}
 	}
 
 	// Parse response
 	items := make([]map[string]interface{}, 0, len(result.Items))
-	validator := NewValidator(eh.entity)
+	sizes := make([]int, 0, len(result.Items))
 	for _, item := range result.Items {
 		var parsedItem map[string]interface{}
 		err = attributevalue.UnmarshalMap(item, &parsedItem)
@@ -301,16 +465,17 @@ func (eh *ExecutionHelper) ExecuteQuery(
 			return nil, NewElectroError("UnmarshalError", "Failed to unmarshal response", err)
 		}
 
-		// Remove internal keys if not raw mode
+		// Format the item unless raw mode was requested
 		if options == nil || !options.Raw {
-			parsedItem = eh.removeInternalKeys(parsedItem)
-			// Remove padding
-			parsedItem = RemovePadding(parsedItem, eh.entity.schema)
-			// Apply Get transformations and filter hidden attributes
-			parsedItem = validator.TransformForRead(parsedItem)
+			if parsedItem, err = eh.formatItem(parsedItem, options != nil && options.IncludeKeys); err != nil {
+				return nil, err
+			}
+		} else {
+			parsedItem = eh.removeNoRead(parsedItem)
 		}
 
 		items = append(items, parsedItem)
+		sizes = append(sizes, itemSize(item))
 	}
 
 	// Generate cursor from LastEvaluatedKey
@@ -326,8 +491,10 @@ func (eh *ExecutionHelper) ExecuteQuery(
 	}
 
 	return &QueryResponse{
-		Data:   items,
-		Cursor: cursor,
+		Data:      items,
+		Cursor:    cursor,
+		PageInfo:  newPageInfo(cursor, result.Count, result.ScannedCount, result.LastEvaluatedKey),
+		itemSizes: sizes,
 	}, nil
 }
 
@@ -337,21 +504,25 @@ func (eh *ExecutionHelper) ExecuteScan(ctx context.Context, options *QueryOption
 		return nil, NewElectroError("NoClientProvided", "No DynamoDB client was provided to the entity", nil)
 	}
 
-	// Build scan input
-	tableName := eh.entity.config.Table
-	if tableName == nil {
-		tableName = &eh.entity.schema.Table
+	builder := NewParamsBuilder(eh.entity)
+	params, err := builder.BuildScanParams(options)
+	if err != nil {
+		return nil, err
+	}
+	if err := auditExpressions(params); err != nil {
+		return nil, err
 	}
 
+	// Convert to DynamoDB ScanInput
 	input := &dynamodb.ScanInput{
-		TableName: tableName,
+		TableName: stringPtr(params["TableName"].(string)),
+	}
+	if limit, ok := params["Limit"].(int32); ok {
+		input.Limit = &limit
 	}
 
 	if options != nil {
-		if options.Limit != nil {
-			input.Limit = options.Limit
-		}
-		if options.Cursor != nil {
+		if options.Cursor != nil && !options.IgnoreCursor {
 			exclusiveStartKey, err := decodeCursor(*options.Cursor)
 			if err != nil {
 				return nil, err
@@ -368,7 +539,6 @@ func (eh *ExecutionHelper) ExecuteScan(ctx context.Context, options *QueryOption
 
 	// Parse response
 	items := make([]map[string]interface{}, 0, len(result.Items))
-	validator := NewValidator(eh.entity)
 	for _, item := range result.Items {
 		var parsedItem map[string]interface{}
 		err = attributevalue.UnmarshalMap(item, &parsedItem)
@@ -376,13 +546,13 @@ func (eh *ExecutionHelper) ExecuteScan(ctx context.Context, options *QueryOption
 			return nil, NewElectroError("UnmarshalError", "Failed to unmarshal response", err)
 		}
 
-		// Remove internal keys if not raw mode
+		// Format the item unless raw mode was requested
 		if options == nil || !options.Raw {
-			parsedItem = eh.removeInternalKeys(parsedItem)
-			// Remove padding
-			parsedItem = RemovePadding(parsedItem, eh.entity.schema)
-			// Apply Get transformations and filter hidden attributes
-			parsedItem = validator.TransformForRead(parsedItem)
+			if parsedItem, err = eh.formatItem(parsedItem, options != nil && options.IncludeKeys); err != nil {
+				return nil, err
+			}
+		} else {
+			parsedItem = eh.removeNoRead(parsedItem)
 		}
 
 		items = append(items, parsedItem)
@@ -401,11 +571,74 @@ func (eh *ExecutionHelper) ExecuteScan(ctx context.Context, options *QueryOption
 	}
 
 	return &ScanResponse{
-		Data:   items,
-		Cursor: cursor,
+		Data:     items,
+		Cursor:   cursor,
+		PageInfo: newPageInfo(cursor, result.Count, result.ScannedCount, result.LastEvaluatedKey),
 	}, nil
 }
 
+// newPageInfo describes a page from the SDK output it was parsed from
+func newPageInfo(cursor *string, count, scannedCount int32, lastKey map[string]types.AttributeValue) PageInfo {
+	if cursor == nil {
+		lastKey = nil
+	}
+	return PageInfo{
+		HasMore:          cursor != nil,
+		Count:            count,
+		ScannedCount:     scannedCount,
+		LastEvaluatedKey: lastKey,
+	}
+}
+
+// add folds the next page into a description of several pages
+func (pi *PageInfo) add(next PageInfo) {
+	pi.HasMore = next.HasMore
+	pi.Count += next.Count
+	pi.ScannedCount += next.ScannedCount
+	pi.LastEvaluatedKey = next.LastEvaluatedKey
+}
+
+// formatItem runs the read pipeline on an item returned by DynamoDB: strip
+// internal keys, remove padding, then apply Get/read transforms and filter
+// hidden attributes. With includeKeys the index key fields are kept.
+func (eh *ExecutionHelper) formatItem(item map[string]interface{}, includeKeys bool) (map[string]interface{}, error) {
+	if item == nil {
+		return nil, nil
+	}
+
+	validator := NewValidator(eh.entity)
+	formatted, err := decryptAttributes(eh.removeInternalKeys(validator.resolveAliases(item)), eh.entity.schema, eh.entity.config.Encryptor)
+	if err != nil {
+		return nil, err
+	}
+	formatted = decompressAttributes(formatted, eh.entity.schema)
+	formatted = RemovePadding(formatted, eh.entity.schema)
+	formatted = validator.TransformForRead(formatted)
+	if includeKeys {
+		formatted = eh.includeKeyFields(item, formatted)
+	}
+	return formatted, nil
+}
+
+// removeNoRead strips NoRead attributes from an unformatted item, which is
+// keyed by field name
+func (eh *ExecutionHelper) removeNoRead(item map[string]interface{}) map[string]interface{} {
+	if item == nil {
+		return nil
+	}
+
+	for name, attr := range eh.entity.schema.Attributes {
+		if !attr.NoRead {
+			continue
+		}
+		delete(item, name)
+		if attr.Field != "" {
+			delete(item, attr.Field)
+		}
+	}
+	return item
+}
+
 // removeInternalKeys removes internal DynamoDB keys from the response
 func (eh *ExecutionHelper) removeInternalKeys(item map[string]interface{}) map[string]interface{} {
 	if item == nil {
@@ -423,3 +656,86 @@ func (eh *ExecutionHelper) removeInternalKeys(item map[string]interface{}) map[s
 
 	return result
 }
+
+// includeKeyFields copies the entity's index key fields (pk, sk and any GSI
+// keys) from the raw DynamoDB item into the formatted item
+func (eh *ExecutionHelper) includeKeyFields(raw, item map[string]interface{}) map[string]interface{} {
+	if raw == nil || item == nil {
+		return item
+	}
+
+	for _, index := range eh.entity.schema.Indexes {
+		if val, exists := raw[index.PK.Field]; exists {
+			item[index.PK.Field] = val
+		}
+		if index.SK != nil {
+			if val, exists := raw[index.SK.Field]; exists {
+				item[index.SK.Field] = val
+			}
+		}
+	}
+
+	return item
+}
+
+// primaryKey picks the primary index key fields out of a composed item
+func (eh *ExecutionHelper) primaryKey(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	key := make(map[string]types.AttributeValue)
+	index := eh.entity.primaryIndex()
+	if index == nil {
+		return key
+	}
+	key[index.PK.Field] = item[index.PK.Field]
+	if index.SK != nil {
+		key[index.SK.Field] = item[index.SK.Field]
+	}
+	return key
+}
+
+// reloadItem reads an item back by its composed primary key with a strongly
+// consistent Get
+func (eh *ExecutionHelper) reloadItem(ctx context.Context, key map[string]types.AttributeValue) (map[string]interface{}, error) {
+	if eh.entity.client == nil {
+		return nil, NewElectroError("NoClientProvided", "No DynamoDB client was provided to the entity", nil)
+	}
+
+	input := &dynamodb.GetItemInput{
+		TableName:      stringPtr(NewParamsBuilder(eh.entity).getTableName()),
+		Key:            key,
+		ConsistentRead: boolPtr(true),
+	}
+
+	var result *dynamodb.GetItemOutput
+	err := eh.retry(ctx, func() (err error) {
+		result, err = eh.entity.client.GetItem(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, NewElectroError("DynamoDBError", "Failed to execute GetItem", err)
+	}
+
+	var item map[string]interface{}
+	if result.Item != nil {
+		if err := attributevalue.UnmarshalMap(result.Item, &item); err != nil {
+			return nil, NewElectroError("UnmarshalError", "Failed to unmarshal response", err)
+		}
+	}
+	return eh.formatItem(item, false)
+}
+
+// keyFields returns the composed index key fields of a written item
+func (eh *ExecutionHelper) keyFields(item map[string]types.AttributeValue) map[string]string {
+	keys := make(map[string]string)
+	for _, index := range eh.entity.schema.Indexes {
+		fields := []string{index.PK.Field}
+		if index.SK != nil {
+			fields = append(fields, index.SK.Field)
+		}
+		for _, field := range fields {
+			if val, ok := item[field].(*types.AttributeValueMemberS); ok {
+				keys[field] = val.Value
+			}
+		}
+	}
+	return keys
+}
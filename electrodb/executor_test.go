@@ -0,0 +1,1088 @@
+package electrodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestIncludeKeys(t *testing.T) {
+	stored := map[string]types.AttributeValue{
+		"pk":   &types.AttributeValueMemberS{Value: "$testservice#id_1"},
+		"sk":   &types.AttributeValueMemberS{Value: "$user_1#name_alice"},
+		"id":   &types.AttributeValueMemberS{Value: "1"},
+		"name": &types.AttributeValueMemberS{Value: "alice"},
+	}
+	client := &mockClient{
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+		query: func(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{stored}}, nil
+		},
+	}
+
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"name"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	query := entity.Query("primary").QueryKeys(Keys{"id": "1"})
+
+	result, err := query.Go()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if _, ok := result.Data[0]["pk"]; ok {
+		t.Error("Expected keys to be stripped by default")
+	}
+
+	result, err = query.Options(&QueryOptions{IncludeKeys: true}).Go()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	item := result.Data[0]
+	if item["pk"] != "$testservice#id_1" || item["sk"] != "$user_1#name_alice" {
+		t.Errorf("Expected composed keys in result, got %v", item)
+	}
+	if item["name"] != "alice" {
+		t.Errorf("Expected attributes alongside keys, got %v", item)
+	}
+
+	got, err := entity.Get(Keys{"id": "1", "name": "alice"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, ok := got.Data["sk"]; ok {
+		t.Error("Expected keys to be stripped by default")
+	}
+
+	got, err = entity.Get(Keys{"id": "1", "name": "alice"}).Options(&GetOptions{IncludeKeys: true}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Data["pk"] != "$testservice#id_1" || got.Data["sk"] != "$user_1#name_alice" {
+		t.Errorf("Expected composed keys in result, got %v", got.Data)
+	}
+}
+
+func TestWriteHooks(t *testing.T) {
+	var putInput *dynamodb.PutItemInput
+	var updateInputs []*dynamodb.UpdateItemInput
+	deleteCalled := false
+	client := &mockClient{
+		putItem: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			putInput = input
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		updateItem: func(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			updateInputs = append(updateInputs, input)
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+		deleteItem: func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+			deleteCalled = true
+			return &dynamodb.DeleteItemOutput{}, nil
+		},
+	}
+
+	var afterOps []string
+	config := &Config{
+		Client: client,
+		BeforeWrite: func(op string, item Item) (Item, error) {
+			if op == "delete" {
+				return nil, errors.New("deletes are disabled")
+			}
+			if op == "put" {
+				item["name"] = strings.ToUpper(item["name"].(string))
+			}
+			if op == "update" {
+				item["name"] = item["name"].(string) + "!"
+			}
+			return item, nil
+		},
+		AfterWrite: func(op string, resp interface{}) {
+			if _, ok := resp.(*PutResponse); op == "put" && !ok {
+				t.Errorf("Expected *PutResponse for put, got %T", resp)
+			}
+			afterOps = append(afterOps, op)
+		},
+	}
+
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, config)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	if _, err := entity.Put(Item{"id": "1", "name": "alice"}).Go(); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	name := putInput.Item["name"].(*types.AttributeValueMemberS).Value
+	if name != "ALICE" {
+		t.Errorf("Expected BeforeWrite to mutate the item, got name %q", name)
+	}
+
+	_, err = entity.Delete(Keys{"id": "1"}).Go()
+	if err == nil || err.Error() != "deletes are disabled" {
+		t.Errorf("Expected BeforeWrite error to abort delete, got %v", err)
+	}
+	if deleteCalled {
+		t.Error("Expected DeleteItem not to be called after BeforeWrite error")
+	}
+
+	if len(afterOps) != 1 || afterOps[0] != "put" {
+		t.Errorf("Expected AfterWrite only for the successful put, got %v", afterOps)
+	}
+
+	// The hook gets a copy, so running an update again does not re-apply it
+	update := entity.Update(Keys{"id": "1"}).Set(map[string]interface{}{"name": "bob"})
+	for i := 0; i < 2; i++ {
+		if _, err := update.Go(); err != nil {
+			t.Fatalf("Update %d failed: %v", i, err)
+		}
+	}
+	for i, input := range updateInputs {
+		found := false
+		for _, value := range input.ExpressionAttributeValues {
+			if s, ok := value.(*types.AttributeValueMemberS); ok && strings.HasPrefix(s.Value, "bob") {
+				found = true
+				if s.Value != "bob!" {
+					t.Errorf("Update %d: expected name bob!, got %s", i, s.Value)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Update %d: expected the hooked name to be written", i)
+		}
+	}
+}
+
+func TestUpdateReturnValuesRemovePadding(t *testing.T) {
+	var updateInput *dynamodb.UpdateItemInput
+	client := &mockClient{
+		updateItem: func(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			updateInput = input
+			return &dynamodb.UpdateItemOutput{
+				Attributes: map[string]types.AttributeValue{
+					"pk":      &types.AttributeValueMemberS{Value: "$testservice#id_1"},
+					"sk":      &types.AttributeValueMemberS{Value: "$doc#"},
+					"id":      &types.AttributeValueMemberS{Value: "1"},
+					"version": &types.AttributeValueMemberS{Value: "000005"},
+				},
+			}, nil
+		},
+	}
+
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Doc",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":      {Type: AttributeTypeString, Required: true},
+			"version": {Type: AttributeTypeNumber, Padding: &PaddingConfig{Length: 6, Char: "0"}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	result, err := entity.Update(Keys{"id": "1"}).Set(map[string]interface{}{"version": 5}).Go()
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// The SET value is padded on write like a put
+	found := false
+	for placeholder, name := range updateInput.ExpressionAttributeNames {
+		if name != "version" {
+			continue
+		}
+		found = true
+		valueRef := ":val" + strings.TrimPrefix(placeholder, "#attr")
+		got := updateInput.ExpressionAttributeValues[valueRef].(*types.AttributeValueMemberS).Value
+		if got != "000005" {
+			t.Errorf("Expected padded SET value, got %q", got)
+		}
+	}
+	if !found {
+		t.Fatal("Expected version in the update expression")
+	}
+
+	// The returned attributes are unpadded and stripped of key fields
+	if result.Data["version"] != int64(5) {
+		t.Errorf("Expected version 5 as a number, got %#v", result.Data["version"])
+	}
+	if _, ok := result.Data["pk"]; ok {
+		t.Error("Expected key fields to be stripped from returned attributes")
+	}
+}
+
+func TestReadPathsRemovePadding(t *testing.T) {
+	stored := map[string]types.AttributeValue{
+		"pk":          &types.AttributeValueMemberS{Value: "$testservice#id_1"},
+		"id":          &types.AttributeValueMemberS{Value: "1"},
+		"orderNumber": &types.AttributeValueMemberS{Value: "0000000042"},
+	}
+	client := &mockClient{
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+		query: func(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{stored}}, nil
+		},
+		scan: func(*dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+			return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{stored}}, nil
+		},
+		batchGetItem: func(*dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+			return &dynamodb.BatchGetItemOutput{
+				Responses: map[string][]map[string]types.AttributeValue{"TestTable": {stored}},
+			}, nil
+		},
+	}
+
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Order",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":          {Type: AttributeTypeString, Required: true},
+			"orderNumber": {Type: AttributeTypeNumber, Padding: &PaddingConfig{Length: 10, Char: "0"}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	got, err := entity.Get(Keys{"id": "1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	queried, err := entity.Query("primary").Query("1").Go()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	scanned, err := entity.Scan().Go()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	batched, err := entity.BatchGet([]Keys{{"id": "1"}}).Go()
+	if err != nil {
+		t.Fatalf("BatchGet failed: %v", err)
+	}
+
+	results := map[string]map[string]interface{}{
+		"get":      got.Data,
+		"query":    queried.Data[0],
+		"scan":     scanned.Data[0],
+		"batchGet": batched.Data[0],
+	}
+	for path, item := range results {
+		if item["orderNumber"] != int64(42) {
+			t.Errorf("%s: expected orderNumber 42 as a number, got %#v", path, item["orderNumber"])
+		}
+	}
+}
+
+func TestReadKeepsPaddedStrings(t *testing.T) {
+	client := &mockClient{
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+				"pk":  &types.AttributeValueMemberS{Value: "$testservice#id_1"},
+				"id":  &types.AttributeValueMemberS{Value: "1"},
+				"zip": &types.AttributeValueMemberS{Value: "00501"},
+			}}, nil
+		},
+	}
+
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Address",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":  {Type: AttributeTypeString, Required: true},
+			"zip": {Type: AttributeTypeString, Padding: &PaddingConfig{Length: 5, Char: "0"}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	got, err := entity.Get(Keys{"id": "1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Data["zip"] != "00501" {
+		t.Errorf("Expected the padded string zip to be returned as stored, got %#v", got.Data["zip"])
+	}
+}
+
+func TestUpdateSetIfChanged(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":      {Type: AttributeTypeString, Required: true},
+			"name":    {Type: AttributeTypeString},
+			"email":   {Type: AttributeTypeString},
+			"version": {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	var input *dynamodb.UpdateItemInput
+	client := &mockClient{
+		updateItem: func(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			input = in
+			return nil, &types.ConditionalCheckFailedException{}
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	params, err := entity.Update(Keys{"id": "1"}).
+		SetIfChanged(map[string]interface{}{"name": "alice", "email": "a@example.com"}).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	expected := "((#cond0 <> :cond0 OR attribute_not_exists(#cond1)) OR (#cond2 <> :cond1 OR attribute_not_exists(#cond3)))"
+	if params["ConditionExpression"] != expected {
+		t.Errorf("Expected change detection condition %q, got %v", expected, params["ConditionExpression"])
+	}
+	names := params["ExpressionAttributeNames"].(map[string]string)
+	if names["#cond0"] != "email" || names["#cond2"] != "name" {
+		t.Errorf("Expected condition names for email and name, got %v", names)
+	}
+	if !strings.Contains(params["UpdateExpression"].(string), "SET") {
+		t.Errorf("Expected values to be set, got %v", params["UpdateExpression"])
+	}
+
+	// Explicit conditions are combined with the change detection
+	params, err = entity.Update(Keys{"id": "1"}).
+		SetIfChanged(map[string]interface{}{"name": "alice"}).
+		Condition(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return attrs["version"].Eq(3)
+		}).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	expected = "(#cond0 = :cond0) AND (((#cond1 <> :cond1 OR attribute_not_exists(#cond2))))"
+	if params["ConditionExpression"] != expected {
+		t.Errorf("Expected combined condition %q, got %v", expected, params["ConditionExpression"])
+	}
+
+	// An unchanged item fails the condition, which is an error by default
+	_, err = entity.Update(Keys{"id": "1"}).SetIfChanged(map[string]interface{}{"name": "alice"}).Go()
+	if err == nil {
+		t.Error("Expected conditional check failure without SkipUnchanged")
+	}
+	if input == nil || input.ConditionExpression == nil {
+		t.Fatal("Expected the condition to be sent with UpdateItem")
+	}
+
+	// ...and a no-op with SkipUnchanged
+	resp, err := entity.Update(Keys{"id": "1"}).
+		SetIfChanged(map[string]interface{}{"name": "alice"}).
+		Options(&UpdateOptions{SkipUnchanged: true}).
+		Go()
+	if err != nil {
+		t.Fatalf("Expected unchanged update to succeed, got %v", err)
+	}
+	if !resp.Unchanged {
+		t.Error("Expected response to be marked Unchanged")
+	}
+
+	// SkipUnchanged cannot mask other condition failures
+	var electroErr *ElectroError
+	_, err = entity.Update(Keys{"id": "1"}).
+		SetIfChanged(map[string]interface{}{"name": "alice"}).
+		Condition(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return attrs["version"].Eq(3)
+		}).
+		Options(&UpdateOptions{SkipUnchanged: true}).
+		Go()
+	if !errors.As(err, &electroErr) || electroErr.Code != ErrInvalidOperation {
+		t.Errorf("Expected InvalidOperation for SkipUnchanged with Condition, got %v", err)
+	}
+	_, err = entity.Update(Keys{"id": "1"}).
+		SetIfChanged(map[string]interface{}{"name": "alice"}).
+		GuardKeys().
+		Options(&UpdateOptions{SkipUnchanged: true}).
+		Go()
+	if !errors.As(err, &electroErr) || electroErr.Code != ErrInvalidOperation {
+		t.Errorf("Expected InvalidOperation for SkipUnchanged with GuardKeys, got %v", err)
+	}
+}
+
+func TestUpdateSetIfChangedComparesStoredValues(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":    {Type: AttributeTypeString, Required: true},
+			"email": {Type: AttributeTypeString, Normalize: &Normalization{Trim: true, Casing: "lower"}},
+			"rank":  {Type: AttributeTypeNumber, Padding: &PaddingConfig{Length: 4, Char: "0"}},
+			"tag": {Type: AttributeTypeString, Set: func(value interface{}) interface{} {
+				return "tag:" + value.(string)
+			}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	params, err := entity.Update(Keys{"id": "1"}).
+		SetIfChanged(map[string]interface{}{"email": " A@Example.com ", "rank": 7, "tag": "x"}).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	names := params["ExpressionAttributeNames"].(map[string]string)
+	values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	expected := map[string]string{"email": "a@example.com", "rank": "0007", "tag": "tag:x"}
+	compared := 0
+	for _, clause := range strings.Split(params["ConditionExpression"].(string), " OR ") {
+		var nameRef, valueRef string
+		if _, err := fmt.Sscanf(strings.Trim(clause, "()"), "%s <> %s", &nameRef, &valueRef); err != nil {
+			continue
+		}
+		name := names[nameRef]
+		compared++
+		if got, ok := values[valueRef].(*types.AttributeValueMemberS); !ok || got.Value != expected[name] {
+			t.Errorf("Expected %s to be compared as %q, got %#v", name, expected[name], values[valueRef])
+		}
+	}
+	if compared != len(expected) {
+		t.Errorf("Expected %d compared values, got %d in %v", len(expected), compared, params["ConditionExpression"])
+	}
+}
+
+func TestPutReturnsKeys(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Order",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"orderId":  {Type: AttributeTypeString, Required: true},
+			"customer": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+			"byCustomer": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"customer"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"orderId"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: &mockClient{}})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	expected := map[string]string{
+		"pk":     "$testservice#orderid_o1",
+		"sk":     "$order_1",
+		"gsi1pk": "$testservice#customer_c1",
+		"gsi1sk": "$order_1#orderid_o1",
+	}
+	for _, raw := range []bool{false, true} {
+		resp, err := entity.Put(Item{"orderId": "o1", "customer": "c1"}).
+			Options(&PutOptions{Raw: raw}).
+			Go()
+		if err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if len(resp.Keys) != len(expected) {
+			t.Errorf("Raw=%v: expected %d keys, got %v", raw, len(expected), resp.Keys)
+		}
+		for field, value := range expected {
+			if resp.Keys[field] != value {
+				t.Errorf("Raw=%v: expected %s=%s, got %s", raw, field, value, resp.Keys[field])
+			}
+		}
+	}
+}
+
+func TestUpdateConditionWithValues(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":    {Type: AttributeTypeString, Required: true},
+			"price": {Type: AttributeTypeNumber},
+			"stock": {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	var input *dynamodb.UpdateItemInput
+	client := &mockClient{
+		updateItem: func(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			input = in
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// The condition is declared before Set and still sees the new price
+	update := entity.Update(Keys{"id": "1"}).
+		ConditionWithValues(func(attrs map[string]*AttributeRef, ops *OperationBuilder, values UpdateValues) string {
+			return fmt.Sprintf("%s AND %s", attrs["price"].Lt(values.Set["price"]), attrs["stock"].Gte(values.Add["stock"]))
+		}).
+		Set(map[string]interface{}{"price": 12}).
+		Add(map[string]interface{}{"stock": 5})
+
+	if _, err := update.Go(); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if input == nil || input.ConditionExpression == nil {
+		t.Fatal("Expected a condition expression to be sent")
+	}
+	expected := "#cond0 < :cond0 AND #cond1 >= :cond1"
+	if *input.ConditionExpression != expected {
+		t.Errorf("Expected condition %q, got %q", expected, *input.ConditionExpression)
+	}
+	if input.ExpressionAttributeNames["#cond0"] != "price" || input.ExpressionAttributeNames["#cond1"] != "stock" {
+		t.Errorf("Expected condition names for price and stock, got %v", input.ExpressionAttributeNames)
+	}
+	price, ok := input.ExpressionAttributeValues[":cond0"].(*types.AttributeValueMemberN)
+	if !ok || price.Value != "12" {
+		t.Errorf("Expected :cond0 to hold the new price, got %#v", input.ExpressionAttributeValues[":cond0"])
+	}
+	stock, ok := input.ExpressionAttributeValues[":cond1"].(*types.AttributeValueMemberN)
+	if !ok || stock.Value != "5" {
+		t.Errorf("Expected :cond1 to hold the added stock, got %#v", input.ExpressionAttributeValues[":cond1"])
+	}
+
+	// A later Condition replaces it
+	params, err := update.Condition(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return ops.Exists(attrs["price"])
+	}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	if params["ConditionExpression"] != "attribute_exists(#cond0)" {
+		t.Errorf("Expected Condition to replace ConditionWithValues, got %v", params["ConditionExpression"])
+	}
+}
+
+func TestAttributeAccessFlags(t *testing.T) {
+	stored := map[string]types.AttributeValue{
+		"pk":       &types.AttributeValueMemberS{Value: "$testservice#id_1"},
+		"id":       &types.AttributeValueMemberS{Value: "1"},
+		"name":     &types.AttributeValueMemberS{Value: "alice"},
+		"revision": &types.AttributeValueMemberN{Value: "3"},
+		"internal": &types.AttributeValueMemberS{Value: "bookkeeping"},
+	}
+	var putInput *dynamodb.PutItemInput
+	client := &mockClient{
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+		putItem: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			putInput = input
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+			"revision": {
+				Type:    AttributeTypeNumber,
+				NoWrite: true,
+				Default: func() interface{} { return 1 },
+			},
+			"internal": {Type: AttributeTypeString, NoRead: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	isNoWrite := func(err error) bool {
+		electroErr, ok := err.(*ElectroError)
+		return ok && electroErr.Code == ErrNoWriteViolation
+	}
+
+	// NoWrite attributes are computed, never accepted from the caller
+	if _, err := entity.Put(Item{"id": "1", "name": "alice"}).Go(); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if revision, ok := putInput.Item["revision"].(*types.AttributeValueMemberN); !ok || revision.Value != "1" {
+		t.Errorf("Expected the default to compute revision, got %#v", putInput.Item["revision"])
+	}
+	if _, err := entity.Put(Item{"id": "1", "revision": 5}).Params(); !isNoWrite(err) {
+		t.Errorf("Expected NoWriteViolation from Put, got %v", err)
+	}
+	if _, err := entity.Update(Keys{"id": "1"}).Set(map[string]interface{}{"revision": 5}).Params(); !isNoWrite(err) {
+		t.Errorf("Expected NoWriteViolation from Set, got %v", err)
+	}
+	if _, err := entity.Update(Keys{"id": "1"}).Add(map[string]interface{}{"revision": 1}).Params(); !isNoWrite(err) {
+		t.Errorf("Expected NoWriteViolation from Add, got %v", err)
+	}
+
+	// NoRead attributes are stripped from formatted and raw results
+	result, err := entity.Get(Keys{"id": "1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, ok := result.Data["internal"]; ok {
+		t.Error("Expected NoRead attribute to be stripped")
+	}
+	if result.Data["name"] != "alice" {
+		t.Errorf("Expected other attributes to be returned, got %v", result.Data)
+	}
+
+	raw, err := entity.Get(Keys{"id": "1"}).Options(&GetOptions{Raw: true}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, ok := raw.Data["internal"]; ok {
+		t.Error("Expected NoRead attribute to be stripped in raw mode")
+	}
+	if raw.Data["pk"] != "$testservice#id_1" {
+		t.Errorf("Expected raw mode to keep key fields, got %v", raw.Data)
+	}
+}
+
+func TestConsistentReadDefault(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Order",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"orderId":  {Type: AttributeTypeString, Required: true},
+			"customer": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+			"byCustomer": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"customer"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"orderId"}},
+			},
+		},
+	}
+
+	var getConsistent, queryConsistent *bool
+	client := &mockClient{
+		getItem: func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			getConsistent = input.ConsistentRead
+			return &dynamodb.GetItemOutput{}, nil
+		},
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			queryConsistent = input.ConsistentRead
+			return &dynamodb.QueryOutput{}, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client, ConsistentRead: true})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// The default applies to primary-index reads
+	if _, err := entity.Get(Keys{"orderId": "o1"}).Go(); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if getConsistent == nil || !*getConsistent {
+		t.Errorf("Expected Get to be consistent by default, got %v", getConsistent)
+	}
+	if _, err := entity.Query("primary").Query("o1").Go(); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if queryConsistent == nil || !*queryConsistent {
+		t.Errorf("Expected primary-index query to be consistent by default, got %v", queryConsistent)
+	}
+
+	// Per-call options override it
+	if _, err := entity.Get(Keys{"orderId": "o1"}).Options(&GetOptions{Consistent: boolPtr(false)}).Go(); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if getConsistent != nil && *getConsistent {
+		t.Errorf("Expected override to disable consistent Get, got %v", getConsistent)
+	}
+
+	// GSI queries ignore the default, and reject an explicit request
+	if _, err := entity.Query("byCustomer").Query("c1").Go(); err != nil {
+		t.Fatalf("GSI query failed: %v", err)
+	}
+	if queryConsistent != nil {
+		t.Errorf("Expected GSI query to ignore the default, got %v", *queryConsistent)
+	}
+	_, err = entity.Query("byCustomer").Query("c1").Options(&QueryOptions{Consistent: boolPtr(true)}).Go()
+	var electroErr *ElectroError
+	if !errors.As(err, &electroErr) || electroErr.Code != ErrInvalidOperation {
+		t.Errorf("Expected InvalidOperation for a consistent GSI query, got %v", err)
+	}
+}
+
+func TestReloadAfterWrite(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Order",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"orderId":  {Type: AttributeTypeString, Required: true},
+			"customer": {Type: AttributeTypeString, Required: true},
+			"status":   {Type: AttributeTypeString, Default: func() interface{} { return "pending" }},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	var stored map[string]types.AttributeValue
+	var getInput *dynamodb.GetItemInput
+	client := &mockClient{
+		putItem: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			stored = input.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItem: func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			getInput = input
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	resp, err := entity.Put(Item{"orderId": "o1", "customer": "c1"}).Go()
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if len(resp.Data) != 0 {
+		t.Fatalf("Expected no returned attributes, got %v", resp.Data)
+	}
+
+	item, err := resp.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if item["orderId"] != "o1" || item["customer"] != "c1" || item["status"] != "pending" {
+		t.Errorf("Expected the stored item with defaults, got %v", item)
+	}
+	if _, ok := item["pk"]; ok {
+		t.Errorf("Expected key fields to be stripped, got %v", item)
+	}
+	if getInput.ConsistentRead == nil || !*getInput.ConsistentRead {
+		t.Error("Expected Reload to read consistently")
+	}
+	if pk := getInput.Key["pk"].(*types.AttributeValueMemberS).Value; pk != "$testservice#orderid_o1" {
+		t.Errorf("Expected Reload to use the composed key, got %s", pk)
+	}
+	if len(getInput.Key) != 2 {
+		t.Errorf("Expected only primary key fields, got %v", getInput.Key)
+	}
+}
+
+func TestAttributeAliases(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":          {Type: AttributeTypeString, Required: true},
+			"displayName": {Type: AttributeTypeString, Aliases: []string{"name", "fullName"}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	stored := map[string]types.AttributeValue{
+		"pk":   &types.AttributeValueMemberS{Value: "$testservice#id_1"},
+		"id":   &types.AttributeValueMemberS{Value: "1"},
+		"name": &types.AttributeValueMemberS{Value: "Ada"},
+	}
+	client := &mockClient{
+		getItem: func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	resp, err := entity.Get(Keys{"id": "1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if resp.Data["displayName"] != "Ada" {
+		t.Errorf("Expected the old field to surface as displayName, got %v", resp.Data)
+	}
+	if _, ok := resp.Data["name"]; ok {
+		t.Errorf("Expected the old field name to be dropped, got %v", resp.Data)
+	}
+
+	// A value under the current name wins over an alias
+	stored["displayName"] = &types.AttributeValueMemberS{Value: "Ada Lovelace"}
+	resp, err = entity.Get(Keys{"id": "1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if resp.Data["displayName"] != "Ada Lovelace" || len(resp.Data) != 2 {
+		t.Errorf("Expected the current field to win, got %v", resp.Data)
+	}
+
+	// Aliases cannot shadow declared attributes
+	schema.Attributes["displayName"].Aliases = []string{"id"}
+	if _, err := NewEntity(schema, nil); err == nil {
+		t.Error("Expected an alias naming an attribute to be rejected")
+	}
+}
+
+func TestPutIfNewer(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Event",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":        {Type: AttributeTypeString, Required: true},
+			"updatedAt": {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	var input *dynamodb.PutItemInput
+	client := &mockClient{
+		putItem: func(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			input = in
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	if _, err := entity.Put(Item{"id": "e1", "updatedAt": 42}).IfNewer("updatedAt").Go(); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if input.ConditionExpression == nil {
+		t.Fatal("Expected the put to carry a condition")
+	}
+	if expr := *input.ConditionExpression; expr != "attribute_not_exists(#cond0) OR #cond1 < :cond0" {
+		t.Errorf("Unexpected condition: %s", expr)
+	}
+	if input.ExpressionAttributeNames["#cond0"] != "pk" || input.ExpressionAttributeNames["#cond1"] != "updatedAt" {
+		t.Errorf("Unexpected names: %v", input.ExpressionAttributeNames)
+	}
+	if value, ok := input.ExpressionAttributeValues[":cond0"].(*types.AttributeValueMemberN); !ok || value.Value != "42" {
+		t.Errorf("Expected the incoming updatedAt as the compared value, got %v", input.ExpressionAttributeValues[":cond0"])
+	}
+
+	// Params reflect the condition too
+	params, err := entity.Put(Item{"id": "e1", "updatedAt": 42}).IfNewer("updatedAt").Params()
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+	if params["ConditionExpression"] != *input.ConditionExpression {
+		t.Errorf("Expected Params to include the condition, got %v", params["ConditionExpression"])
+	}
+
+	if _, err := entity.Put(Item{"id": "e1"}).IfNewer("updatedAt").Go(); err == nil {
+		t.Error("Expected an error without an incoming value")
+	}
+
+	// A padded attribute is compared in its stored, padded form
+	schema.Attributes["updatedAt"].Padding = &PaddingConfig{Length: 6, Char: "0"}
+	padded, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if _, err := padded.Put(Item{"id": "e1", "updatedAt": 42}).IfNewer("updatedAt").Go(); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if value, ok := input.ExpressionAttributeValues[":cond0"].(*types.AttributeValueMemberS); !ok || value.Value != "000042" {
+		t.Errorf("Expected the padded incoming value, got %v", input.ExpressionAttributeValues[":cond0"])
+	}
+	if stored, ok := input.Item["updatedAt"].(*types.AttributeValueMemberS); !ok || stored.Value != "000042" {
+		t.Errorf("Expected updatedAt to be stored padded, got %v", input.Item["updatedAt"])
+	}
+}
+
+func TestUpdateGuardKeys(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Order",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"customerId": {Type: AttributeTypeString, Required: true},
+			"orderNo":    {Type: AttributeTypeString, Required: true, Padding: &PaddingConfig{Length: 4, Char: "0"}},
+			"status":     {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"customerId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"orderNo"}},
+			},
+		},
+	}
+
+	var input *dynamodb.UpdateItemInput
+	client := &mockClient{
+		updateItem: func(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			input = in
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	_, err = entity.Update(Keys{"customerId": "c1", "orderNo": "7"}).
+		Set(map[string]interface{}{"status": "shipped"}).
+		Condition(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return attrs["status"].Eq("packed")
+		}).
+		GuardKeys().
+		Go()
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	expected := "(#cond0 = :cond0) AND (#cond1 = :cond1 AND #cond2 = :cond2)"
+	if input.ConditionExpression == nil || *input.ConditionExpression != expected {
+		t.Fatalf("Expected condition %q, got %v", expected, input.ConditionExpression)
+	}
+	if input.ExpressionAttributeNames["#cond1"] != "customerId" || input.ExpressionAttributeNames["#cond2"] != "orderNo" {
+		t.Errorf("Expected guards on customerId and orderNo, got %v", input.ExpressionAttributeNames)
+	}
+	if v := input.ExpressionAttributeValues[":cond2"].(*types.AttributeValueMemberS).Value; v != "0007" {
+		t.Errorf("Expected the guard to compare the padded facet value, got %q", v)
+	}
+
+	// GuardKeys on its own is the whole condition
+	params, err := entity.Update(Keys{"customerId": "c1", "orderNo": "7"}).
+		Set(map[string]interface{}{"status": "shipped"}).
+		GuardKeys().
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	if params["ConditionExpression"] != "#cond0 = :cond0 AND #cond1 = :cond1" {
+		t.Errorf("Unexpected guard condition: %v", params["ConditionExpression"])
+	}
+}
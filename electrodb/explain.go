@@ -0,0 +1,163 @@
+package electrodb
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// explainedFields are the request parameters rendered by explainParams, in
+// display order
+var explainedFields = []struct {
+	key   string
+	label string
+}{
+	{"TableName", "Table"},
+	{"IndexName", "Index"},
+	{"Key", "Key"},
+	{"Item", "Item"},
+	{"KeyConditionExpression", "KeyCondition"},
+	{"UpdateExpression", "Update"},
+	{"FilterExpression", "Filter"},
+	{"ConditionExpression", "Condition"},
+	{"ProjectionExpression", "Projection"},
+	{"Limit", "Limit"},
+	{"ScanIndexForward", "ScanIndexForward"},
+	{"ConsistentRead", "ConsistentRead"},
+	{"ReturnValues", "ReturnValues"},
+}
+
+// placeholderPattern matches expression name and value placeholders
+var placeholderPattern = regexp.MustCompile(`[#:][A-Za-z0-9_]+`)
+
+// explainParams renders request parameters as one "Label: value" line per
+// field, with placeholders in expressions replaced by the names and values
+// they stand for
+func explainParams(operation string, params map[string]interface{}, err error) string {
+	if err != nil {
+		return fmt.Sprintf("%s: %v", operation, err)
+	}
+
+	names, _ := params["ExpressionAttributeNames"].(map[string]string)
+	values, _ := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	substitute := func(expression string) string {
+		return placeholderPattern.ReplaceAllStringFunc(expression, func(placeholder string) string {
+			if name, ok := names[placeholder]; ok {
+				return name
+			}
+			if value, ok := values[placeholder]; ok {
+				return describeAttributeValue(value)
+			}
+			return placeholder
+		})
+	}
+
+	lines := []string{operation}
+	for _, field := range explainedFields {
+		value, ok := params[field.key]
+		if !ok {
+			continue
+		}
+
+		var rendered string
+		switch v := value.(type) {
+		case string:
+			if strings.HasSuffix(field.key, "Expression") {
+				rendered = substitute(v)
+			} else {
+				rendered = v
+			}
+		case map[string]types.AttributeValue:
+			rendered = describeAttributeValue(&types.AttributeValueMemberM{Value: v})
+		default:
+			rendered = fmt.Sprintf("%v", v)
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", field.label, rendered))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describeAttributeValue renders an attribute value in a readable,
+// JSON-like form
+func describeAttributeValue(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return strconv.Quote(v.Value)
+	case *types.AttributeValueMemberN:
+		return v.Value
+	case *types.AttributeValueMemberBOOL:
+		return strconv.FormatBool(v.Value)
+	case *types.AttributeValueMemberNULL:
+		return "null"
+	case *types.AttributeValueMemberB:
+		return fmt.Sprintf("<%d bytes>", len(v.Value))
+	case *types.AttributeValueMemberSS:
+		quoted := make([]string, len(v.Value))
+		for i, s := range v.Value {
+			quoted[i] = strconv.Quote(s)
+		}
+		return "<<" + strings.Join(quoted, ", ") + ">>"
+	case *types.AttributeValueMemberNS:
+		return "<<" + strings.Join(v.Value, ", ") + ">>"
+	case *types.AttributeValueMemberBS:
+		return fmt.Sprintf("<<%d binary values>>", len(v.Value))
+	case *types.AttributeValueMemberL:
+		elements := make([]string, len(v.Value))
+		for i, element := range v.Value {
+			elements[i] = describeAttributeValue(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *types.AttributeValueMemberM:
+		keys := make([]string, 0, len(v.Value))
+		for key := range v.Value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]string, len(keys))
+		for i, key := range keys {
+			fields[i] = fmt.Sprintf("%s: %s", key, describeAttributeValue(v.Value[key]))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return fmt.Sprintf("%v", av)
+}
+
+// String renders the effective GetItem request for troubleshooting
+func (g *GetOperation) String() string {
+	params, err := g.Params()
+	return explainParams("GetItem", params, err)
+}
+
+// String renders the effective PutItem request for troubleshooting
+func (p *PutOperation) String() string {
+	params, err := p.Params()
+	return explainParams("PutItem", params, err)
+}
+
+// String renders the effective UpdateItem request for troubleshooting
+func (u *UpdateOperation) String() string {
+	params, err := u.Params()
+	return explainParams("UpdateItem", params, err)
+}
+
+// String renders the effective DeleteItem request for troubleshooting
+func (d *DeleteOperation) String() string {
+	params, err := d.Params()
+	return explainParams("DeleteItem", params, err)
+}
+
+// String renders the effective Scan request for troubleshooting
+func (s *ScanOperation) String() string {
+	params, err := s.Params()
+	return explainParams("Scan", params, err)
+}
+
+// String renders the effective Query request for troubleshooting
+func (qc *QueryChain) String() string {
+	params, err := qc.Params()
+	return explainParams("Query", params, err)
+}
@@ -0,0 +1,59 @@
+package electrodb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOperationString(t *testing.T) {
+	schema := &Schema{
+		Service: "MallService",
+		Entity:  "Store",
+		Table:   "MallTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":     {Type: AttributeTypeString, Required: true},
+			"mall":   {Type: AttributeTypeString, Required: true},
+			"status": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+			"byMall": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"mall"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	query := entity.Query("byMall").Query("EastPointe").Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return attrs["status"].Eq("open")
+	})
+	rendered := query.String()
+	for _, want := range []string{
+		"Query\nTable: MallTable\nIndex: gsi1\n",
+		`KeyCondition: gsi1pk = "$mallservice#mall_eastpointe" AND begins_with(gsi1sk, "$store_1#id_")`,
+		`Filter: status = "open"`,
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Expected %q in:\n%s", want, rendered)
+		}
+	}
+
+	rendered = entity.Get(Keys{"id": "s1"}).String()
+	if !strings.Contains(rendered, `Key: {pk: "$mallservice#id_s1", sk: "$store_1"}`) {
+		t.Errorf("Expected the composed key in:\n%s", rendered)
+	}
+
+	if rendered = entity.Get(Keys{}).String(); !strings.HasPrefix(rendered, "GetItem: ") {
+		t.Errorf("Expected the params error to be rendered, got:\n%s", rendered)
+	}
+}
@@ -2,8 +2,11 @@ package electrodb
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
@@ -15,6 +18,14 @@ type ExpressionBuilder struct {
 	nameCount  int
 	valueCount int
 	attributes map[string]*AttributeDefinition
+	timeFormat TimeFormat
+	// placeholder prefixes, "#attr" and ":val" unless set; conditions use
+	// their own so they can be merged into update and put expressions
+	namePrefix  string
+	valuePrefix string
+	// keyFields are index key fields (pk, sk, ...) offered to where
+	// callbacks alongside the attributes; set for conditions
+	keyFields []string
 }
 
 // NewExpressionBuilder creates a new expression builder
@@ -29,10 +40,36 @@ func NewExpressionBuilder(attributes map[string]*AttributeDefinition) *Expressio
 	}
 }
 
+// clone returns an independent copy of the expression builder, including its
+// placeholder counters so that further expressions never reuse a placeholder
+func (eb *ExpressionBuilder) clone() *ExpressionBuilder {
+	names := make(map[string]string, len(eb.names))
+	for k, v := range eb.names {
+		names[k] = v
+	}
+	values := make(map[string]types.AttributeValue, len(eb.values))
+	for k, v := range eb.values {
+		values[k] = v
+	}
+	return &ExpressionBuilder{
+		names:       names,
+		values:      values,
+		expression:  eb.expression,
+		nameCount:   eb.nameCount,
+		valueCount:  eb.valueCount,
+		attributes:  eb.attributes,
+		timeFormat:  eb.timeFormat,
+		namePrefix:  eb.namePrefix,
+		valuePrefix: eb.valuePrefix,
+		keyFields:   eb.keyFields,
+	}
+}
+
 // AttributeRef represents a reference to an attribute in an expression
 type AttributeRef struct {
 	builder *ExpressionBuilder
 	name    string
+	path    []string // nested map path, see OperationBuilder.Path
 }
 
 // OperationBuilder provides filter operation methods
@@ -45,18 +82,39 @@ type WhereCallback func(attrs map[string]*AttributeRef, ops *OperationBuilder) s
 
 // addName adds an attribute name to the expression
 func (eb *ExpressionBuilder) addName(name string) string {
-	placeholder := fmt.Sprintf("#attr%d", eb.nameCount)
+	prefix := eb.namePrefix
+	if prefix == "" {
+		prefix = "#attr"
+	}
+	placeholder := fmt.Sprintf("%s%d", prefix, eb.nameCount)
 	eb.nameCount++
 	eb.names[placeholder] = name
 	return placeholder
 }
 
+// nameRef adds the attribute's name, or each segment of its nested path, to
+// the expression and returns the document path placeholder, e.g. #attr0.#attr1
+func (ar *AttributeRef) nameRef() string {
+	if len(ar.path) == 0 {
+		return ar.builder.addName(ar.name)
+	}
+	refs := make([]string, len(ar.path))
+	for i, segment := range ar.path {
+		refs[i] = ar.builder.addName(segment)
+	}
+	return strings.Join(refs, ".")
+}
+
 // addValue adds a value to the expression
 func (eb *ExpressionBuilder) addValue(value interface{}) (string, error) {
-	placeholder := fmt.Sprintf(":val%d", eb.valueCount)
+	prefix := eb.valuePrefix
+	if prefix == "" {
+		prefix = ":val"
+	}
+	placeholder := fmt.Sprintf("%s%d", prefix, eb.valueCount)
 	eb.valueCount++
 
-	av, err := marshalValue(value)
+	av, err := marshalValue(value, eb.timeFormat)
 	if err != nil {
 		return "", err
 	}
@@ -65,8 +123,28 @@ func (eb *ExpressionBuilder) addValue(value interface{}) (string, error) {
 	return placeholder, nil
 }
 
-// marshalValue marshals a Go value to a DynamoDB attribute value
-func marshalValue(value interface{}) (types.AttributeValue, error) {
+// addValues adds several values, registering none of them when any fails
+// to marshal, so a failed condition leaves no unused placeholders behind
+func (eb *ExpressionBuilder) addValues(values []interface{}) ([]string, error) {
+	for _, value := range values {
+		if _, err := marshalValue(value, eb.timeFormat); err != nil {
+			return nil, err
+		}
+	}
+	refs := make([]string, len(values))
+	for i, value := range values {
+		ref, err := eb.addValue(value)
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = ref
+	}
+	return refs, nil
+}
+
+// marshalValue marshals a Go value to a DynamoDB attribute value, storing
+// time.Time in the given format
+func marshalValue(value interface{}, format TimeFormat) (types.AttributeValue, error) {
 	switch v := value.(type) {
 	case string:
 		return &types.AttributeValueMemberS{Value: v}, nil
@@ -84,11 +162,119 @@ func marshalValue(value interface{}) (types.AttributeValue, error) {
 		return &types.AttributeValueMemberBOOL{Value: v}, nil
 	case nil:
 		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case time.Time, *time.Time:
+		return marshalValue(normalizeTime(v, format), format)
+	case []byte:
+		return &types.AttributeValueMemberB{Value: v}, nil
+	case [][]byte:
+		return &types.AttributeValueMemberBS{Value: v}, nil
 	default:
 		return &types.AttributeValueMemberS{Value: fmt.Sprintf("%v", v)}, nil
 	}
 }
 
+// rfc3339Fixed is RFC 3339 with nanoseconds that keeps trailing zeros, so
+// every UTC time has the same width and strings sort in time order
+const rfc3339Fixed = "2006-01-02T15:04:05.000000000Z07:00"
+
+// normalizeTime converts time.Time (and *time.Time) values to the configured
+// storage representation; other values are returned unchanged
+func normalizeTime(value interface{}, format TimeFormat) interface{} {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		t = *v
+	default:
+		return value
+	}
+
+	switch format {
+	case TimeFormatEpochSeconds:
+		return t.Unix()
+	case TimeFormatEpochMillis:
+		return t.UnixMilli()
+	default:
+		return t.UTC().Format(rfc3339Fixed)
+	}
+}
+
+// marshalAttribute marshals an item or update value, serializing time.Time in
+// the given format and [][]byte as a binary set rather than a list
+func marshalAttribute(value interface{}, format TimeFormat) (types.AttributeValue, error) {
+	if v, ok := value.([][]byte); ok {
+		return &types.AttributeValueMemberBS{Value: v}, nil
+	}
+	return attributevalue.Marshal(normalizeTime(value, format))
+}
+
+// marshalItem marshals every top-level value of an item with marshalAttribute
+func marshalItem(item Item, format TimeFormat) (map[string]types.AttributeValue, error) {
+	result := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		av, err := marshalAttribute(v, format)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = av
+	}
+	return result, nil
+}
+
+// auditedExpressions are the request parameters whose placeholders must
+// resolve against ExpressionAttributeNames and ExpressionAttributeValues
+var auditedExpressions = []string{
+	"KeyConditionExpression",
+	"UpdateExpression",
+	"FilterExpression",
+	"ConditionExpression",
+	"ProjectionExpression",
+}
+
+// auditExpressions checks that every placeholder used by the request's
+// expressions is defined, and that every defined name and value is used.
+// DynamoDB rejects both cases, so catching them before the call points at
+// the builder that produced them
+func auditExpressions(params map[string]interface{}) error {
+	names, _ := params["ExpressionAttributeNames"].(map[string]string)
+	values, _ := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+
+	used := make(map[string]bool)
+	for _, key := range auditedExpressions {
+		expression, _ := params[key].(string)
+		for _, placeholder := range placeholderPattern.FindAllString(expression, -1) {
+			used[placeholder] = true
+			if _, ok := names[placeholder]; ok && placeholder[0] == '#' {
+				continue
+			}
+			if _, ok := values[placeholder]; ok && placeholder[0] == ':' {
+				continue
+			}
+			return NewElectroError("MalformedExpression",
+				fmt.Sprintf("%s references undefined placeholder '%s'", key, placeholder), nil)
+		}
+	}
+
+	for _, placeholder := range sortedKeys(names) {
+		if !used[placeholder] {
+			return NewElectroError("MalformedExpression",
+				fmt.Sprintf("Expression attribute name '%s' is not used by any expression", placeholder), nil)
+		}
+	}
+	for _, placeholder := range sortedKeys(values) {
+		if !used[placeholder] {
+			return NewElectroError("MalformedExpression",
+				fmt.Sprintf("Expression attribute value '%s' is not used by any expression", placeholder), nil)
+		}
+	}
+
+	return nil
+}
+
 // AddExpression adds an expression to the builder
 func (eb *ExpressionBuilder) AddExpression(expr string) {
 	if eb.expression == "" {
@@ -112,9 +298,13 @@ func (eb *ExpressionBuilder) GetExpression() string {
 	return eb.expression
 }
 
-// Eq creates an equals condition
+// Eq creates an equals condition. Eq(nil) is equivalent to ops.IsNull, since
+// DynamoDB never matches a missing attribute with "="
 func (ar *AttributeRef) Eq(value interface{}) string {
-	nameRef := ar.builder.addName(ar.name)
+	if value == nil {
+		return ar.builder.isNull(ar.nameRef())
+	}
+	nameRef := ar.nameRef()
 	valueRef, err := ar.builder.addValue(value)
 	if err != nil {
 		return ""
@@ -122,9 +312,12 @@ func (ar *AttributeRef) Eq(value interface{}) string {
 	return fmt.Sprintf("%s = %s", nameRef, valueRef)
 }
 
-// Ne creates a not-equals condition
+// Ne creates a not-equals condition. Ne(nil) is equivalent to ops.IsNotNull
 func (ar *AttributeRef) Ne(value interface{}) string {
-	nameRef := ar.builder.addName(ar.name)
+	if value == nil {
+		return ar.builder.isNotNull(ar.nameRef())
+	}
+	nameRef := ar.nameRef()
 	valueRef, err := ar.builder.addValue(value)
 	if err != nil {
 		return ""
@@ -134,7 +327,7 @@ func (ar *AttributeRef) Ne(value interface{}) string {
 
 // Gt creates a greater-than condition
 func (ar *AttributeRef) Gt(value interface{}) string {
-	nameRef := ar.builder.addName(ar.name)
+	nameRef := ar.nameRef()
 	valueRef, err := ar.builder.addValue(value)
 	if err != nil {
 		return ""
@@ -144,7 +337,7 @@ func (ar *AttributeRef) Gt(value interface{}) string {
 
 // Gte creates a greater-than-or-equal condition
 func (ar *AttributeRef) Gte(value interface{}) string {
-	nameRef := ar.builder.addName(ar.name)
+	nameRef := ar.nameRef()
 	valueRef, err := ar.builder.addValue(value)
 	if err != nil {
 		return ""
@@ -154,7 +347,7 @@ func (ar *AttributeRef) Gte(value interface{}) string {
 
 // Lt creates a less-than condition
 func (ar *AttributeRef) Lt(value interface{}) string {
-	nameRef := ar.builder.addName(ar.name)
+	nameRef := ar.nameRef()
 	valueRef, err := ar.builder.addValue(value)
 	if err != nil {
 		return ""
@@ -164,7 +357,7 @@ func (ar *AttributeRef) Lt(value interface{}) string {
 
 // Lte creates a less-than-or-equal condition
 func (ar *AttributeRef) Lte(value interface{}) string {
-	nameRef := ar.builder.addName(ar.name)
+	nameRef := ar.nameRef()
 	valueRef, err := ar.builder.addValue(value)
 	if err != nil {
 		return ""
@@ -174,7 +367,7 @@ func (ar *AttributeRef) Lte(value interface{}) string {
 
 // Between creates a between condition
 func (ar *AttributeRef) Between(start, end interface{}) string {
-	nameRef := ar.builder.addName(ar.name)
+	nameRef := ar.nameRef()
 	startRef, err := ar.builder.addValue(start)
 	if err != nil {
 		return ""
@@ -186,9 +379,20 @@ func (ar *AttributeRef) Between(start, end interface{}) string {
 	return fmt.Sprintf("(%s BETWEEN %s AND %s)", nameRef, startRef, endRef)
 }
 
-// Contains creates a contains condition
+// Contains creates a contains condition. A slice value (other than []byte)
+// checks set or list membership of every element, like ContainsAll.
 func (ar *AttributeRef) Contains(value interface{}) string {
-	nameRef := ar.builder.addName(ar.name)
+	if _, isBinary := value.([]byte); !isBinary {
+		if members := reflect.ValueOf(value); members.Kind() == reflect.Slice {
+			values := make([]interface{}, members.Len())
+			for i := range values {
+				values[i] = members.Index(i).Interface()
+			}
+			return ar.ContainsAll(values...)
+		}
+	}
+
+	nameRef := ar.nameRef()
 	valueRef, err := ar.builder.addValue(value)
 	if err != nil {
 		return ""
@@ -196,9 +400,29 @@ func (ar *AttributeRef) Contains(value interface{}) string {
 	return fmt.Sprintf("contains(%s, %s)", nameRef, valueRef)
 }
 
+// ContainsAll matches a set or list attribute holding every one of values,
+// ANDing one contains condition per value, e.g. for tag filtering. With no
+// values it matches nothing, rather than leaving the filter out.
+func (ar *AttributeRef) ContainsAll(values ...interface{}) string {
+	if len(values) == 0 {
+		nameRef := ar.nameRef()
+		return fmt.Sprintf("(attribute_exists(%s) AND attribute_not_exists(%s))", nameRef, nameRef)
+	}
+
+	valueRefs, err := ar.builder.addValues(values)
+	if err != nil {
+		return ""
+	}
+	conditions := make([]string, len(valueRefs))
+	for i, valueRef := range valueRefs {
+		conditions[i] = fmt.Sprintf("contains(%s, %s)", ar.nameRef(), valueRef)
+	}
+	return joinConditions("AND", conditions)
+}
+
 // Begins creates a begins_with condition
 func (ar *AttributeRef) Begins(value interface{}) string {
-	nameRef := ar.builder.addName(ar.name)
+	nameRef := ar.nameRef()
 	valueRef, err := ar.builder.addValue(value)
 	if err != nil {
 		return ""
@@ -206,34 +430,190 @@ func (ar *AttributeRef) Begins(value interface{}) string {
 	return fmt.Sprintf("begins_with(%s, %s)", nameRef, valueRef)
 }
 
+// SizeEq matches a string, binary, set, list or map attribute whose size is n
+func (ar *AttributeRef) SizeEq(n int) string {
+	return ar.sizeCompare("=", n)
+}
+
+// SizeNe matches an attribute whose size is not n
+func (ar *AttributeRef) SizeNe(n int) string {
+	return ar.sizeCompare("<>", n)
+}
+
+// SizeGt matches an attribute whose size is greater than n
+func (ar *AttributeRef) SizeGt(n int) string {
+	return ar.sizeCompare(">", n)
+}
+
+// SizeGte matches an attribute whose size is at least n, e.g.
+// attrs["tags"].SizeGte(2) for items with two or more tags
+func (ar *AttributeRef) SizeGte(n int) string {
+	return ar.sizeCompare(">=", n)
+}
+
+// SizeLt matches an attribute whose size is less than n
+func (ar *AttributeRef) SizeLt(n int) string {
+	return ar.sizeCompare("<", n)
+}
+
+// SizeLte matches an attribute whose size is at most n
+func (ar *AttributeRef) SizeLte(n int) string {
+	return ar.sizeCompare("<=", n)
+}
+
+// SizeBetween matches an attribute whose size is between start and end,
+// inclusive
+func (ar *AttributeRef) SizeBetween(start, end int) string {
+	nameRef := ar.nameRef()
+	startRef, err := ar.builder.addValue(start)
+	if err != nil {
+		return ""
+	}
+	endRef, err := ar.builder.addValue(end)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("(size(%s) BETWEEN %s AND %s)", nameRef, startRef, endRef)
+}
+
+// sizeCompare compares the attribute's size with n using operator
+func (ar *AttributeRef) sizeCompare(operator string, n int) string {
+	nameRef := ar.nameRef()
+	valueRef, err := ar.builder.addValue(n)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("size(%s) %s %s", nameRef, operator, valueRef)
+}
+
 // Operation methods
 
+// Path references a nested map attribute, e.g. Path("settings", "feature")
+// for settings.feature. The result works with every operation, including
+// Exists and NotExists
+func (ob *OperationBuilder) Path(segments ...string) *AttributeRef {
+	return &AttributeRef{
+		builder: ob.builder,
+		name:    strings.Join(segments, "."),
+		path:    segments,
+	}
+}
+
 // Exists creates an attribute_exists condition
 func (ob *OperationBuilder) Exists(attr *AttributeRef) string {
-	nameRef := ob.builder.addName(attr.name)
+	nameRef := attr.nameRef()
 	return fmt.Sprintf("attribute_exists(%s)", nameRef)
 }
 
 // NotExists creates an attribute_not_exists condition
 func (ob *OperationBuilder) NotExists(attr *AttributeRef) string {
-	nameRef := ob.builder.addName(attr.name)
+	nameRef := attr.nameRef()
 	return fmt.Sprintf("attribute_not_exists(%s)", nameRef)
 }
 
+// IsNull matches items where the attribute is missing or stored as NULL
+func (ob *OperationBuilder) IsNull(attr *AttributeRef) string {
+	return ob.builder.isNull(attr.nameRef())
+}
+
+// IsNotNull matches items where the attribute is present and not stored as NULL
+func (ob *OperationBuilder) IsNotNull(attr *AttributeRef) string {
+	return ob.builder.isNotNull(attr.nameRef())
+}
+
+// isNull builds the expression for a missing or NULL attribute
+func (eb *ExpressionBuilder) isNull(nameRef string) string {
+	typeRef, _ := eb.addValue("NULL")
+	return fmt.Sprintf("(attribute_not_exists(%s) OR attribute_type(%s, %s))", nameRef, nameRef, typeRef)
+}
+
+// isNotNull builds the expression for a present, non-NULL attribute
+func (eb *ExpressionBuilder) isNotNull(nameRef string) string {
+	typeRef, _ := eb.addValue("NULL")
+	return fmt.Sprintf("(attribute_exists(%s) AND NOT attribute_type(%s, %s))", nameRef, nameRef, typeRef)
+}
+
 // Size returns the size of an attribute
 func (ob *OperationBuilder) Size(attr *AttributeRef) string {
-	nameRef := ob.builder.addName(attr.name)
+	nameRef := attr.nameRef()
 	return fmt.Sprintf("size(%s)", nameRef)
 }
 
 // AttributeType checks the type of an attribute
 func (ob *OperationBuilder) AttributeType(attr *AttributeRef, typeName string) string {
-	nameRef := ob.builder.addName(attr.name)
+	nameRef := attr.nameRef()
 	typeRef, _ := ob.builder.addValue(typeName)
 	return fmt.Sprintf("attribute_type(%s, %s)", nameRef, typeRef)
 }
 
-// buildAttributeRefs builds attribute references for the where callback
+// And joins conditions with AND inside one parenthesized group, so the result
+// can be nested in Or or Not without precedence surprises. Empty conditions
+// are skipped, which lets optional clauses be passed unconditionally.
+func (ob *OperationBuilder) And(conditions ...string) string {
+	return joinConditions("AND", conditions)
+}
+
+// Or joins conditions with OR inside one parenthesized group
+func (ob *OperationBuilder) Or(conditions ...string) string {
+	return joinConditions("OR", conditions)
+}
+
+// Not negates a condition, grouping it first when it is compound
+func (ob *OperationBuilder) Not(condition string) string {
+	if condition == "" {
+		return ""
+	}
+	return fmt.Sprintf("(NOT %s)", groupCondition(condition))
+}
+
+// joinConditions joins the non-empty conditions with a logical operator. A
+// single condition is returned as-is.
+func joinConditions(operator string, conditions []string) string {
+	parts := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		if condition != "" {
+			parts = append(parts, groupCondition(condition))
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return ""
+	case 1:
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, " "+operator+" ") + ")"
+}
+
+// groupCondition wraps a compound condition in parentheses unless one pair
+// already encloses all of it
+func groupCondition(condition string) string {
+	if !strings.Contains(condition, " AND ") && !strings.Contains(condition, " OR ") {
+		return condition
+	}
+	if strings.HasPrefix(condition, "(") {
+		depth := 0
+		for i, r := range condition {
+			switch r {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				if i == len(condition)-1 {
+					return condition
+				}
+				break
+			}
+		}
+	}
+	return "(" + condition + ")"
+}
+
+// buildAttributeRefs builds attribute references for the where callback.
+// References allocate no placeholders until an operation uses them, so
+// placeholders are numbered in call order and the same callback always builds
+// the same expression regardless of map iteration order.
 func (eb *ExpressionBuilder) buildAttributeRefs() map[string]*AttributeRef {
 	refs := make(map[string]*AttributeRef)
 	for name := range eb.attributes {
@@ -242,6 +622,14 @@ func (eb *ExpressionBuilder) buildAttributeRefs() map[string]*AttributeRef {
 			name:    name,
 		}
 	}
+	for _, field := range eb.keyFields {
+		if _, exists := refs[field]; !exists {
+			refs[field] = &AttributeRef{
+				builder: eb,
+				name:    field,
+			}
+		}
+	}
 	return refs
 }
 
@@ -277,6 +665,14 @@ func (fb *FilterBuilder) Where(callback WhereCallback) error {
 	return fb.builder.BuildWhereExpression(callback)
 }
 
+// clone returns an independent copy of the filter builder
+func (fb *FilterBuilder) clone() *FilterBuilder {
+	return &FilterBuilder{
+		builder:    fb.builder.clone(),
+		filterExpr: fb.filterExpr,
+	}
+}
+
 // Build returns the filter expression and attributes
 func (fb *FilterBuilder) Build() (string, map[string]string, map[string]types.AttributeValue) {
 	return fb.builder.Build()
@@ -289,11 +685,19 @@ type ConditionBuilder struct {
 
 // NewConditionBuilder creates a new condition builder
 func NewConditionBuilder(attributes map[string]*AttributeDefinition) *ConditionBuilder {
+	builder := NewExpressionBuilder(attributes)
+	builder.namePrefix = "#cond"
+	builder.valuePrefix = ":cond"
 	return &ConditionBuilder{
-		builder: NewExpressionBuilder(attributes),
+		builder: builder,
 	}
 }
 
+// clone returns an independent copy of the condition builder
+func (cb *ConditionBuilder) clone() *ConditionBuilder {
+	return &ConditionBuilder{builder: cb.builder.clone()}
+}
+
 // Where adds a condition expression
 func (cb *ConditionBuilder) Where(callback WhereCallback) error {
 	return cb.builder.BuildWhereExpression(callback)
@@ -1,8 +1,11 @@
 package electrodb
 
 import (
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
@@ -369,3 +372,413 @@ func TestMergeExpressionAttributes(t *testing.T) {
 		t.Error("Expected new name to be added")
 	}
 }
+
+func TestTimeValueSerialization(t *testing.T) {
+	when := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+
+	newEntity := func(format TimeFormat) *Entity {
+		schema := &Schema{
+			Service: "TestService",
+			Entity:  "Event",
+			Table:   "TestTable",
+			Attributes: map[string]*AttributeDefinition{
+				"id":         {Type: AttributeTypeString, Required: true},
+				"occurredAt": {Type: AttributeTypeAny},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				},
+			},
+		}
+		entity, err := NewEntity(schema, &Config{TimeFormat: format})
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		return entity
+	}
+
+	tests := []struct {
+		format TimeFormat
+		want   types.AttributeValue
+	}{
+		{"", &types.AttributeValueMemberS{Value: "2024-03-15T10:30:00.000000000Z"}},
+		{TimeFormatRFC3339, &types.AttributeValueMemberS{Value: "2024-03-15T10:30:00.000000000Z"}},
+		{TimeFormatEpochSeconds, &types.AttributeValueMemberN{Value: "1710498600"}},
+		{TimeFormatEpochMillis, &types.AttributeValueMemberN{Value: "1710498600000"}},
+	}
+
+	for _, tt := range tests {
+		entity := newEntity(tt.format)
+
+		params, err := entity.Query("primary").
+			QueryKeys(Keys{"id": "1"}).
+			Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+				return attrs["occurredAt"].Gt(when)
+			}).
+			Params()
+		if err != nil {
+			t.Fatalf("Failed to build query params: %v", err)
+		}
+		values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+		if !reflect.DeepEqual(values[":val0"], tt.want) {
+			t.Errorf("format %q: expected filter value %#v, got %#v", tt.format, tt.want, values[":val0"])
+		}
+
+		params, err = entity.Put(Item{"id": "1", "occurredAt": when}).Params()
+		if err != nil {
+			t.Fatalf("Failed to build put params: %v", err)
+		}
+		item := params["Item"].(map[string]types.AttributeValue)
+		if !reflect.DeepEqual(item["occurredAt"], tt.want) {
+			t.Errorf("format %q: expected item value %#v, got %#v", tt.format, tt.want, item["occurredAt"])
+		}
+	}
+
+	// RFC3339 strings have a fixed width in UTC, so they sort in time order
+	times := []time.Time{
+		when.Add(time.Second),
+		when.Add(100 * time.Millisecond),
+		when.In(time.FixedZone("CET", 3600)).Add(10 * time.Millisecond),
+	}
+	sorted := make([]string, len(times))
+	for i, next := range times {
+		sorted[i] = normalizeTime(next, TimeFormatRFC3339).(string)
+		if len(sorted[i]) != len("2024-03-15T10:30:00.000000000Z") {
+			t.Errorf("Expected a fixed-width UTC time, got %s", sorted[i])
+		}
+	}
+	sort.Strings(sorted)
+	if sorted[0] != "2024-03-15T10:30:00.010000000Z" || sorted[2] != "2024-03-15T10:30:01.000000000Z" {
+		t.Errorf("Expected strings to sort in time order, got %v", sorted)
+	}
+
+	// Values passed directly keep the configured format
+	value, err := marshalValue(when, TimeFormatEpochSeconds)
+	if err != nil || !reflect.DeepEqual(value, &types.AttributeValueMemberN{Value: "1710498600"}) {
+		t.Errorf("Expected epoch seconds, got %#v (%v)", value, err)
+	}
+}
+
+func TestNullFilters(t *testing.T) {
+	attributes := map[string]*AttributeDefinition{
+		"deletedAt": {Type: AttributeTypeString},
+		"active":    {Type: AttributeTypeBoolean},
+	}
+
+	tests := []struct {
+		name     string
+		callback WhereCallback
+		expected string
+	}{
+		{
+			name: "IsNull",
+			callback: func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+				return ops.IsNull(attrs["deletedAt"])
+			},
+			expected: "(attribute_not_exists(#attr0) OR attribute_type(#attr0, :val0))",
+		},
+		{
+			name: "IsNotNull",
+			callback: func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+				return ops.IsNotNull(attrs["deletedAt"])
+			},
+			expected: "(attribute_exists(#attr0) AND NOT attribute_type(#attr0, :val0))",
+		},
+		{
+			name: "Eq nil",
+			callback: func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+				return attrs["deletedAt"].Eq(nil)
+			},
+			expected: "(attribute_not_exists(#attr0) OR attribute_type(#attr0, :val0))",
+		},
+		{
+			name: "Ne nil",
+			callback: func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+				return attrs["deletedAt"].Ne(nil)
+			},
+			expected: "(attribute_exists(#attr0) AND NOT attribute_type(#attr0, :val0))",
+		},
+	}
+
+	for _, tt := range tests {
+		eb := NewExpressionBuilder(attributes)
+		if err := eb.BuildWhereExpression(tt.callback); err != nil {
+			t.Fatalf("%s: failed to build expression: %v", tt.name, err)
+		}
+
+		expr, names, values := eb.Build()
+		if expr != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, expr)
+		}
+		if names["#attr0"] != "deletedAt" {
+			t.Errorf("%s: expected #attr0 to be deletedAt, got %q", tt.name, names["#attr0"])
+		}
+		if got := values[":val0"].(*types.AttributeValueMemberS).Value; got != "NULL" {
+			t.Errorf("%s: expected NULL type operand, got %q", tt.name, got)
+		}
+	}
+
+	// Boolean false is a value comparison, not a null check
+	eb := NewExpressionBuilder(attributes)
+	eb.BuildWhereExpression(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return attrs["active"].Eq(false)
+	})
+	expr, _, values := eb.Build()
+	if expr != "#attr0 = :val0" {
+		t.Errorf("Expected plain equality for false, got %q", expr)
+	}
+	if v, ok := values[":val0"].(*types.AttributeValueMemberBOOL); !ok || v.Value {
+		t.Errorf("Expected BOOL false operand, got %#v", values[":val0"])
+	}
+}
+
+func TestNestedPathConditions(t *testing.T) {
+	attributes := map[string]*AttributeDefinition{
+		"settings": {Type: AttributeTypeMap},
+	}
+
+	tests := []struct {
+		name     string
+		callback WhereCallback
+		expected string
+	}{
+		{
+			name: "Exists",
+			callback: func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+				return ops.Exists(ops.Path("settings", "feature"))
+			},
+			expected: "attribute_exists(#attr0.#attr1)",
+		},
+		{
+			name: "NotExists",
+			callback: func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+				return ops.NotExists(ops.Path("settings", "feature"))
+			},
+			expected: "attribute_not_exists(#attr0.#attr1)",
+		},
+		{
+			name: "Eq",
+			callback: func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+				return ops.Path("settings", "feature").Eq("on")
+			},
+			expected: "#attr0.#attr1 = :val0",
+		},
+	}
+
+	for _, tt := range tests {
+		eb := NewExpressionBuilder(attributes)
+		if err := eb.BuildWhereExpression(tt.callback); err != nil {
+			t.Fatalf("%s: failed to build expression: %v", tt.name, err)
+		}
+
+		expr, names, _ := eb.Build()
+		if expr != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, expr)
+		}
+		if names["#attr0"] != "settings" || names["#attr1"] != "feature" {
+			t.Errorf("%s: expected path segments as separate names, got %v", tt.name, names)
+		}
+	}
+}
+
+func TestLogicalGrouping(t *testing.T) {
+	attributes := map[string]*AttributeDefinition{
+		"a": {Type: AttributeTypeString},
+		"b": {Type: AttributeTypeNumber},
+		"c": {Type: AttributeTypeBoolean},
+	}
+
+	fb := NewFilterBuilder(attributes)
+	err := fb.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return ops.Or(ops.And(attrs["a"].Eq("x"), attrs["b"].Gt(1)), attrs["c"].Eq(true))
+	})
+	if err != nil {
+		t.Fatalf("Failed to build filter: %v", err)
+	}
+	expr, names, values := fb.Build()
+	if expr != "((#attr0 = :val0 AND #attr1 > :val1) OR #attr2 = :val2)" {
+		t.Errorf("Unexpected expression: %s", expr)
+	}
+	if names["#attr0"] != "a" || names["#attr1"] != "b" || names["#attr2"] != "c" || len(values) != 3 {
+		t.Errorf("Unexpected placeholders: %v %v", names, values)
+	}
+
+	cb := NewConditionBuilder(attributes)
+	err = cb.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		between := attrs["b"].Between(1, 5)
+		return ops.And(ops.Not(ops.Or(ops.Exists(attrs["a"]), "")), between)
+	})
+	if err != nil {
+		t.Fatalf("Failed to build condition: %v", err)
+	}
+	expr, _, _ = cb.Build()
+	if expr != "((NOT attribute_exists(#cond1)) AND (#cond0 BETWEEN :cond0 AND :cond1))" {
+		t.Errorf("Unexpected condition: %s", expr)
+	}
+
+	if got := groupCondition("(a = :v0) AND (b = :v1)"); got != "((a = :v0) AND (b = :v1))" {
+		t.Errorf("Expected sibling groups to be wrapped, got %s", got)
+	}
+}
+
+func TestContainsAll(t *testing.T) {
+	attributes := map[string]*AttributeDefinition{
+		"tags": {Type: AttributeTypeSet},
+		"data": {Type: AttributeTypeBinary},
+	}
+
+	fb := NewFilterBuilder(attributes)
+	err := fb.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return attrs["tags"].ContainsAll("go", "dynamodb")
+	})
+	if err != nil {
+		t.Fatalf("Failed to build filter: %v", err)
+	}
+	expr, names, values := fb.Build()
+	if expr != "(contains(#attr0, :val0) AND contains(#attr1, :val1))" {
+		t.Errorf("Unexpected expression: %s", expr)
+	}
+	if names["#attr0"] != "tags" || names["#attr1"] != "tags" {
+		t.Errorf("Unexpected names: %v", names)
+	}
+	if values[":val0"].(*types.AttributeValueMemberS).Value != "go" || values[":val1"].(*types.AttributeValueMemberS).Value != "dynamodb" {
+		t.Errorf("Unexpected values: %v", values)
+	}
+
+	// A slice passed to Contains checks every member; []byte is one value
+	cb := NewConditionBuilder(attributes)
+	err = cb.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return ops.And(attrs["tags"].Contains([]string{"go", "aws"}), attrs["data"].Contains([]byte("x")))
+	})
+	if err != nil {
+		t.Fatalf("Failed to build condition: %v", err)
+	}
+	expr, _, values = cb.Build()
+	if expr != "((contains(#cond0, :cond0) AND contains(#cond1, :cond1)) AND contains(#cond2, :cond2))" {
+		t.Errorf("Unexpected condition: %s", expr)
+	}
+	if _, ok := values[":cond2"].(*types.AttributeValueMemberB); !ok {
+		t.Errorf("Expected []byte to be a single binary value, got %v", values[":cond2"])
+	}
+
+	// No values match nothing instead of dropping the filter
+	for _, empty := range []func(*AttributeRef) string{
+		func(tags *AttributeRef) string { return tags.ContainsAll() },
+		func(tags *AttributeRef) string { return tags.Contains([]string{}) },
+	} {
+		fb = NewFilterBuilder(attributes)
+		fb.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return empty(attrs["tags"])
+		})
+		expr, _, _ = fb.Build()
+		if expr != "(attribute_exists(#attr0) AND attribute_not_exists(#attr0))" {
+			t.Errorf("Expected an always-false filter, got %q", expr)
+		}
+	}
+}
+
+func TestAuditExpressions(t *testing.T) {
+	value := &types.AttributeValueMemberS{Value: "x"}
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		valid  bool
+	}{
+		{"matched placeholders", map[string]interface{}{
+			"UpdateExpression":          "SET #attr0 = :val0",
+			"ConditionExpression":       "attribute_exists(#cond0)",
+			"ExpressionAttributeNames":  map[string]string{"#attr0": "name", "#cond0": "pk"},
+			"ExpressionAttributeValues": map[string]types.AttributeValue{":val0": value},
+		}, true},
+		{"no expressions", map[string]interface{}{"TableName": "table"}, true},
+		{"undefined name", map[string]interface{}{
+			"FilterExpression":          "#attr0 = :val0 AND #attr1 = :val0",
+			"ExpressionAttributeNames":  map[string]string{"#attr0": "name"},
+			"ExpressionAttributeValues": map[string]types.AttributeValue{":val0": value},
+		}, false},
+		{"undefined value", map[string]interface{}{
+			"KeyConditionExpression":    "#pk = :pk",
+			"ExpressionAttributeNames":  map[string]string{"#pk": "pk"},
+			"ExpressionAttributeValues": map[string]types.AttributeValue{":val0": value},
+		}, false},
+		{"orphan value", map[string]interface{}{
+			"ProjectionExpression":      "#attr0",
+			"ExpressionAttributeNames":  map[string]string{"#attr0": "name"},
+			"ExpressionAttributeValues": map[string]types.AttributeValue{":val0": value},
+		}, false},
+		{"orphan name", map[string]interface{}{
+			"ConditionExpression":      "attribute_not_exists(#cond0)",
+			"ExpressionAttributeNames": map[string]string{"#cond0": "pk", "#cond1": "sk"},
+		}, false},
+		{"name used as value", map[string]interface{}{
+			"FilterExpression":         "#attr0 = :attr0",
+			"ExpressionAttributeNames": map[string]string{"#attr0": "name", ":attr0": "name"},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := auditExpressions(tt.params)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			electroErr, ok := err.(*ElectroError)
+			if !ok || electroErr.Code != ErrMalformedExpression {
+				t.Fatalf("Expected MalformedExpression error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSizeComparisons(t *testing.T) {
+	attributes := map[string]*AttributeDefinition{
+		"tags": {Type: AttributeTypeSet},
+		"name": {Type: AttributeTypeString},
+	}
+
+	fb := NewFilterBuilder(attributes)
+	err := fb.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return attrs["tags"].SizeGte(2)
+	})
+	if err != nil {
+		t.Fatalf("Failed to build filter: %v", err)
+	}
+	expr, names, values := fb.Build()
+	if expr != "size(#attr0) >= :val0" {
+		t.Errorf("Unexpected expression: %s", expr)
+	}
+	if names["#attr0"] != "tags" {
+		t.Errorf("Unexpected names: %v", names)
+	}
+	if n, ok := values[":val0"].(*types.AttributeValueMemberN); !ok || n.Value != "2" {
+		t.Errorf("Expected the size as a number, got %v", values[":val0"])
+	}
+
+	tests := []struct {
+		build    func(attr *AttributeRef) string
+		expected string
+	}{
+		{func(attr *AttributeRef) string { return attr.SizeEq(1) }, "size(#attr0) = :val0"},
+		{func(attr *AttributeRef) string { return attr.SizeNe(1) }, "size(#attr0) <> :val0"},
+		{func(attr *AttributeRef) string { return attr.SizeGt(1) }, "size(#attr0) > :val0"},
+		{func(attr *AttributeRef) string { return attr.SizeLt(1) }, "size(#attr0) < :val0"},
+		{func(attr *AttributeRef) string { return attr.SizeLte(1) }, "size(#attr0) <= :val0"},
+		{func(attr *AttributeRef) string { return attr.SizeBetween(1, 5) }, "(size(#attr0) BETWEEN :val0 AND :val1)"},
+	}
+	for _, tt := range tests {
+		fb := NewFilterBuilder(attributes)
+		err := fb.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return tt.build(attrs["name"])
+		})
+		if err != nil {
+			t.Fatalf("Failed to build filter: %v", err)
+		}
+		if expr, _, _ := fb.Build(); expr != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, expr)
+		}
+	}
+}
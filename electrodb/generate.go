@@ -0,0 +1,123 @@
+package electrodb
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// crockfordAlphabet encodes ULIDs
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	// base62Alphabet encodes KSUIDs
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// ksuidEpoch is the Unix time KSUID timestamps count from
+	ksuidEpoch = 1400000000
+)
+
+// validateGenerators checks that generated attributes use a known scheme, are
+// strings and have no Default competing with the generator
+func validateGenerators(schema *Schema) error {
+	for _, name := range sortedAttributeNames(schema.Attributes) {
+		attr := schema.Attributes[name]
+		if attr.Generate == "" {
+			continue
+		}
+		switch attr.Generate {
+		case GenerateUUID, GenerateULID, GenerateKSUID:
+		default:
+			return NewElectroError("InvalidSchema",
+				fmt.Sprintf("Attribute '%s' has unknown generator '%s'", name, attr.Generate), nil)
+		}
+		if attr.Type != AttributeTypeString {
+			return NewElectroError("InvalidSchema",
+				fmt.Sprintf("Generated attribute '%s' must be of type string", name), nil)
+		}
+		if attr.Default != nil || attr.DefaultFromContext != nil {
+			return NewElectroError("InvalidSchema",
+				fmt.Sprintf("Attribute '%s' cannot have both a Default and a generator", name), nil)
+		}
+	}
+	return nil
+}
+
+// randomBytes fills b from the system's secure random source; replaceable in
+// tests
+var randomBytes = rand.Read
+
+// generateID returns a new ID of the given scheme; now timestamps ULIDs and
+// KSUIDs. It fails rather than return a predictable ID when the random
+// source does.
+func generateID(generator IDGenerator, now time.Time) (string, error) {
+	switch generator {
+	case GenerateULID:
+		return newULID(now)
+	case GenerateKSUID:
+		return newKSUID(now)
+	}
+	return newUUID()
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := randomBytes(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32], nil
+}
+
+// newULID returns a ULID: a 48-bit millisecond timestamp followed by 80
+// random bits, as 26 Crockford base32 characters
+func newULID(now time.Time) (string, error) {
+	var b [16]byte
+	ms := uint64(now.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	if _, err := randomBytes(b[6:]); err != nil {
+		return "", err
+	}
+
+	// 26 characters hold 130 bits; the 128 bits are right-aligned
+	out := make([]byte, 26)
+	for i := range out {
+		var v byte
+		for bit := i*5 - 2; bit < i*5+3; bit++ {
+			v <<= 1
+			if bit >= 0 && b[bit/8]&(0x80>>(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		out[i] = crockfordAlphabet[v]
+	}
+	return string(out), nil
+}
+
+// newKSUID returns a KSUID: a 32-bit second timestamp from the KSUID epoch
+// followed by 128 random bits, as 27 base62 characters
+func newKSUID(now time.Time) (string, error) {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(now.Unix()-ksuidEpoch))
+	if _, err := randomBytes(b[4:]); err != nil {
+		return "", err
+	}
+
+	out := make([]byte, 27)
+	n := new(big.Int).SetBytes(b[:])
+	base := big.NewInt(62)
+	digit := new(big.Int)
+	for i := len(out) - 1; i >= 0; i-- {
+		n.DivMod(n, base, digit)
+		out[i] = base62Alphabet[digit.Int64()]
+	}
+	return string(out), nil
+}
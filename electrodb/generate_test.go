@@ -0,0 +1,149 @@
+package electrodb
+
+import (
+	"crypto/rand"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestGeneratedIDs(t *testing.T) {
+	formats := map[IDGenerator]*regexp.Regexp{
+		GenerateUUID:  regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		GenerateULID:  regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`),
+		GenerateKSUID: regexp.MustCompile(`^[0-9A-Za-z]{27}$`),
+	}
+
+	for generator, format := range formats {
+		schema := &Schema{
+			Service: "TestService",
+			Entity:  "Order",
+			Table:   "TestTable",
+			Attributes: map[string]*AttributeDefinition{
+				"orderId": {Type: AttributeTypeString, Generate: generator},
+				"total":   {Type: AttributeTypeNumber},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+				},
+			},
+		}
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("%s: failed to create entity: %v", generator, err)
+		}
+
+		seen := make(map[string]bool)
+		for i := 0; i < 2; i++ {
+			params, err := entity.Put(Item{"total": 10}).Params()
+			if err != nil {
+				t.Fatalf("%s: Params failed: %v", generator, err)
+			}
+			item := params["Item"].(map[string]types.AttributeValue)
+			id := item["orderId"].(*types.AttributeValueMemberS).Value
+			if !format.MatchString(id) {
+				t.Errorf("%s: malformed ID %q", generator, id)
+			}
+			if seen[id] {
+				t.Errorf("%s: ID %q generated twice", generator, id)
+			}
+			seen[id] = true
+			if pk := item["pk"].(*types.AttributeValueMemberS).Value; !strings.HasSuffix(pk, "#orderid_"+strings.ToLower(id)) {
+				t.Errorf("%s: expected the key to use the generated ID %q, got %q", generator, id, pk)
+			}
+		}
+
+		// A supplied value is kept
+		params, err := entity.Put(Item{"orderId": "given", "total": 10}).Params()
+		if err != nil {
+			t.Fatalf("%s: Params failed: %v", generator, err)
+		}
+		if id := params["Item"].(map[string]types.AttributeValue)["orderId"].(*types.AttributeValueMemberS).Value; id != "given" {
+			t.Errorf("%s: expected the supplied ID to be kept, got %q", generator, id)
+		}
+	}
+}
+
+func TestULIDsSortByTime(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	earlier, _ := newULID(start)
+	later, _ := newULID(start.Add(time.Millisecond))
+	if earlier >= later {
+		t.Errorf("Expected %q to sort before %q", earlier, later)
+	}
+	if again, _ := newULID(start); again[:10] != earlier[:10] {
+		t.Errorf("Expected ULIDs of the same millisecond to share the time prefix, got %q and %q", again[:10], earlier[:10])
+	}
+}
+
+func TestGenerateRequiredAndRandomFailure(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Order",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"orderId": {Type: AttributeTypeString, Required: true, Generate: GenerateULID},
+			"status":  {Type: AttributeTypeString, Required: true, Default: func() interface{} { return "new" }},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+			},
+		},
+	}
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// Required is checked after defaults and generators fill the item
+	params, err := entity.Put(Item{}).Params()
+	if err != nil {
+		t.Fatalf("Expected generated and defaulted required attributes to pass, got %v", err)
+	}
+	item := params["Item"].(map[string]types.AttributeValue)
+	if item["orderId"].(*types.AttributeValueMemberS).Value == "" || item["status"].(*types.AttributeValueMemberS).Value != "new" {
+		t.Errorf("Expected a generated ID and the default status, got %v", item)
+	}
+
+	// A failing random source fails the write instead of a predictable ID
+	failure := errors.New("entropy exhausted")
+	randomBytes = func(b []byte) (int, error) { return 0, failure }
+	defer func() { randomBytes = rand.Read }()
+	_, err = entity.Put(Item{}).Params()
+	var electroErr *ElectroError
+	if !errors.As(err, &electroErr) || electroErr.Code != ErrGeneration || !errors.Is(err, failure) {
+		t.Errorf("Expected GenerationError, got %v", err)
+	}
+}
+
+func TestGenerateSchemaValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		attr *AttributeDefinition
+	}{
+		{"unknown generator", &AttributeDefinition{Type: AttributeTypeString, Generate: "serial"}},
+		{"non-string type", &AttributeDefinition{Type: AttributeTypeNumber, Generate: GenerateUUID}},
+		{"with default", &AttributeDefinition{Type: AttributeTypeString, Generate: GenerateUUID, Default: func() interface{} { return "x" }}},
+	}
+	for _, tt := range tests {
+		schema := &Schema{
+			Service:    "TestService",
+			Entity:     "Order",
+			Table:      "TestTable",
+			Attributes: map[string]*AttributeDefinition{"orderId": tt.attr},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}}},
+			},
+		}
+		_, err := NewEntity(schema, nil)
+		if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != ErrInvalidSchema {
+			t.Errorf("%s: expected InvalidSchema, got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package electrodb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ItemHash returns a stable SHA-256 hex digest of an item's declared
+// attributes, for idempotency keys and "did anything change" checks before a
+// write. Facet values are normalized, sets are order-independent, nil values
+// count as absent, and undeclared attributes, the timestamp attributes and
+// the TTL attribute are left out, so an item hashes the same however it was
+// built and whenever it was last touched.
+func (e *Entity) ItemHash(item Item) (string, error) {
+	volatile := make(map[string]bool)
+	if e.schema.Timestamps != nil {
+		volatile[e.schema.Timestamps.CreatedAt] = true
+		volatile[e.schema.Timestamps.UpdatedAt] = true
+	}
+	if e.schema.TTL != nil {
+		volatile[e.schema.TTL.Attribute] = true
+	}
+
+	canonical := make(map[string]types.AttributeValue)
+	for name, value := range ApplyNormalization(item, e.schema) {
+		if _, declared := e.schema.Attributes[name]; !declared || volatile[name] || value == nil {
+			continue
+		}
+		av, err := marshalAttribute(value, e.timeFormat())
+		if err != nil {
+			return "", NewElectroError("MarshalError", fmt.Sprintf("Failed to marshal attribute %s", name), err)
+		}
+		canonical[name] = sortSets(av)
+	}
+
+	// json.Marshal orders map keys, so equal items encode identically
+	encoded, err := json.Marshal(attributeMapToInterface(canonical))
+	if err != nil {
+		return "", NewElectroError("MarshalError", "Failed to encode item", err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sortSets returns the attribute value with every set, including sets nested
+// in maps and lists, in sorted order
+func sortSets(av types.AttributeValue) types.AttributeValue {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberSS:
+		values := append([]string{}, v.Value...)
+		sort.Strings(values)
+		return &types.AttributeValueMemberSS{Value: values}
+	case *types.AttributeValueMemberNS:
+		values := append([]string{}, v.Value...)
+		sort.Strings(values)
+		return &types.AttributeValueMemberNS{Value: values}
+	case *types.AttributeValueMemberBS:
+		values := append([][]byte{}, v.Value...)
+		sort.Slice(values, func(i, j int) bool { return bytes.Compare(values[i], values[j]) < 0 })
+		return &types.AttributeValueMemberBS{Value: values}
+	case *types.AttributeValueMemberM:
+		m := make(map[string]types.AttributeValue, len(v.Value))
+		for key, value := range v.Value {
+			m[key] = sortSets(value)
+		}
+		return &types.AttributeValueMemberM{Value: m}
+	case *types.AttributeValueMemberL:
+		l := make([]types.AttributeValue, len(v.Value))
+		for i, value := range v.Value {
+			l[i] = sortSets(value)
+		}
+		return &types.AttributeValueMemberL{Value: l}
+	}
+	return av
+}
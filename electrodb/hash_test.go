@@ -0,0 +1,69 @@
+package electrodb
+
+import (
+	"testing"
+)
+
+func TestItemHash(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":        {Type: AttributeTypeString, Required: true},
+			"name":      {Type: AttributeTypeString},
+			"price":     {Type: AttributeTypeNumber},
+			"details":   {Type: AttributeTypeMap},
+			"images":    {Type: AttributeTypeSet},
+			"updatedAt": {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+		Timestamps: &TimestampsConfig{UpdatedAt: "updatedAt"},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	first := Item{"id": "p1", "name": "Lamp", "price": 3, "details": map[string]interface{}{"color": "red", "watts": 40}}
+	first["images"] = [][]byte{[]byte("b"), []byte("a")}
+	first["updatedAt"] = int64(1700000000)
+
+	second := Item{}
+	second["updatedAt"] = int64(1800000000)
+	second["images"] = [][]byte{[]byte("a"), []byte("b")}
+	second["details"] = map[string]interface{}{"watts": float64(40), "color": "red"}
+	second["price"] = float64(3)
+	second["name"] = "Lamp"
+	second["id"] = "p1"
+	second["undeclared"] = "ignored"
+
+	firstHash, err := entity.ItemHash(first)
+	if err != nil {
+		t.Fatalf("ItemHash failed: %v", err)
+	}
+	secondHash, err := entity.ItemHash(second)
+	if err != nil {
+		t.Fatalf("ItemHash failed: %v", err)
+	}
+	if firstHash != secondHash {
+		t.Errorf("Expected semantically equal items to hash the same, got %s and %s", firstHash, secondHash)
+	}
+	if len(firstHash) != 64 {
+		t.Errorf("Expected a hex SHA-256 digest, got %q", firstHash)
+	}
+
+	second["price"] = 4
+	changedHash, err := entity.ItemHash(second)
+	if err != nil {
+		t.Fatalf("ItemHash failed: %v", err)
+	}
+	if changedHash == firstHash {
+		t.Error("Expected a changed field to change the hash")
+	}
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 // KeyOptions defines options for key building
@@ -13,6 +14,118 @@ type KeyOptions struct {
 	Postfix          *string
 	ExcludeLabelTail bool
 	ExcludePostfix   bool
+	Delimiters       Delimiters // zero value uses DefaultDelimiters
+}
+
+// Delimiters are the characters composite keys are built from:
+// <Prefix><service><Facet><label><Value><value>...
+type Delimiters struct {
+	Prefix string // starts a key, "$" by default
+	Facet  string // precedes each facet label, "#" by default
+	Value  string // separates a label from its value (and entity from version), "_" by default
+	Escape bool   // backslash-escape the Facet delimiter inside facet values; off by default
+}
+
+// DefaultDelimiters are ElectroDB's key delimiters
+var DefaultDelimiters = Delimiters{Prefix: "$", Facet: "#", Value: "_"}
+
+// OrDefault fills any empty delimiter with its default
+func (d Delimiters) OrDefault() Delimiters {
+	if d.Prefix == "" {
+		d.Prefix = DefaultDelimiters.Prefix
+	}
+	if d.Facet == "" {
+		d.Facet = DefaultDelimiters.Facet
+	}
+	if d.Value == "" {
+		d.Value = DefaultDelimiters.Value
+	}
+	return d
+}
+
+// PartitionKeyPrefix builds the partition key prefix: <Prefix><service>
+func (d Delimiters) PartitionKeyPrefix(service string) string {
+	d = d.OrDefault()
+	return d.Prefix + strings.ToLower(service)
+}
+
+// SortKeyPrefix builds the sort key prefix: <Prefix><entity><Value><version>
+func (d Delimiters) SortKeyPrefix(entity, version string) string {
+	d = d.OrDefault()
+	entity = strings.ToLower(entity)
+	if version != "" {
+		return d.Prefix + entity + d.Value + version
+	}
+	return d.Prefix + entity
+}
+
+// LabelMarker returns the text preceding a facet value: <Facet><label><Value>
+func (d Delimiters) LabelMarker(label string) string {
+	d = d.OrDefault()
+	return d.Facet + label + d.Value
+}
+
+// KeyEscape precedes facet delimiters (and itself) inside facet values
+const KeyEscape = "\\"
+
+// EscapeValue escapes the facet delimiter and escape character in a facet
+// value so the value cannot end early when the key is parsed. It returns the
+// value unchanged unless d.Escape is set, since escaping changes the stored
+// key of any existing item whose facet value contains either character.
+func EscapeValue(value string, d Delimiters) string {
+	d = d.OrDefault()
+	if !d.Escape {
+		return value
+	}
+	if !strings.Contains(value, KeyEscape) && !strings.Contains(value, d.Facet) {
+		return value
+	}
+	value = strings.ReplaceAll(value, KeyEscape, KeyEscape+KeyEscape)
+	return strings.ReplaceAll(value, d.Facet, KeyEscape+d.Facet)
+}
+
+// ParseKey splits a key composed by MakeKey back into its facet values, which
+// are unescaped when d.Escape is set (and lowercased, as stored). Parsing stops at the end of a
+// partial key; ok is false when the key does not follow the prefix and labels.
+func ParseKey(key, prefix string, labels []FacetLabel, d Delimiters) (values map[string]string, ok bool) {
+	d = d.OrDefault()
+	if !strings.HasPrefix(key, prefix) {
+		return nil, false
+	}
+	rest := key[len(prefix):]
+	values = make(map[string]string, len(labels))
+
+	for _, label := range labels {
+		if rest == "" {
+			break
+		}
+		marker := d.LabelMarker(label.Label)
+		if !strings.HasPrefix(rest, marker) {
+			return nil, false
+		}
+		rest = rest[len(marker):]
+
+		var value strings.Builder
+		for rest != "" && !strings.HasPrefix(rest, d.Facet) {
+			if d.Escape && strings.HasPrefix(rest, KeyEscape) && len(rest) > len(KeyEscape) {
+				rest = rest[len(KeyEscape):]
+				if strings.HasPrefix(rest, d.Facet) {
+					value.WriteString(d.Facet)
+					rest = rest[len(d.Facet):]
+					continue
+				}
+			}
+			_, size := utf8.DecodeRuneInString(rest)
+			value.WriteString(rest[:size])
+			rest = rest[size:]
+		}
+		values[label.Name] = value.String()
+	}
+
+	if rest != "" {
+		return nil, false
+	}
+	return values, true
 }
 
 // FacetLabel represents a facet with its label
@@ -37,6 +150,7 @@ func MakeKey(
 ) KeyResult {
 	key := options.Prefix
 	foundCount := 0
+	delimiters := options.Delimiters.OrDefault()
 
 	for i := 0; i < len(labels); i++ {
 		label := labels[i]
@@ -51,7 +165,7 @@ func MakeKey(
 		if options.IsCustom {
 			key = fmt.Sprintf("%s%s", key, label.Label)
 		} else {
-			key = fmt.Sprintf("%s#%s_", key, label.Label)
+			key = key + delimiters.LabelMarker(label.Label)
 		}
 
 		// If value is undefined, we can't build any more of the key
@@ -74,6 +188,7 @@ func MakeKey(
 		} else {
 			formattedValue = strings.ToLower(fmt.Sprintf("%v", value))
 		}
+		formattedValue = EscapeValue(formattedValue, delimiters)
 		key = fmt.Sprintf("%s%s", key, formattedValue)
 	}
 
@@ -96,6 +211,68 @@ func MakeKey(
 	}
 }
 
+// KeyTemplate is the precomputed layout of one key: the label marker of each
+// facet and the key's casing. Make composes the same keys as MakeKey with
+// IsCustom, ExcludeLabelTail and Postfix unset, without rebuilding labels or
+// formatting through fmt for every write.
+type KeyTemplate struct {
+	facets     []string
+	markers    []string
+	casing     *string
+	delimiters Delimiters
+}
+
+// NewKeyTemplate precomputes the label markers for facets
+func NewKeyTemplate(facets []string, casing *string, d Delimiters) *KeyTemplate {
+	d = d.OrDefault()
+	markers := make([]string, len(facets))
+	for i, label := range BuildLabels(facets) {
+		markers[i] = d.LabelMarker(label.Label)
+	}
+	return &KeyTemplate{facets: facets, markers: markers, casing: casing, delimiters: d}
+}
+
+// Make composes a key from prefix and the supplied facet values, stopping
+// after the label of the first facet without a value
+func (t *KeyTemplate) Make(prefix string, supplied map[string]interface{}) KeyResult {
+	var key strings.Builder
+	key.Grow(len(prefix) + 16*len(t.facets))
+	key.WriteString(prefix)
+
+	found := 0
+	for i, facet := range t.facets {
+		key.WriteString(t.markers[i])
+		value, exists := supplied[facet]
+		if !exists {
+			break
+		}
+		found++
+		key.WriteString(EscapeValue(formatKeyValue(value), t.delimiters))
+	}
+
+	result := KeyResult{Key: key.String(), Fulfilled: found == len(t.facets)}
+	if t.casing != nil {
+		result.Key = formatKeyCasing(result.Key, *t.casing)
+	}
+	return result
+}
+
+// formatKeyValue lowercases a facet value as MakeKey does, avoiding fmt for
+// the common string and bool cases
+func formatKeyValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.ToLower(v)
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return strings.ToLower(fmt.Sprintf("%v", value))
+	}
+}
+
 // formatKeyCasing applies casing transformations to a key
 func formatKeyCasing(key string, casing string) string {
 	switch strings.ToLower(casing) {
@@ -113,17 +290,13 @@ func formatKeyCasing(key string, casing string) string {
 // BuildPartitionKeyPrefix builds the partition key prefix
 // Format: $<service> (all lowercase)
 func BuildPartitionKeyPrefix(service string) string {
-	return fmt.Sprintf("$%s", strings.ToLower(service))
+	return DefaultDelimiters.PartitionKeyPrefix(service)
 }
 
 // BuildSortKeyPrefix builds the sort key prefix
 // Format: $<entity>_<version> (all lowercase)
 func BuildSortKeyPrefix(entity, version string) string {
-	entity = strings.ToLower(entity)
-	if version != "" {
-		return fmt.Sprintf("$%s_%s", entity, version)
-	}
-	return fmt.Sprintf("$%s", entity)
+	return DefaultDelimiters.SortKeyPrefix(entity, version)
 }
 
 // BuildLabels creates FacetLabel array from facet names
@@ -204,6 +204,129 @@ func TestMakeKey(t *testing.T) {
 	}
 }
 
+func TestMakeKeyCustomDelimiters(t *testing.T) {
+	delimiters := Delimiters{Prefix: "!", Facet: "|", Value: "="}
+	options := KeyOptions{
+		Prefix:     delimiters.SortKeyPrefix("Order", "2"),
+		Delimiters: delimiters,
+	}
+	labels := BuildLabels([]string{"store", "orderId"})
+	result := MakeKey(options, []string{"store", "orderId"}, map[string]interface{}{
+		"store":   "East#1",
+		"orderId": "A_7",
+	}, labels)
+
+	expected := "!order=2|store=east#1|orderid=a_7"
+	if result.Key != expected || !result.Fulfilled {
+		t.Errorf("Expected %q (fulfilled), got %q (fulfilled=%v)", expected, result.Key, result.Fulfilled)
+	}
+
+	// Partially configured delimiters fall back to the defaults
+	partial := Delimiters{Facet: "|"}
+	if got := partial.PartitionKeyPrefix("Service"); got != "$service" {
+		t.Errorf("Expected default prefix, got %q", got)
+	}
+	if got := partial.LabelMarker("id"); got != "|id_" {
+		t.Errorf("Expected |id_, got %q", got)
+	}
+}
+
+func TestKeyTemplateMatchesMakeKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		facets     []string
+		casing     *string
+		delimiters Delimiters
+		supplied   map[string]interface{}
+	}{
+		{"all facets", []string{"mall", "building"}, nil, Delimiters{}, map[string]interface{}{"mall": "EastPointe", "building": "BuildingA"}},
+		{"partial", []string{"mall", "building", "unit"}, nil, Delimiters{}, map[string]interface{}{"mall": "EastPointe"}},
+		{"gap", []string{"mall", "building", "unit"}, nil, Delimiters{}, map[string]interface{}{"mall": "EastPointe", "unit": "B54"}},
+		{"no facets supplied", []string{"id"}, nil, Delimiters{}, map[string]interface{}{"other": "x"}},
+		{"no facets", nil, nil, Delimiters{}, map[string]interface{}{}},
+		{"non-string values", []string{"active", "count", "ratio"}, nil, Delimiters{}, map[string]interface{}{"active": true, "count": 42, "ratio": 1.5}},
+		{"escaped values", []string{"path"}, nil, Delimiters{}, map[string]interface{}{"path": `a#b\c`}},
+		{"upper casing", []string{"userId"}, stringPtr("upper"), Delimiters{}, map[string]interface{}{"userId": "Abc"}},
+		{"custom delimiters", []string{"store", "orderId"}, nil, Delimiters{Prefix: "!", Facet: "|", Value: "="}, map[string]interface{}{"store": "East|1", "orderId": "A_7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix := tt.delimiters.SortKeyPrefix("Order", "1")
+			expected := MakeKey(KeyOptions{Prefix: prefix, Casing: tt.casing, Delimiters: tt.delimiters}, tt.facets, tt.supplied, BuildLabels(tt.facets))
+			got := NewKeyTemplate(tt.facets, tt.casing, tt.delimiters).Make(prefix, tt.supplied)
+			if got != expected {
+				t.Errorf("Expected %+v, got %+v", expected, got)
+			}
+		})
+	}
+}
+
+func BenchmarkMakeKey(b *testing.B) {
+	facets := []string{"tenant", "userId", "createdAt"}
+	supplied := map[string]interface{}{"tenant": "acme", "userId": "u-123", "createdAt": 1700000000}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		MakeKey(KeyOptions{Prefix: "$app"}, facets, supplied, BuildLabels(facets))
+	}
+}
+
+func BenchmarkKeyTemplate(b *testing.B) {
+	facets := []string{"tenant", "userId", "createdAt"}
+	supplied := map[string]interface{}{"tenant": "acme", "userId": "u-123", "createdAt": 1700000000}
+	template := NewKeyTemplate(facets, nil, DefaultDelimiters)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		template.Make("$app", supplied)
+	}
+}
+
+func TestParseKeyRoundTrip(t *testing.T) {
+	facets := []string{"org", "team"}
+	labels := BuildLabels(facets)
+
+	escaped := DefaultDelimiters
+	escaped.Escape = true
+	for _, delimiters := range []Delimiters{escaped, {Prefix: "!", Facet: "||", Value: "=", Escape: true}} {
+		prefix := delimiters.PartitionKeyPrefix("service")
+		for _, supplied := range []map[string]interface{}{
+			{"org": "a#b", "team": "c"},
+			{"org": "a_b", "team": "#"},
+			{"org": `a\b#`, "team": "x||y"},
+			{"org": "plain", "team": ""},
+		} {
+			key := MakeKey(KeyOptions{Prefix: prefix, Delimiters: delimiters}, facets, supplied, labels).Key
+
+			values, ok := ParseKey(key, prefix, labels, delimiters)
+			if !ok {
+				t.Errorf("Failed to parse %q", key)
+				continue
+			}
+			for _, facet := range facets {
+				if values[facet] != supplied[facet] {
+					t.Errorf("Key %q: expected %s=%q, got %q", key, facet, supplied[facet], values[facet])
+				}
+			}
+		}
+	}
+
+	supplied := map[string]interface{}{"org": "a#b", "team": "c"}
+	key := MakeKey(KeyOptions{Prefix: "$service", Delimiters: escaped}, facets, supplied, labels).Key
+	if key != `$service#org_a\#b#team_c` {
+		t.Errorf("Expected escaped facet delimiter, got %q", key)
+	}
+	if key := MakeKey(KeyOptions{Prefix: "$service"}, facets, supplied, labels).Key; key != "$service#org_a#b#team_c" {
+		t.Errorf("Expected values to be stored raw without Escape, got %q", key)
+	}
+
+	if _, ok := ParseKey("$service#other_a", "$service", labels, DefaultDelimiters); ok {
+		t.Error("Expected a key with unknown labels not to parse")
+	}
+	if _, ok := ParseKey("$other#org_a", "$service", labels, DefaultDelimiters); ok {
+		t.Error("Expected a key with a different prefix not to parse")
+	}
+}
+
 func TestBuildPartitionKeyPrefix(t *testing.T) {
 	tests := []struct {
 		service  string
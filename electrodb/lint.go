@@ -0,0 +1,97 @@
+package electrodb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Warning describes a potential schema problem reported by Schema.Lint
+type Warning struct {
+	Code    string
+	Message string
+}
+
+// Lint warning codes
+const (
+	LintEnumWithoutValues   = "EnumWithoutValues"
+	LintPaddingOnNonKey     = "PaddingOnNonKey"
+	LintRequiredWithDefault = "RequiredWithDefault"
+	LintReservedWordFacet   = "ReservedWordFacet"
+	LintUndeclaredFacet     = "UndeclaredFacet"
+)
+
+// reservedWords holds DynamoDB reserved words that commonly collide with
+// attribute names. Expressions built by this package always use placeholders,
+// but hand-written expressions and other tools reading the table do not.
+var reservedWords = map[string]bool{
+	"action": true, "all": true, "and": true, "attribute": true, "between": true,
+	"by": true, "comment": true, "connection": true, "count": true, "data": true,
+	"date": true, "day": true, "delete": true, "domain": true, "end": true,
+	"group": true, "hour": true, "in": true, "index": true, "items": true,
+	"key": true, "keys": true, "language": true, "level": true, "location": true,
+	"minute": true, "month": true, "name": true, "not": true, "number": true,
+	"or": true, "order": true, "owner": true, "path": true, "percent": true,
+	"position": true, "range": true, "rank": true, "region": true, "role": true,
+	"schema": true, "second": true, "session": true, "set": true, "size": true,
+	"source": true, "state": true, "status": true, "string": true, "system": true,
+	"table": true, "text": true, "time": true, "timestamp": true, "token": true,
+	"total": true, "type": true, "unique": true, "update": true, "user": true,
+	"value": true, "values": true, "view": true, "year": true, "zone": true,
+}
+
+// Lint reports common single-table design pitfalls in the schema that are not
+// hard errors: reserved-word facets, undeclared facets, enums without values,
+// required attributes with defaults and padding on attributes outside any key.
+// It is meant to run before NewEntity, which rejects undeclared facets.
+// Warnings are returned in a stable order.
+func (s *Schema) Lint() []Warning {
+	var warnings []Warning
+	add := func(code, format string, args ...interface{}) {
+		warnings = append(warnings, Warning{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	keyFacets := make(map[string]bool)
+	for _, indexName := range sortedIndexNames(s.Indexes) {
+		index := s.Indexes[indexName]
+		facets := index.PK.Facets
+		if index.SK != nil {
+			facets = append(append([]string{}, facets...), index.SK.Facets...)
+		}
+
+		for _, facet := range facets {
+			keyFacets[facet] = true
+			if _, exists := s.Attributes[facet]; !exists {
+				add(LintUndeclaredFacet, "Facet '%s' in index '%s' is not a declared attribute", facet, indexName)
+			}
+			if reservedWords[strings.ToLower(facet)] {
+				add(LintReservedWordFacet, "Facet '%s' in index '%s' is a DynamoDB reserved word", facet, indexName)
+			}
+		}
+	}
+
+	for _, name := range sortedAttributeNames(s.Attributes) {
+		attr := s.Attributes[name]
+		if attr.Type == AttributeTypeEnum && len(attr.EnumValues) == 0 {
+			add(LintEnumWithoutValues, "Enum attribute '%s' has no EnumValues", name)
+		}
+		if attr.Required && attr.Default != nil {
+			add(LintRequiredWithDefault, "Attribute '%s' is Required and has a Default; Required is checked after the Default fills a missing value, so it never fails", name)
+		}
+		if attr.Padding != nil && !keyFacets[name] {
+			add(LintPaddingOnNonKey, "Attribute '%s' is padded but is not a facet of any index", name)
+		}
+	}
+
+	return warnings
+}
+
+// sortedIndexNames returns index names in a stable order
+func sortedIndexNames(indexes map[string]*IndexDefinition) []string {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
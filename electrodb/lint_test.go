@@ -0,0 +1,69 @@
+package electrodb
+
+import (
+	"testing"
+)
+
+func TestSchemaLint(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Task",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"status":   {Type: AttributeTypeString, Required: true},
+			"priority": {Type: AttributeTypeEnum},
+			"owner": {
+				Type:     AttributeTypeString,
+				Required: true,
+				Default:  func() interface{} { return "nobody" },
+			},
+			"points": {Type: AttributeTypeNumber, Padding: &PaddingConfig{Length: 4}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"byStatus": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"status"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"dueDate"}},
+			},
+		},
+	}
+
+	warnings := schema.Lint()
+
+	expected := []string{
+		LintReservedWordFacet,
+		LintUndeclaredFacet,
+		LintRequiredWithDefault,
+		LintPaddingOnNonKey,
+		LintEnumWithoutValues,
+	}
+	if len(warnings) != len(expected) {
+		t.Fatalf("Expected %d warnings, got %d: %v", len(expected), len(warnings), warnings)
+	}
+	for i, code := range expected {
+		if warnings[i].Code != code {
+			t.Errorf("Warning %d: expected code %s, got %s (%s)", i, code, warnings[i].Code, warnings[i].Message)
+		}
+	}
+
+	clean := &Schema{
+		Service: "TestService",
+		Entity:  "Task",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"taskId": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"taskId"}},
+			},
+		},
+	}
+	if warnings := clean.Lint(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for a clean schema, got %v", warnings)
+	}
+}
@@ -0,0 +1,100 @@
+package electrodb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// mockClient is a DynamoDBClient whose operations are supplied per test.
+// Operations without a handler return an empty output.
+type mockClient struct {
+	getItem            func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	putItem            func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	updateItem         func(*dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	deleteItem         func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	query              func(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	scan               func(*dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	batchGetItem       func(*dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error)
+	batchWriteItem     func(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	transactWriteItems func(*dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
+	transactGetItems   func(*dynamodb.TransactGetItemsInput) (*dynamodb.TransactGetItemsOutput, error)
+	executeStatement   func(*dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error)
+}
+
+func (m *mockClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if m.getItem == nil {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return m.getItem(params)
+}
+
+func (m *mockClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if m.putItem == nil {
+		return &dynamodb.PutItemOutput{}, nil
+	}
+	return m.putItem(params)
+}
+
+func (m *mockClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if m.updateItem == nil {
+		return &dynamodb.UpdateItemOutput{}, nil
+	}
+	return m.updateItem(params)
+}
+
+func (m *mockClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if m.deleteItem == nil {
+		return &dynamodb.DeleteItemOutput{}, nil
+	}
+	return m.deleteItem(params)
+}
+
+func (m *mockClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if m.query == nil {
+		return &dynamodb.QueryOutput{}, nil
+	}
+	return m.query(params)
+}
+
+func (m *mockClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if m.scan == nil {
+		return &dynamodb.ScanOutput{}, nil
+	}
+	return m.scan(params)
+}
+
+func (m *mockClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if m.batchGetItem == nil {
+		return &dynamodb.BatchGetItemOutput{}, nil
+	}
+	return m.batchGetItem(params)
+}
+
+func (m *mockClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if m.batchWriteItem == nil {
+		return &dynamodb.BatchWriteItemOutput{}, nil
+	}
+	return m.batchWriteItem(params)
+}
+
+func (m *mockClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if m.transactWriteItems == nil {
+		return &dynamodb.TransactWriteItemsOutput{}, nil
+	}
+	return m.transactWriteItems(params)
+}
+
+func (m *mockClient) TransactGetItems(ctx context.Context, params *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	if m.transactGetItems == nil {
+		return &dynamodb.TransactGetItemsOutput{}, nil
+	}
+	return m.transactGetItems(params)
+}
+
+func (m *mockClient) ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error) {
+	if m.executeStatement == nil {
+		return &dynamodb.ExecuteStatementOutput{}, nil
+	}
+	return m.executeStatement(params)
+}
@@ -1,6 +1,7 @@
 package electrodb
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -291,3 +292,57 @@ func TestNamedFilterWithComplexExpression(t *testing.T) {
 		t.Errorf("Expected filter expression to contain 'AND', got: %s", filterExpr)
 	}
 }
+
+func TestFilterExpressionDeterministic(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"productId": {Type: AttributeTypeString, Required: true},
+			"category":  {Type: AttributeTypeString, Required: true},
+			"price":     {Type: AttributeTypeNumber},
+			"rating":    {Type: AttributeTypeNumber},
+			"inStock":   {Type: AttributeTypeBoolean},
+			"brand":     {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"byCategory": {
+				Index: stringPtr("gsi1pk-gsi1sk-index"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"category"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"productId"}},
+			},
+		},
+		Filters: map[string]FilterFunc{
+			"affordable": func(attr AttributeOperations, params map[string]interface{}) string {
+				return attr["price"].Lte(params["maxPrice"])
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	build := func() string {
+		params, err := entity.Query("byCategory").Query("electronics").
+			Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+				return attrs["rating"].Gte(4) + " AND " + attrs["inStock"].Eq(true) + " AND " + attrs["brand"].Contains("acme")
+			}).
+			Filter("affordable", map[string]interface{}{"maxPrice": 100}).
+			Params()
+		if err != nil {
+			t.Fatalf("Failed to build params: %v", err)
+		}
+		names := params["ExpressionAttributeNames"].(map[string]string)
+		return fmt.Sprintf("%s %v", params["FilterExpression"], []string{names["#attr0"], names["#attr1"], names["#attr2"], names["#attr3"]})
+	}
+
+	expected := "(#attr0 >= :val0 AND #attr1 = :val1 AND contains(#attr2, :val2)) AND (#attr3 <= :val3) [rating inStock brand price]"
+	for i := 0; i < 20; i++ {
+		if got := build(); got != expected {
+			t.Fatalf("Run %d: expected %q, got %q", i, expected, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package electrodb
+
+import (
+	"strings"
+)
+
+// ApplyNormalization normalizes the string values of attributes that have a
+// Normalize config, so values written and values used in key lookups agree
+func ApplyNormalization(item Item, schema *Schema) Item {
+	result := make(Item, len(item))
+	for k, v := range item {
+		result[k] = v
+	}
+
+	for attrName, attr := range schema.Attributes {
+		if attr.Normalize == nil {
+			continue
+		}
+		if value, exists := result[attrName]; exists {
+			result[attrName] = normalizeValue(value, attr.Normalize)
+		}
+	}
+
+	return result
+}
+
+// normalizeFacet normalizes a single facet value by attribute name
+func (s *Schema) normalizeFacet(name string, value interface{}) interface{} {
+	attr, exists := s.Attributes[name]
+	if !exists || attr.Normalize == nil {
+		return value
+	}
+	return normalizeValue(value, attr.Normalize)
+}
+
+// normalizeValue trims and re-cases a string value; other values are
+// returned unchanged
+func normalizeValue(value interface{}, normalization *Normalization) interface{} {
+	str, ok := value.(string)
+	if !ok {
+		return value
+	}
+
+	if normalization.Trim {
+		str = strings.TrimSpace(str)
+	}
+	switch strings.ToLower(normalization.Casing) {
+	case "lower":
+		str = strings.ToLower(str)
+	case "upper":
+		str = strings.ToUpper(str)
+	}
+	return str
+}
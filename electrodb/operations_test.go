@@ -234,6 +234,7 @@ func TestCombinedOperations(t *testing.T) {
 			"balance": {Type: AttributeTypeNumber},
 			"tags":    {Type: AttributeTypeList},
 			"notes":   {Type: AttributeTypeList},
+			"oldAttribute": {Type: AttributeTypeString},
 		},
 		Indexes: map[string]*IndexDefinition{
 			"primary": {
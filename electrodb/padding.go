@@ -2,6 +2,7 @@ package electrodb
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -15,8 +16,9 @@ func ApplyPadding(item Item, schema *Schema) Item {
 	}
 
 	// Apply padding to each attribute that has padding config
-	for attrName, attrDef := range schema.Attributes {
-		if attrDef.Padding == nil {
+	for attrName := range schema.Attributes {
+		padding := schema.storedPaddingFor(attrName)
+		if padding == nil {
 			continue
 		}
 
@@ -26,7 +28,7 @@ func ApplyPadding(item Item, schema *Schema) Item {
 		}
 
 		// Apply padding
-		padded := padValue(value, attrDef.Padding)
+		padded := padValue(value, padding)
 		if padded != nil {
 			result[attrName] = padded
 		}
@@ -36,7 +38,8 @@ func ApplyPadding(item Item, schema *Schema) Item {
 }
 
 // RemovePadding removes padding from attributes that have PaddingConfig
-// This is used when reading from DynamoDB
+// This is used when reading from DynamoDB. Only numeric attributes are
+// unpadded; padded strings such as zip codes are returned as stored.
 func RemovePadding(item Item, schema *Schema) Item {
 	if item == nil {
 		return nil
@@ -48,8 +51,9 @@ func RemovePadding(item Item, schema *Schema) Item {
 	}
 
 	// Remove padding from each attribute that has padding config
-	for attrName, attrDef := range schema.Attributes {
-		if attrDef.Padding == nil {
+	for attrName, attr := range schema.Attributes {
+		padding := schema.storedPaddingFor(attrName)
+		if padding == nil || attr.Type != AttributeTypeNumber {
 			continue
 		}
 
@@ -59,7 +63,7 @@ func RemovePadding(item Item, schema *Schema) Item {
 		}
 
 		// Remove padding
-		unpadded := unpadValue(value, attrDef.Padding)
+		unpadded := unpadValue(value, padding)
 		if unpadded != nil {
 			result[attrName] = unpadded
 		}
@@ -68,6 +72,63 @@ func RemovePadding(item Item, schema *Schema) Item {
 	return result
 }
 
+// paddingFor returns the padding of an attribute's value in composed keys:
+// its stored padding, or Schema.Padding for a numeric key facet without its
+// own PaddingConfig.
+func (s *Schema) paddingFor(name string) *PaddingConfig {
+	if padding := s.storedPaddingFor(name); padding != nil {
+		return padding
+	}
+
+	attr, exists := s.Attributes[name]
+	if exists && s.Padding != nil && attr.Type == AttributeTypeNumber && s.isKeyFacet(name) {
+		return s.Padding
+	}
+	return nil
+}
+
+// storedPaddingFor returns the padding of an attribute's stored value. Only
+// attributes with their own PaddingConfig are stored padded; empty fields are
+// filled from Schema.Padding. Schema.Padding alone only pads composed keys,
+// so those attributes keep their declared type.
+func (s *Schema) storedPaddingFor(name string) *PaddingConfig {
+	attr, exists := s.Attributes[name]
+	if !exists || attr.Padding == nil {
+		return nil
+	}
+
+	if s.Padding == nil {
+		return attr.Padding
+	}
+	padding := *attr.Padding
+	if padding.Length == 0 {
+		padding.Length = s.Padding.Length
+	}
+	if padding.Char == "" {
+		padding.Char = s.Padding.Char
+	}
+	return &padding
+}
+
+// isKeyFacet reports whether an attribute is a facet of any index key
+func (s *Schema) isKeyFacet(name string) bool {
+	for _, index := range s.Indexes {
+		for _, facet := range index.PK.Facets {
+			if facet == name {
+				return true
+			}
+		}
+		if index.SK != nil {
+			for _, facet := range index.SK.Facets {
+				if facet == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // padValue pads a single value according to padding config
 func padValue(value interface{}, padding *PaddingConfig) interface{} {
 	if padding == nil || padding.Length == 0 {
@@ -87,8 +148,14 @@ func padValue(value interface{}, padding *PaddingConfig) interface{} {
 		strValue = strconv.Itoa(v)
 	case int64:
 		strValue = strconv.FormatInt(v, 10)
+	case float32:
+		return padValue(float64(v), padding)
 	case float64:
-		// For floats, convert to int first
+		// Padding a fraction would have to truncate it, so only whole
+		// floats are padded
+		if v != math.Trunc(v) {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
 		strValue = strconv.FormatInt(int64(v), 10)
 	case string:
 		strValue = v
@@ -138,6 +205,10 @@ func unpadValue(value interface{}, padding *PaddingConfig) interface{} {
 		// Return as int64 to match common numeric types
 		return intVal
 	}
+	if floatVal, err := strconv.ParseFloat(unpadded, 64); err == nil {
+		// Fractions are stored unpadded
+		return floatVal
+	}
 
 	// Return as string if not a number
 	return unpadded
@@ -1,6 +1,11 @@
 package electrodb
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
 
 // Page represents a single page of query results
 type Page struct {
@@ -29,40 +34,20 @@ func (qc *QueryChain) Pages(opts ...PagesOptions) ([]map[string]interface{}, err
 	pageCount := 0
 
 	for {
-		// Build options for this page
-		queryOpts := &QueryOptions{
-			Cursor: cursor,
+		// Build options for this page, inheriting the query chain's options
+		queryOpts := &QueryOptions{}
+		if qc.options != nil {
+			*queryOpts = *qc.options
 		}
+		queryOpts.Cursor = cursor
+		queryOpts.IgnoreCursor = false
 
 		if limit > 0 {
 			queryOpts.Limit = &limit
 		}
 
-		// Override options if they were set on the query chain
-		if qc.options != nil {
-			if qc.options.Limit != nil && limit == 0 {
-				queryOpts.Limit = qc.options.Limit
-			}
-			if qc.options.Order != nil {
-				queryOpts.Order = qc.options.Order
-			}
-			if qc.options.Raw {
-				queryOpts.Raw = qc.options.Raw
-			}
-		}
-
 		// Execute query with cursor
-		tempChain := &QueryChain{
-			entity:        qc.entity,
-			accessPattern: qc.accessPattern,
-			index:         qc.index,
-			pkFacets:      qc.pkFacets,
-			skCondition:   qc.skCondition,
-			filterBuilder: qc.filterBuilder,
-			options:       queryOpts,
-		}
-
-		result, err := tempChain.Go()
+		result, err := qc.page(queryOpts)
 		if err != nil {
 			return nil, err
 		}
@@ -85,9 +70,85 @@ func (qc *QueryChain) Pages(opts ...PagesOptions) ([]map[string]interface{}, err
 		}
 	}
 
+	if qc.sortBy != "" {
+		sortItems(allItems, qc.sortBy, qc.sortDesc)
+	}
+
 	return allItems, nil
 }
 
+// sortItems stably sorts items on attr, placing items without it last
+func sortItems(items []map[string]interface{}, attr string, desc bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, aok := items[i][attr]
+		b, bok := items[j][attr]
+		if !aok || a == nil || !bok || b == nil {
+			return (aok && a != nil) && !(bok && b != nil)
+		}
+		if desc {
+			return compareValues(b, a) < 0
+		}
+		return compareValues(a, b) < 0
+	})
+}
+
+// compareValues orders numbers numerically, times chronologically, false
+// before true and anything else by its string form
+func compareValues(a, b interface{}) int {
+	if x, ok := toFloat64(a); ok {
+		if y, ok := toFloat64(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	if x, ok := a.(bool); ok {
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			}
+			return 1
+		}
+	}
+	x, y := fmt.Sprintf("%v", a), fmt.Sprintf("%v", b)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// toFloat64 converts Go numeric types to float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
 // PagesIterator provides an iterator interface for paginating through results
 type PagesIterator struct {
 	query     *QueryChain
@@ -115,22 +176,15 @@ func (qc *QueryChain) Page(opts ...PagesOptions) *PagesIterator {
 		}
 	}
 
-	if queryOpts == nil {
-		queryOpts = &QueryOptions{}
-	}
-
 	// Inherit options from query chain
+	inherited := &QueryOptions{}
 	if qc.options != nil {
-		if qc.options.Limit != nil && queryOpts.Limit == nil {
-			queryOpts.Limit = qc.options.Limit
-		}
-		if qc.options.Order != nil {
-			queryOpts.Order = qc.options.Order
-		}
-		if qc.options.Raw {
-			queryOpts.Raw = qc.options.Raw
-		}
+		*inherited = *qc.options
+	}
+	if queryOpts != nil {
+		inherited.Limit = queryOpts.Limit
 	}
+	queryOpts = inherited
 
 	return &PagesIterator{
 		query:     qc,
@@ -143,54 +197,49 @@ func (qc *QueryChain) Page(opts ...PagesOptions) *PagesIterator {
 
 // Next retrieves the next page of results
 // Returns (page, hasMore, error)
+// A filter can leave a page with no items even though more follow; Next
+// skips such pages, returning a page with no items only when the results are
+// exhausted or MaxPages is reached. Skipped pages count towards MaxPages.
 func (pi *PagesIterator) Next() (*Page, bool, error) {
 	if pi.done {
 		return nil, false, pi.err
 	}
 
-	// Check if max pages reached
-	if pi.maxPages > 0 && pi.pageCount >= pi.maxPages {
-		pi.done = true
-		return nil, false, nil
-	}
+	var result *QueryResponse
+	for {
+		// Check if max pages reached
+		if pi.maxPages > 0 && pi.pageCount >= pi.maxPages {
+			if result != nil {
+				// Return the last empty page fetched, with its cursor
+				break
+			}
+			pi.done = true
+			return nil, false, nil
+		}
 
-	// Build query options with cursor
-	opts := &QueryOptions{
-		Cursor: pi.cursor,
-	}
+		// Build query options with cursor
+		opts := *pi.options
+		opts.Cursor = pi.cursor
+		opts.IgnoreCursor = false
 
-	if pi.options.Limit != nil {
-		opts.Limit = pi.options.Limit
-	}
-	if pi.options.Order != nil {
-		opts.Order = pi.options.Order
-	}
-	if pi.options.Raw {
-		opts.Raw = pi.options.Raw
-	}
+		// Execute query
+		var err error
+		result, err = pi.query.page(&opts)
+		if err != nil {
+			pi.done = true
+			pi.err = err
+			return nil, false, err
+		}
 
-	// Execute query
-	tempChain := &QueryChain{
-		entity:        pi.query.entity,
-		accessPattern: pi.query.accessPattern,
-		index:         pi.query.index,
-		pkFacets:      pi.query.pkFacets,
-		skCondition:   pi.query.skCondition,
-		filterBuilder: pi.query.filterBuilder,
-		options:       opts,
-	}
+		// Update cursor for next iteration
+		pi.cursor = result.Cursor
+		pi.pageCount++
 
-	result, err := tempChain.Go()
-	if err != nil {
-		pi.done = true
-		pi.err = err
-		return nil, false, err
+		if len(result.Data) > 0 || result.Cursor == nil || *result.Cursor == "" {
+			break
+		}
 	}
 
-	// Update cursor for next iteration
-	pi.cursor = result.Cursor
-	pi.pageCount++
-
 	// Create page response
 	page := &Page{
 		Data:   result.Data,
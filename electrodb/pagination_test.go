@@ -1,7 +1,14 @@
 package electrodb
 
 import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func TestPagesMethod(t *testing.T) {
@@ -304,3 +311,628 @@ func TestPageOptionsOverrideQueryOptions(t *testing.T) {
 		t.Errorf("Expected overridden limit to be 50, got %v", iterator.options.Limit)
 	}
 }
+
+func TestQueryOrdering(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"productId": {Type: AttributeTypeString, Required: true},
+			"category":  {Type: AttributeTypeString, Required: true},
+			"price":     {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"byCategory": {
+				Index: stringPtr("gsi1pk-gsi1sk-index"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"category"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"productId"}},
+			},
+		},
+	}
+
+	// Two pages, each ordered by productId but not by price
+	pages := [][]map[string]types.AttributeValue{
+		{
+			{"productId": &types.AttributeValueMemberS{Value: "a"}, "price": &types.AttributeValueMemberN{Value: "30"}},
+			{"productId": &types.AttributeValueMemberS{Value: "b"}, "price": &types.AttributeValueMemberN{Value: "5"}},
+		},
+		{
+			{"productId": &types.AttributeValueMemberS{Value: "c"}},
+			{"productId": &types.AttributeValueMemberS{Value: "d"}, "price": &types.AttributeValueMemberN{Value: "12"}},
+		},
+	}
+	var forward []bool
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			forward = append(forward, input.ScanIndexForward == nil || *input.ScanIndexForward)
+			if input.ExclusiveStartKey == nil {
+				return &dynamodb.QueryOutput{
+					Items:            pages[0],
+					LastEvaluatedKey: map[string]types.AttributeValue{"productId": &types.AttributeValueMemberS{Value: "b"}},
+				}, nil
+			}
+			return &dynamodb.QueryOutput{Items: pages[1]}, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	query := entity.Query("byCategory").Query("electronics")
+
+	// Native ordering is by sort key and sets ScanIndexForward
+	params, err := query.Descending().Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	if params["ScanIndexForward"] != false {
+		t.Errorf("Expected ScanIndexForward false for Descending, got %v", params["ScanIndexForward"])
+	}
+	params, err = query.Descending().Ascending().Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	if _, ok := params["ScanIndexForward"]; ok {
+		t.Errorf("Expected default forward order for Ascending, got %v", params["ScanIndexForward"])
+	}
+
+	// Client-side sorting applies across all accumulated pages
+	items, err := query.SortBy("price", false).Pages()
+	if err != nil {
+		t.Fatalf("Pages failed: %v", err)
+	}
+	expected := []string{"b", "d", "a", "c"}
+	for i, id := range expected {
+		if items[i]["productId"] != id {
+			t.Errorf("Ascending position %d: expected %s, got %v", i, id, items[i]["productId"])
+		}
+	}
+
+	items, err = query.Descending().SortBy("price", true).Pages()
+	if err != nil {
+		t.Fatalf("Pages failed: %v", err)
+	}
+	expected = []string{"a", "d", "b", "c"}
+	for i, id := range expected {
+		if items[i]["productId"] != id {
+			t.Errorf("Descending position %d: expected %s, got %v", i, id, items[i]["productId"])
+		}
+	}
+	if forward[len(forward)-1] {
+		t.Error("Expected Descending to be sent with Pages")
+	}
+}
+
+func TestQueryGoFollowsPages(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"productId": {Type: AttributeTypeString, Required: true},
+			"category":  {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"byCategory": {
+				Index: stringPtr("gsi1pk-gsi1sk-index"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"category"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"productId"}},
+			},
+		},
+	}
+
+	// Every page returns one item and a cursor pointing at the next
+	calls := 0
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			calls++
+			id := fmt.Sprintf("p%d", calls)
+			return &dynamodb.QueryOutput{
+				Items:            []map[string]types.AttributeValue{{"productId": &types.AttributeValueMemberS{Value: id}}},
+				LastEvaluatedKey: map[string]types.AttributeValue{"productId": &types.AttributeValueMemberS{Value: id}},
+			}, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	query := entity.Query("byCategory").Query("electronics")
+
+	pages := 2
+	result, err := query.Options(&QueryOptions{Pages: &pages}).Go()
+	if err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 Query calls, got %d", calls)
+	}
+	if len(result.Data) != 2 || result.Data[0]["productId"] != "p1" || result.Data[1]["productId"] != "p2" {
+		t.Errorf("Expected concatenated items p1, p2, got %v", result.Data)
+	}
+	if result.Cursor == nil {
+		t.Error("Expected the cursor of the last page")
+	}
+
+	// Without Pages a single page is fetched
+	calls = 0
+	if _, err := query.Go(); err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 Query call without Pages, got %d", calls)
+	}
+
+	// Pages() is not multiplied by the Pages option
+	calls = 0
+	items, err := query.Options(&QueryOptions{Pages: &pages}).Pages(PagesOptions{MaxPages: 3})
+	if err != nil {
+		t.Fatalf("Pages failed: %v", err)
+	}
+	if calls != 3 || len(items) != 3 {
+		t.Errorf("Expected 3 Query calls and items, got %d calls and %d items", calls, len(items))
+	}
+}
+
+func TestPageInfo(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"productId": {Type: AttributeTypeString, Required: true},
+			"category":  {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"byCategory": {
+				Index: stringPtr("gsi1pk-gsi1sk-index"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"category"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"productId"}},
+			},
+		},
+	}
+
+	// The first page has a successor, the second is the last
+	lastKey := map[string]types.AttributeValue{"productId": &types.AttributeValueMemberS{Value: "p1"}}
+	page := func(exclusiveStartKey map[string]types.AttributeValue) (items []map[string]types.AttributeValue, next map[string]types.AttributeValue) {
+		items = []map[string]types.AttributeValue{{"productId": &types.AttributeValueMemberS{Value: "p1"}}}
+		if exclusiveStartKey == nil {
+			return items, lastKey
+		}
+		return items, nil
+	}
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			items, next := page(input.ExclusiveStartKey)
+			return &dynamodb.QueryOutput{Items: items, Count: 1, ScannedCount: 3, LastEvaluatedKey: next}, nil
+		},
+		scan: func(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+			items, next := page(input.ExclusiveStartKey)
+			return &dynamodb.ScanOutput{Items: items, Count: 1, ScannedCount: 5, LastEvaluatedKey: next}, nil
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	query := entity.Query("byCategory").Query("electronics")
+
+	first, err := query.Go()
+	if err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+	info := first.PageInfo
+	if !info.HasMore || info.Count != 1 || info.ScannedCount != 3 || !reflect.DeepEqual(info.LastEvaluatedKey, lastKey) {
+		t.Errorf("Unexpected first page info: %+v", info)
+	}
+
+	last, err := query.Options(&QueryOptions{Cursor: first.Cursor}).Go()
+	if err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+	if info := last.PageInfo; info.HasMore || last.Cursor != nil || info.LastEvaluatedKey != nil {
+		t.Errorf("Expected the last page to have no more results, got %+v", info)
+	}
+
+	// Following pages sums the counts
+	pages := 2
+	both, err := query.Options(&QueryOptions{Pages: &pages}).Go()
+	if err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+	if info := both.PageInfo; info.HasMore || info.Count != 2 || info.ScannedCount != 6 {
+		t.Errorf("Unexpected page info across pages: %+v", info)
+	}
+
+	scanned, err := entity.Scan().Go()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if info := scanned.PageInfo; !info.HasMore || info.Count != 1 || info.ScannedCount != 5 {
+		t.Errorf("Unexpected scan page info: %+v", info)
+	}
+}
+
+func TestQueryIgnoreCursor(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"productId": {Type: AttributeTypeString, Required: true},
+			"category":  {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"byCategory": {
+				Index: stringPtr("gsi1pk-gsi1sk-index"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"category"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"productId"}},
+			},
+		},
+	}
+
+	var startKeys []map[string]types.AttributeValue
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			startKeys = append(startKeys, input.ExclusiveStartKey)
+			return &dynamodb.QueryOutput{
+				Items:            []map[string]types.AttributeValue{{"productId": &types.AttributeValueMemberS{Value: "a"}}},
+				LastEvaluatedKey: map[string]types.AttributeValue{"productId": &types.AttributeValueMemberS{Value: "a"}},
+			}, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	query := entity.Query("byCategory").Query("electronics")
+
+	first, err := query.Go()
+	if err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+
+	// The cursor is sent unless IgnoreCursor is set
+	startKeys = nil
+	if _, err := query.Options(&QueryOptions{Cursor: first.Cursor}).Go(); err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+	if _, err := query.Options(&QueryOptions{Cursor: first.Cursor, IgnoreCursor: true}).Go(); err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+	if startKeys[0] == nil {
+		t.Error("Expected ExclusiveStartKey from the cursor")
+	}
+	if startKeys[1] != nil {
+		t.Errorf("Expected ExclusiveStartKey to be omitted with IgnoreCursor, got %v", startKeys[1])
+	}
+
+	// Pages after the first still follow their own cursors
+	startKeys = nil
+	pages := 2
+	if _, err := query.Options(&QueryOptions{Cursor: first.Cursor, IgnoreCursor: true, Pages: &pages}).Go(); err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+	if len(startKeys) != 2 || startKeys[0] != nil || startKeys[1] == nil {
+		t.Errorf("Expected the first page from the beginning and the second from its cursor, got %v", startKeys)
+	}
+}
+
+func TestQueryTake(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Task",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"listId": {Type: AttributeTypeString, Required: true},
+			"taskId": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"listId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"taskId"}},
+			},
+		},
+	}
+
+	// Tasks interleaved with another entity's notes in one partition; the
+	// mock applies Limit before matching the entity, as a filter would
+	pk := &types.AttributeValueMemberS{Value: "$testservice#listid_l1"}
+	var partition []map[string]types.AttributeValue
+	for i := 0; i < 10; i++ {
+		sk := fmt.Sprintf("$task_1#taskid_%02d", i)
+		if i%2 == 1 {
+			sk = fmt.Sprintf("$note_1#noteid_%02d", i)
+		}
+		partition = append(partition, map[string]types.AttributeValue{
+			"pk":     pk,
+			"sk":     &types.AttributeValueMemberS{Value: sk},
+			"listId": &types.AttributeValueMemberS{Value: "l1"},
+			"taskId": &types.AttributeValueMemberS{Value: fmt.Sprintf("%02d", i)},
+		})
+	}
+	// Order the partition by position rather than sort key
+	position := func(key map[string]types.AttributeValue) int {
+		for i, item := range partition {
+			if item["sk"].(*types.AttributeValueMemberS).Value == key["sk"].(*types.AttributeValueMemberS).Value {
+				return i + 1
+			}
+		}
+		return 0
+	}
+	queries := 0
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			queries++
+			start := 0
+			if input.ExclusiveStartKey != nil {
+				start = position(input.ExclusiveStartKey)
+			}
+			end := start + int(*input.Limit)
+			if end > len(partition) {
+				end = len(partition)
+			}
+			prefix := input.ExpressionAttributeValues[":sk"].(*types.AttributeValueMemberS).Value
+			output := &dynamodb.QueryOutput{}
+			for _, item := range partition[start:end] {
+				if strings.HasPrefix(item["sk"].(*types.AttributeValueMemberS).Value, prefix) {
+					output.Items = append(output.Items, item)
+				}
+			}
+			if end < len(partition) {
+				output.LastEvaluatedKey = map[string]types.AttributeValue{"pk": pk, "sk": partition[end-1]["sk"]}
+			}
+			return output, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	query := entity.Query("primary").Query("l1").Options(&QueryOptions{Limit: int32Ptr(3)})
+	result, err := query.Take(4)
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	var ids []interface{}
+	for _, item := range result.Data {
+		ids = append(ids, item["taskId"])
+		if _, ok := item["sk"]; ok {
+			t.Errorf("Expected key fields to be stripped, got %v", item)
+		}
+	}
+	if fmt.Sprint(ids) != "[00 02 04 06]" {
+		t.Errorf("Expected exactly 4 tasks, got %v", ids)
+	}
+	if queries != 3 {
+		t.Errorf("Expected 3 pages to be read, got %d", queries)
+	}
+	if result.Cursor == nil {
+		t.Fatal("Expected a cursor after stopping partway through a page")
+	}
+
+	// The cursor resumes right after the last task taken
+	rest, err := query.Options(&QueryOptions{Limit: int32Ptr(3), Cursor: result.Cursor}).Take(10)
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	if len(rest.Data) != 1 || rest.Data[0]["taskId"] != "08" {
+		t.Errorf("Expected the remaining task 08, got %v", rest.Data)
+	}
+	if rest.Cursor != nil && *rest.Cursor != "" {
+		t.Errorf("Expected no cursor once the partition is exhausted, got %v", *rest.Cursor)
+	}
+}
+
+func TestPaginationSkipsEmptyFilteredPages(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"productId": {Type: AttributeTypeString, Required: true},
+			"category":  {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"category"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"productId"}},
+			},
+		},
+	}
+
+	// Three pages whose items all fail the filter, then one with matches
+	pages := [][]string{nil, nil, nil, {"p7", "p8"}}
+	requests := 0
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			index := 0
+			if input.ExclusiveStartKey != nil {
+				index, _ = strconv.Atoi(input.ExclusiveStartKey["page"].(*types.AttributeValueMemberS).Value)
+			}
+			requests++
+			output := &dynamodb.QueryOutput{ScannedCount: 10}
+			for _, id := range pages[index] {
+				output.Items = append(output.Items, map[string]types.AttributeValue{
+					"productId": &types.AttributeValueMemberS{Value: id},
+					"category":  &types.AttributeValueMemberS{Value: "electronics"},
+				})
+			}
+			output.Count = int32(len(output.Items))
+			if index+1 < len(pages) {
+				output.LastEvaluatedKey = map[string]types.AttributeValue{"page": &types.AttributeValueMemberS{Value: strconv.Itoa(index + 1)}}
+			}
+			return output, nil
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	query := entity.Query("primary").Query("electronics").
+		Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return attrs["productId"].Begins("p7")
+		})
+
+	items, err := query.Pages()
+	if err != nil {
+		t.Fatalf("Pages failed: %v", err)
+	}
+	if len(items) != 2 || requests != 4 {
+		t.Errorf("Expected Pages to read past empty pages to 2 items, got %d items in %d requests", len(items), requests)
+	}
+
+	requests = 0
+	taken, err := query.Take(1)
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	if len(taken.Data) != 1 || taken.Data[0]["productId"] != "p7" || requests != 4 {
+		t.Errorf("Expected Take to read past empty pages to p7, got %v in %d requests", taken.Data, requests)
+	}
+
+	requests = 0
+	iterator := query.Page()
+	page, hasMore, err := iterator.Next()
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if len(page.Data) != 2 || hasMore || requests != 4 {
+		t.Errorf("Expected Next to skip empty pages, got %d items (hasMore=%v) in %d requests", len(page.Data), hasMore, requests)
+	}
+
+	// MaxPages bounds the requests, returning the empty page reached with its cursor
+	requests = 0
+	page, hasMore, err = query.Page(PagesOptions{MaxPages: 2}).Next()
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if len(page.Data) != 0 || page.Cursor == nil || !hasMore || requests != 2 {
+		t.Errorf("Expected an empty page with a cursor after 2 requests, got %d items (hasMore=%v) in %d requests", len(page.Data), hasMore, requests)
+	}
+}
+
+func TestQueryMaxBytes(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Document",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"folderId": {Type: AttributeTypeString, Required: true},
+			"docId":    {Type: AttributeTypeString, Required: true},
+			"body":     {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"folderId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"docId"}},
+			},
+		},
+	}
+
+	// Ten equally sized large documents, three per page
+	pk := &types.AttributeValueMemberS{Value: "$testservice#folderid_f1"}
+	var partition []map[string]types.AttributeValue
+	for i := 0; i < 10; i++ {
+		partition = append(partition, map[string]types.AttributeValue{
+			"pk":       pk,
+			"sk":       &types.AttributeValueMemberS{Value: fmt.Sprintf("$document_1#docid_%02d", i)},
+			"folderId": &types.AttributeValueMemberS{Value: "f1"},
+			"docId":    &types.AttributeValueMemberS{Value: fmt.Sprintf("%02d", i)},
+			"body":     &types.AttributeValueMemberS{Value: strings.Repeat("x", 10000)},
+		})
+	}
+	queries := 0
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			queries++
+			start := 0
+			if input.ExclusiveStartKey != nil {
+				for i, item := range partition {
+					if item["sk"].(*types.AttributeValueMemberS).Value == input.ExclusiveStartKey["sk"].(*types.AttributeValueMemberS).Value {
+						start = i + 1
+					}
+				}
+			}
+			end := start + 3
+			if end > len(partition) {
+				end = len(partition)
+			}
+			output := &dynamodb.QueryOutput{Items: partition[start:end], Count: int32(end - start)}
+			if end < len(partition) {
+				output.LastEvaluatedKey = map[string]types.AttributeValue{"pk": pk, "sk": partition[end-1]["sk"]}
+			}
+			return output, nil
+		},
+	}
+
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	docIDs := func(result *QueryResponse) string {
+		var ids []interface{}
+		for _, item := range result.Data {
+			ids = append(ids, item["docId"])
+		}
+		return fmt.Sprint(ids)
+	}
+
+	// A budget of four and a half documents returns four of them
+	docSize := itemSize(partition[0])
+	budget := docSize*4 + docSize/2
+	query := entity.Query("primary").Query("f1")
+	result, err := query.Options(&QueryOptions{MaxBytes: &budget}).Go()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if docIDs(result) != "[00 01 02 03]" {
+		t.Errorf("Expected four documents, got %v", docIDs(result))
+	}
+	if queries != 2 {
+		t.Errorf("Expected pagination to stop after 2 pages, got %d", queries)
+	}
+	if result.Cursor == nil || !result.PageInfo.HasMore || result.PageInfo.Count != 4 {
+		t.Fatalf("Expected a cursor and a count of 4, got %+v", result.PageInfo)
+	}
+	if _, ok := result.Data[0]["sk"]; ok {
+		t.Errorf("Expected key fields to be stripped, got %v", result.Data[0])
+	}
+
+	// The cursor resumes right after the last document returned
+	result, err = query.Options(&QueryOptions{MaxBytes: &budget, Cursor: result.Cursor}).Go()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if docIDs(result) != "[04 05 06 07]" {
+		t.Errorf("Expected the next four documents, got %v", docIDs(result))
+	}
+
+	// Pages still caps the pages fetched
+	pages := 1
+	result, err = query.Options(&QueryOptions{MaxBytes: &budget, Pages: &pages}).Go()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if docIDs(result) != "[00 01 02]" || result.Cursor == nil {
+		t.Errorf("Expected one page of documents and a cursor, got %v", docIDs(result))
+	}
+
+	// A single document larger than the budget is still returned
+	small := docSize / 2
+	result, err = query.Options(&QueryOptions{MaxBytes: &small}).Go()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if docIDs(result) != "[00]" || result.Cursor == nil {
+		t.Errorf("Expected the first document and a cursor, got %v", docIDs(result))
+	}
+}
@@ -1,17 +1,20 @@
 package electrodb
 
 import (
+	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/execute008/goelectrodb/electrodb/internal"
 )
 
 // ParamsBuilder builds DynamoDB operation parameters
 type ParamsBuilder struct {
-	entity *Entity
+	entity     *Entity
+	ctx        context.Context // passed to DefaultFromContext; context.Background() when nil
+	identifier *KeyIdentity    // per-call override of the sort key prefix identity
 }
 
 // NewParamsBuilder creates a new ParamsBuilder
@@ -19,8 +22,20 @@ func NewParamsBuilder(entity *Entity) *ParamsBuilder {
 	return &ParamsBuilder{entity: entity}
 }
 
+// withContext sets the context defaults are evaluated with
+func (pb *ParamsBuilder) withContext(ctx context.Context) *ParamsBuilder {
+	pb.ctx = ctx
+	return pb
+}
+
 // BuildGetItemParams builds parameters for GetItem operation
 func (pb *ParamsBuilder) BuildGetItemParams(keys Keys, options *GetOptions) (map[string]interface{}, error) {
+	if options != nil && options.IdentifierOverride != nil {
+		override := *pb
+		override.identifier = options.IdentifierOverride
+		pb = &override
+	}
+
 	// Find the primary index (the one without an Index field set)
 	var primaryIndex *IndexDefinition
 	for _, index := range pb.entity.schema.Indexes {
@@ -68,16 +83,17 @@ func (pb *ParamsBuilder) BuildGetItemParams(keys Keys, options *GetOptions) (map
 		"Key":       keyMap,
 	}
 
+	var consistent *bool
+	if options != nil {
+		consistent = options.Consistent
+	}
+	if pb.consistentRead(consistent) {
+		params["ConsistentRead"] = true
+	}
+
 	// Add projection expression if attributes are specified
 	if options != nil && len(options.Attributes) > 0 {
-		projectionExpression := ""
-		for i, attr := range options.Attributes {
-			if i > 0 {
-				projectionExpression += ", "
-			}
-			projectionExpression += attr
-		}
-		params["ProjectionExpression"] = projectionExpression
+		params["ProjectionExpression"], params["ExpressionAttributeNames"] = pb.projection(primaryIndex, options.Attributes)
 	}
 
 	return params, nil
@@ -85,21 +101,63 @@ func (pb *ParamsBuilder) BuildGetItemParams(keys Keys, options *GetOptions) (map
 
 // BuildPutItemParams builds parameters for PutItem operation
 func (pb *ParamsBuilder) BuildPutItemParams(item Item, options *PutOptions) (map[string]interface{}, error) {
+	var skipIndexes []string
+	if options != nil {
+		skipIndexes = options.SkipIndexes
+	}
+	av, err := pb.buildPutItem(item, skipIndexes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject items DynamoDB would refuse, before sending them
+	if pb.entity.config.EnforceItemSize {
+		if size := itemSize(av); size > MaxItemSize {
+			return nil, NewElectroError("ItemTooLarge", fmt.Sprintf("Item size of %d bytes exceeds the maximum of %d bytes", size, MaxItemSize), nil)
+		}
+	}
+
+	params := map[string]interface{}{
+		"TableName": pb.getTableName(),
+		"Item":      av,
+	}
+
+	// Add return values if specified
+	if options != nil && options.Response != nil {
+		params["ReturnValues"] = *options.Response
+	}
+
+	return params, nil
+}
+
+// buildPutItem runs the write pipeline on an item and marshals it with its
+// composed keys, leaving out the keys of skipIndexes
+func (pb *ParamsBuilder) buildPutItem(item Item, skipIndexes []string) (map[string]types.AttributeValue, error) {
+	if err := NewValidator(pb.entity).checkNoWrite(item); err != nil {
+		return nil, err
+	}
+
+	// Apply defaults and generated IDs, so Required is checked after them
+	enrichedItem, err := pb.applyDefaults(item)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate required attributes
-	if err := pb.validateRequiredAttributes(item); err != nil {
+	if err := pb.validateRequiredAttributes(enrichedItem); err != nil {
 		return nil, err
 	}
 
-	// Apply defaults
-	enrichedItem := pb.applyDefaults(item)
+	// Normalize facet values before they are stored or composed into keys
+	enrichedItem = ApplyNormalization(enrichedItem, pb.entity.schema)
 
 	// Apply automatic timestamps
-	enrichedItem = ApplyTimestamps(enrichedItem, pb.entity.schema, false)
-
-	// Apply attribute padding
-	enrichedItem = ApplyPadding(enrichedItem, pb.entity.schema)
+	enrichedItem = applyTimestampsAt(enrichedItem, pb.entity.schema, false, pb.entity.now())
+	if err := checkTTLValue(pb.entity.schema, enrichedItem); err != nil {
+		return nil, err
+	}
 
-	// Validate and transform for write (validation, enum, Set transforms, readonly checks)
+	// Validate and transform for write (validation, enum, padding, Set transforms, readonly checks)
 	validator := NewValidator(pb.entity)
 	transformedItem, err := validator.ValidateAndTransformForWrite(enrichedItem, false)
 	if err != nil {
@@ -107,28 +165,28 @@ func (pb *ParamsBuilder) BuildPutItemParams(item Item, options *PutOptions) (map
 	}
 
 	// Add keys to the item
-	transformedItem, err = pb.addKeysToItem(transformedItem)
+	transformedItem, err = pb.addKeysToItem(transformedItem, skipIndexes)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert to DynamoDB format
-	av, err := attributevalue.MarshalMap(transformedItem)
+	// Compress attributes after keys are composed from their plain values
+	transformedItem, err = compressAttributes(transformedItem, pb.entity.schema)
 	if err != nil {
-		return nil, NewElectroError("MarshalError", "Failed to marshal item", err)
+		return nil, err
 	}
-
-	params := map[string]interface{}{
-		"TableName": pb.getTableName(),
-		"Item":      av,
+	transformedItem, err = encryptAttributes(transformedItem, pb.entity.schema, pb.entity.config.Encryptor)
+	if err != nil {
+		return nil, err
 	}
 
-	// Add return values if specified
-	if options != nil && options.Response != nil {
-		params["ReturnValues"] = *options.Response
+	// Convert to DynamoDB format
+	av, err := marshalItem(transformedItem, pb.entity.timeFormat())
+	if err != nil {
+		return nil, NewElectroError("MarshalError", "Failed to marshal item", err)
 	}
 
-	return params, nil
+	return av, nil
 }
 
 // BuildUpdateItemParams builds parameters for UpdateItem operation
@@ -144,25 +202,82 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 	dataOps map[string]interface{},
 	options *UpdateOptions,
 ) (map[string]interface{}, error) {
+	remValues := make(map[string]interface{}, len(remOps))
+	for _, name := range remOps {
+		remValues[name] = nil
+	}
+	if err := NewValidator(pb.entity).checkNoWrite(setOps, addOps, delOps, remValues, appendOps, prependOps, subtractOps, dataOps); err != nil {
+		return nil, err
+	}
+
 	// Build key first
 	getParams, err := pb.BuildGetItemParams(keys, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	// A nil SET value stores NULL unless nil is asked to mean remove
+	if options != nil && options.NilMeansRemove {
+		setOps, remOps = nilSetsToRemoves(setOps, remOps)
+	}
+
+	// Setting a key facet would leave the stored keys stale
+	if err := pb.checkFacetMutations(keys, setOps, options != nil && options.RecomputeKeys); err != nil {
+		return nil, err
+	}
+
 	// Apply automatic timestamps to update operations
-	setOps = ApplyUpdateTimestamps(setOps, pb.entity.schema)
+	setOps = applyUpdateTimestampsAt(setOps, pb.entity.schema, pb.entity.now())
+	if err := checkTTLValue(pb.entity.schema, setOps); err != nil {
+		return nil, err
+	}
 
-	// Validate update operations (readonly checks)
+	if len(setOps) > 0 {
+		setOps = ApplyNormalization(setOps, pb.entity.schema)
+	}
+
+	// Validate update operations (readonly checks, constraints on the values
+	// as supplied)
 	validator := NewValidator(pb.entity)
 	if err := validator.ValidateUpdateOperations(setOps, addOps, delOps, remOps); err != nil {
 		return nil, err
 	}
 
+	// Pad SET values the same way as on put, so padded attributes keep sorting
+	// as strings and are unpadded again on read
+	if len(setOps) > 0 {
+		setOps = ApplyPadding(setOps, pb.entity.schema)
+	}
+
 	// Apply transformations and validations
 	setOps, addOps, delOps = validator.ApplySetTransformations(setOps, addOps, delOps)
 
-	// Build update expression
+	// ADD and DELETE need set attributes holding a single element type
+	if err := validator.checkSetOperations(addOps, delOps); err != nil {
+		return nil, err
+	}
+
+	// Keep secondary index keys in step with any facets being set
+	if options != nil && options.RecomputeKeys {
+		setOps, err = pb.recomputeIndexKeys(keys, setOps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(setOps) > 0 {
+		setOps, err = compressAttributes(setOps, pb.entity.schema)
+		if err != nil {
+			return nil, err
+		}
+		setOps, err = encryptAttributes(setOps, pb.entity.schema, pb.entity.config.Encryptor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Build update expression; each clause lists its attributes in name order
+	// so the same operations always produce the same expression
 	updateExpr := ""
 	exprAttrNames := make(map[string]string)
 	exprAttrValues := make(map[string]types.AttributeValue)
@@ -172,7 +287,8 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 	if len(setOps) > 0 {
 		updateExpr += "SET "
 		first := true
-		for attr, value := range setOps {
+		for _, attr := range sortedKeys(setOps) {
+			value := setOps[attr]
 			if !first {
 				updateExpr += ", "
 			}
@@ -185,7 +301,7 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 			updateExpr += fmt.Sprintf("%s = %s", attrName, valueName)
 			exprAttrNames[attrName] = attr
 
-			av, err := attributevalue.Marshal(value)
+			av, err := marshalAttribute(value, pb.entity.timeFormat())
 			if err != nil {
 				return nil, NewElectroError("MarshalError", "Failed to marshal value", err)
 			}
@@ -200,7 +316,8 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 		}
 		updateExpr += "ADD "
 		first := true
-		for attr, value := range addOps {
+		for _, attr := range sortedKeys(addOps) {
+			value := addOps[attr]
 			if !first {
 				updateExpr += ", "
 			}
@@ -213,7 +330,7 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 			updateExpr += fmt.Sprintf("%s %s", attrName, valueName)
 			exprAttrNames[attrName] = attr
 
-			av, err := attributevalue.Marshal(value)
+			av, err := marshalAttribute(value, pb.entity.timeFormat())
 			if err != nil {
 				return nil, NewElectroError("MarshalError", "Failed to marshal value", err)
 			}
@@ -228,7 +345,8 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 		}
 		updateExpr += "DELETE "
 		first := true
-		for attr, value := range delOps {
+		for _, attr := range sortedKeys(delOps) {
+			value := delOps[attr]
 			if !first {
 				updateExpr += ", "
 			}
@@ -241,7 +359,7 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 			updateExpr += fmt.Sprintf("%s %s", attrName, valueName)
 			exprAttrNames[attrName] = attr
 
-			av, err := attributevalue.Marshal(value)
+			av, err := marshalAttribute(value, pb.entity.timeFormat())
 			if err != nil {
 				return nil, NewElectroError("MarshalError", "Failed to marshal value", err)
 			}
@@ -272,7 +390,8 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 
 	// Handle APPEND operations (using list_append in SET clause)
 	if len(appendOps) > 0 {
-		for attr, value := range appendOps {
+		for _, attr := range sortedKeys(appendOps) {
+			value := appendOps[attr]
 			if updateExpr == "" {
 				updateExpr = "SET "
 			} else if !contains(updateExpr, "SET") {
@@ -289,7 +408,7 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 			updateExpr += fmt.Sprintf("%s = list_append(%s, %s)", attrName, attrName, valueName)
 			exprAttrNames[attrName] = attr
 
-			av, err := attributevalue.Marshal(value)
+			av, err := marshalAttribute(value, pb.entity.timeFormat())
 			if err != nil {
 				return nil, NewElectroError("MarshalError", "Failed to marshal value", err)
 			}
@@ -299,7 +418,8 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 
 	// Handle PREPEND operations (using list_append in SET clause with reversed order)
 	if len(prependOps) > 0 {
-		for attr, value := range prependOps {
+		for _, attr := range sortedKeys(prependOps) {
+			value := prependOps[attr]
 			if updateExpr == "" {
 				updateExpr = "SET "
 			} else if !contains(updateExpr, "SET") {
@@ -316,7 +436,7 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 			updateExpr += fmt.Sprintf("%s = list_append(%s, %s)", attrName, valueName, attrName)
 			exprAttrNames[attrName] = attr
 
-			av, err := attributevalue.Marshal(value)
+			av, err := marshalAttribute(value, pb.entity.timeFormat())
 			if err != nil {
 				return nil, NewElectroError("MarshalError", "Failed to marshal value", err)
 			}
@@ -326,7 +446,8 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 
 	// Handle SUBTRACT operations (using subtraction in SET clause)
 	if len(subtractOps) > 0 {
-		for attr, value := range subtractOps {
+		for _, attr := range sortedKeys(subtractOps) {
+			value := subtractOps[attr]
 			if updateExpr == "" {
 				updateExpr = "SET "
 			} else if !contains(updateExpr, "SET") {
@@ -343,7 +464,7 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 			updateExpr += fmt.Sprintf("%s = %s - %s", attrName, attrName, valueName)
 			exprAttrNames[attrName] = attr
 
-			av, err := attributevalue.Marshal(value)
+			av, err := marshalAttribute(value, pb.entity.timeFormat())
 			if err != nil {
 				return nil, NewElectroError("MarshalError", "Failed to marshal value", err)
 			}
@@ -354,7 +475,8 @@ func (pb *ParamsBuilder) BuildUpdateItemParams(
 	// Handle DATA operations (for removing specific list indices)
 	// This uses REMOVE with indexed paths like attribute[0], attribute[1]
 	if len(dataOps) > 0 {
-		for attr, indices := range dataOps {
+		for _, attr := range sortedKeys(dataOps) {
+			indices := dataOps[attr]
 			if indexList, ok := indices.([]int); ok {
 				for _, index := range indexList {
 					if updateExpr != "" && !contains(updateExpr, "REMOVE") {
@@ -413,6 +535,24 @@ func (pb *ParamsBuilder) BuildDeleteItemParams(keys Keys, options *DeleteOptions
 	return params, nil
 }
 
+// BuildScanParams builds parameters for Scan operation
+func (pb *ParamsBuilder) BuildScanParams(options *QueryOptions) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"TableName": pb.getTableName(),
+	}
+
+	if options != nil {
+		if options.Limit != nil {
+			params["Limit"] = *options.Limit
+		}
+		if options.Cursor != nil {
+			params["ExclusiveStartKey"] = *options.Cursor
+		}
+	}
+
+	return params, nil
+}
+
 // BuildQueryParams builds parameters for Query operation
 func (pb *ParamsBuilder) BuildQueryParams(
 	indexName string,
@@ -445,84 +585,110 @@ func (pb *ParamsBuilder) BuildQueryParams(
 		return nil, NewElectroError("InvalidKeys", "Partition key facets not fully provided", nil)
 	}
 
+	// Names from the key condition, projection and filter accumulate in one
+	// map, so placeholders from each part coexist
+	exprAttrNames := make(map[string]string)
+
 	// Build key condition expression
-	keyCondition := fmt.Sprintf("%s = :pk", index.PK.Field)
+	keyCondition := fmt.Sprintf("%s = :pk", keyFieldRef(exprAttrNames, "#pk", index.PK.Field))
 	exprAttrValues := map[string]types.AttributeValue{
 		":pk": &types.AttributeValueMemberS{Value: pkKey.Key},
 	}
 
 	// Add sort key condition if provided, OR add entity prefix filter if SK exists
 	if index.SK != nil {
-		skField := index.SK.Field
+		skField := keyFieldRef(exprAttrNames, "#sk", index.SK.Field)
 		if skCondition != nil {
 			// Explicit SK condition provided (e.g., .Begins(), .Eq(), etc.)
+			// Keys operands are composed against the SK facets; others are used verbatim
+			operands := make([]string, len(skCondition.values))
+			for i, value := range skCondition.values {
+				operand, err := pb.composeSortKeyOperand(*index.SK, skFacets, value, !skCondition.prefix)
+				if err != nil {
+					return nil, err
+				}
+				operands[i] = operand
+			}
+
 			switch skCondition.operation {
 			case "=":
 				keyCondition += fmt.Sprintf(" AND %s = :sk", skField)
 				exprAttrValues[":sk"] = &types.AttributeValueMemberS{
-					Value: fmt.Sprintf("%v", skCondition.values[0]),
+					Value: operands[0],
 				}
 			case ">":
 				keyCondition += fmt.Sprintf(" AND %s > :sk", skField)
 				exprAttrValues[":sk"] = &types.AttributeValueMemberS{
-					Value: fmt.Sprintf("%v", skCondition.values[0]),
+					Value: operands[0],
 				}
 			case ">=":
 				keyCondition += fmt.Sprintf(" AND %s >= :sk", skField)
 				exprAttrValues[":sk"] = &types.AttributeValueMemberS{
-					Value: fmt.Sprintf("%v", skCondition.values[0]),
+					Value: operands[0],
 				}
 			case "<":
 				keyCondition += fmt.Sprintf(" AND %s < :sk", skField)
 				exprAttrValues[":sk"] = &types.AttributeValueMemberS{
-					Value: fmt.Sprintf("%v", skCondition.values[0]),
+					Value: operands[0],
 				}
 			case "<=":
 				keyCondition += fmt.Sprintf(" AND %s <= :sk", skField)
 				exprAttrValues[":sk"] = &types.AttributeValueMemberS{
-					Value: fmt.Sprintf("%v", skCondition.values[0]),
+					Value: operands[0],
 				}
 			case "BETWEEN":
 				keyCondition += fmt.Sprintf(" AND %s BETWEEN :sk1 AND :sk2", skField)
 				exprAttrValues[":sk1"] = &types.AttributeValueMemberS{
-					Value: fmt.Sprintf("%v", skCondition.values[0]),
+					Value: operands[0],
 				}
 				exprAttrValues[":sk2"] = &types.AttributeValueMemberS{
-					Value: fmt.Sprintf("%v", skCondition.values[1]),
+					Value: operands[1],
 				}
 			case "begins_with":
 				keyCondition += fmt.Sprintf(" AND begins_with(%s, :sk)", skField)
 				exprAttrValues[":sk"] = &types.AttributeValueMemberS{
-					Value: fmt.Sprintf("%v", skCondition.values[0]),
+					Value: operands[0],
 				}
 			}
 		} else if len(skFacets) > 0 {
 			// SK facets provided in Query() - build begins_with prefix like JS ElectroDB
 			// Example: .Query("byApp").Query(appId, "published") where "published" is status
 			// Builds: begins_with(gsi1sk, "$contentitem_1#status_published")
-			skPrefix := internal.BuildSortKeyPrefix(pb.entity.schema.Entity, pb.entity.schema.Version)
+			delimiters := pb.entity.keyDelimiters()
+			skPrefix := pb.sortKeyPrefix()
 
 			// Add each provided SK facet to the prefix
 			for i, facetValue := range skFacets {
 				if i < len(index.SK.Facets) {
+					facetValue = pb.entity.schema.normalizeFacet(index.SK.Facets[i], facetValue)
+					if fmt.Sprintf("%v", facetValue) == "" {
+						return nil, NewElectroError("EmptyFacetValue",
+							fmt.Sprintf("Facet '%s' cannot be an empty string", index.SK.Facets[i]), nil)
+					}
+					if err := checkFacetDelimiters(index.SK.Facets[i:i+1], map[string]interface{}{index.SK.Facets[i]: facetValue}, delimiters); err != nil {
+						return nil, err
+					}
+					if padding := pb.entity.schema.paddingFor(index.SK.Facets[i]); padding != nil {
+						facetValue = padValue(facetValue, padding)
+					}
 					facetName := strings.ToLower(index.SK.Facets[i])
-					facetVal := strings.ToLower(fmt.Sprintf("%v", facetValue))
-					skPrefix = fmt.Sprintf("%s#%s_%s", skPrefix, facetName, facetVal)
+					facetVal := internal.EscapeValue(strings.ToLower(fmt.Sprintf("%v", facetValue)), delimiters)
+					skPrefix += delimiters.LabelMarker(facetName) + facetVal
 				}
 			}
 
 			keyCondition += fmt.Sprintf(" AND begins_with(%s, :sk)", skField)
 			exprAttrValues[":sk"] = &types.AttributeValueMemberS{Value: skPrefix}
-		} else {
+		} else if options == nil || !options.NoEntityFilter {
 			// No explicit SK condition and no SK facets - add entity prefix to filter by entity type
 			// This is critical for single-table design where multiple entities share the same PK
 			// TypeScript ElectroDB format: $<entity>_<version>#<firstFacetLabel>_
 			// Example: $contentlike_1#likeid_
-			skPrefix := internal.BuildSortKeyPrefix(pb.entity.schema.Entity, pb.entity.schema.Version)
+			delimiters := pb.entity.keyDelimiters()
+			skPrefix := pb.sortKeyPrefix()
 			// Add the first SK facet label to match TypeScript ElectroDB format
 			if len(index.SK.Facets) > 0 {
-				firstFacet := strings.ToLower(index.SK.Facets[0])
-				skPrefix = fmt.Sprintf("%s#%s_", skPrefix, firstFacet)
+				skPrefix += delimiters.LabelMarker(strings.ToLower(index.SK.Facets[0]))
 			}
 			keyCondition += fmt.Sprintf(" AND begins_with(%s, :sk)", skField)
 			exprAttrValues[":sk"] = &types.AttributeValueMemberS{Value: skPrefix}
@@ -540,6 +706,20 @@ func (pb *ParamsBuilder) BuildQueryParams(
 		params["IndexName"] = *index.Index
 	}
 
+	// Consistent reads default from config on the primary index only;
+	// DynamoDB rejects them on global secondary indexes
+	var consistent *bool
+	if options != nil {
+		consistent = options.Consistent
+	}
+	if index.Index != nil {
+		if consistent != nil && *consistent {
+			return nil, NewElectroError("InvalidOperation", fmt.Sprintf("Consistent reads are not supported on global secondary index '%s'", indexName), nil)
+		}
+	} else if pb.consistentRead(consistent) {
+		params["ConsistentRead"] = true
+	}
+
 	// Add options
 	if options != nil {
 		if options.Limit != nil {
@@ -548,6 +728,13 @@ func (pb *ParamsBuilder) BuildQueryParams(
 		if options.Order != nil && *options.Order == "desc" {
 			params["ScanIndexForward"] = false
 		}
+		if len(options.Attributes) > 0 {
+			projection, names := pb.projection(index, options.Attributes)
+			params["ProjectionExpression"] = projection
+			if err := mergeNames(exprAttrNames, names); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Add filter expression if provided
@@ -555,61 +742,304 @@ func (pb *ParamsBuilder) BuildQueryParams(
 		filterExpr, filterNames, filterValues := filterBuilder.Build()
 		if filterExpr != "" {
 			params["FilterExpression"] = filterExpr
-
-			// Merge expression attribute names and values
-			existingNames := make(map[string]string)
-			if params["ExpressionAttributeNames"] != nil {
-				existingNames = params["ExpressionAttributeNames"].(map[string]string)
+			if err := mergeNames(exprAttrNames, filterNames); err != nil {
+				return nil, err
 			}
-
-			existingValues := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
-
-			mergedNames, mergedValues := MergeExpressionAttributes(
-				existingNames,
-				existingValues,
-				filterNames,
-				filterValues,
-			)
-
-			if len(mergedNames) > 0 {
-				params["ExpressionAttributeNames"] = mergedNames
+			for placeholder, value := range filterValues {
+				exprAttrValues[placeholder] = value
 			}
-			params["ExpressionAttributeValues"] = mergedValues
 		}
 	}
 
+	if len(exprAttrNames) > 0 {
+		params["ExpressionAttributeNames"] = exprAttrNames
+	}
+
 	return params, nil
 }
 
 // Helper methods
 
+// nilSetsToRemoves moves nil-valued SET entries to the REMOVE list, in name
+// order and without repeating attributes already being removed
+func nilSetsToRemoves(setOps map[string]interface{}, remOps []string) (map[string]interface{}, []string) {
+	removing := make(map[string]bool, len(remOps))
+	for _, name := range remOps {
+		removing[name] = true
+	}
+
+	sets := make(map[string]interface{}, len(setOps))
+	removes := append([]string{}, remOps...)
+	for _, name := range sortedKeys(setOps) {
+		value := setOps[name]
+		if value != nil {
+			sets[name] = value
+			continue
+		}
+		if !removing[name] {
+			removes = append(removes, name)
+			removing[name] = true
+		}
+	}
+	return sets, removes
+}
+
+// consistentRead resolves a per-call consistency override against the
+// entity's ConsistentRead default
+func (pb *ParamsBuilder) consistentRead(override *bool) bool {
+	if override != nil {
+		return *override
+	}
+	return pb.entity.config.ConsistentRead
+}
+
+// keyFieldRef returns how a key condition refers to a key field: the field
+// itself, or placeholder when the field is a reserved word or not a plain
+// identifier, in which case the placeholder is added to names
+func keyFieldRef(names map[string]string, placeholder, field string) string {
+	if !reservedWords[strings.ToLower(field)] && plainIdentifier.MatchString(field) {
+		return field
+	}
+	names[placeholder] = field
+	return placeholder
+}
+
+// plainIdentifier matches attribute names usable unquoted in expressions
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// mergeNames adds expression attribute names to dst, rejecting a placeholder
+// already bound to a different name instead of silently overwriting it
+func mergeNames(dst, src map[string]string) error {
+	for placeholder, name := range src {
+		if existing, ok := dst[placeholder]; ok && existing != name {
+			return NewElectroError("InvalidOperation",
+				fmt.Sprintf("Expression placeholder '%s' refers to both '%s' and '%s'", placeholder, existing, name), nil)
+		}
+		dst[placeholder] = name
+	}
+	return nil
+}
+
+// projection builds a ProjectionExpression for the requested attributes. The
+// key fields and facet attributes of the queried and primary indexes are
+// always added, so cursors and composite values survive a narrow projection.
+func (pb *ParamsBuilder) projection(index *IndexDefinition, attributes []string) (string, map[string]string) {
+	fields := append([]string{}, attributes...)
+	for _, idx := range []*IndexDefinition{index, pb.entity.primaryIndex()} {
+		if idx == nil {
+			continue
+		}
+		fields = append(fields, idx.PK.Field)
+		fields = append(fields, idx.PK.Facets...)
+		if idx.SK != nil {
+			fields = append(fields, idx.SK.Field)
+			fields = append(fields, idx.SK.Facets...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	names := make(map[string]string)
+	placeholders := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field == "" || seen[field] {
+			continue
+		}
+		seen[field] = true
+		placeholder := fmt.Sprintf("#proj%d", len(placeholders))
+		names[placeholder] = field
+		placeholders = append(placeholders, placeholder)
+	}
+	return strings.Join(placeholders, ", "), names
+}
+
+// applyCondition adds a condition's expression, names and values to params
+func applyCondition(params map[string]interface{}, cb *ConditionBuilder) {
+	if cb == nil {
+		return
+	}
+	expr, names, values := cb.Build()
+	if expr == "" {
+		return
+	}
+	params["ConditionExpression"] = expr
+
+	existingNames, _ := params["ExpressionAttributeNames"].(map[string]string)
+	if existingNames == nil {
+		existingNames = make(map[string]string)
+	}
+	existingValues, _ := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if existingValues == nil {
+		existingValues = make(map[string]types.AttributeValue)
+	}
+	params["ExpressionAttributeNames"], params["ExpressionAttributeValues"] = MergeExpressionAttributes(existingNames, existingValues, names, values)
+}
+
+// sortKeyPrefix returns the $<entity>_<version> sort key prefix, with any
+// per-call identifier override applied
+func (pb *ParamsBuilder) sortKeyPrefix() string {
+	entity, version := pb.entity.schema.Entity, pb.entity.schema.Version
+	if pb.identifier != nil {
+		if pb.identifier.Entity != "" {
+			entity = pb.identifier.Entity
+		}
+		if pb.identifier.Version != "" {
+			version = pb.identifier.Version
+		}
+	}
+	return pb.entity.keyDelimiters().SortKeyPrefix(entity, version)
+}
+
 func (pb *ParamsBuilder) buildKey(facetDef FacetDefinition, supplied map[string]interface{}) (internal.KeyResult, error) {
 	return pb.buildKeyWithType(facetDef, supplied, false)
 }
 
 func (pb *ParamsBuilder) buildKeyWithType(facetDef FacetDefinition, supplied map[string]interface{}, isSortKey bool) (internal.KeyResult, error) {
+	template := internal.NewKeyTemplate(facetDef.Facets, facetDef.Casing, pb.entity.keyDelimiters())
+	return pb.composeKey(template, facetDef.Facets, supplied, isSortKey)
+}
+
+// composeKey builds a key from a precomputed template. Only the key's facets
+// are read from supplied.
+func (pb *ParamsBuilder) composeKey(template *internal.KeyTemplate, facets []string, supplied map[string]interface{}, isSortKey bool) (internal.KeyResult, error) {
 	var prefix string
 	if isSortKey {
 		// SK prefix: $<entity>_<version>
-		prefix = internal.BuildSortKeyPrefix(pb.entity.schema.Entity, pb.entity.schema.Version)
+		prefix = pb.sortKeyPrefix()
 	} else {
 		// PK prefix: $<service>
-		prefix = internal.BuildPartitionKeyPrefix(pb.entity.schema.Service)
+		prefix = pb.entity.keyDelimiters().PartitionKeyPrefix(pb.entity.schema.Service)
 	}
 
-	labels := internal.BuildLabels(facetDef.Facets)
+	// Normalize and pad facet values so keys built from lookups match keys
+	// built on write
+	values := make(map[string]interface{}, len(facets))
+	for _, facet := range facets {
+		if value, ok := supplied[facet]; ok {
+			values[facet] = pb.entity.schema.normalizeFacet(facet, value)
+		}
+	}
+	if err := checkEmptyFacets(facets, values); err != nil {
+		return internal.KeyResult{}, err
+	}
+	if err := checkFacetDelimiters(facets, values, pb.entity.keyDelimiters()); err != nil {
+		return internal.KeyResult{}, err
+	}
+	for _, facet := range facets {
+		if padding := pb.entity.schema.paddingFor(facet); padding != nil {
+			if value, ok := values[facet]; ok {
+				values[facet] = padValue(value, padding)
+			}
+		}
+	}
 
+	return template.Make(prefix, values), nil
+}
+
+// composeSortKeyOperand turns a sort key condition operand into a key value.
+// Keys operands are merged over the facets already supplied to the query and
+// composed into a (possibly partial) sort key that stops at the first facet
+// not supplied, so a condition can target a leading subset of a composite SK.
+// Facets are normalized and padded as on write; padOperand false leaves the
+// operand's own facets unpadded, for value prefixes. Any other operand is
+// formatted verbatim.
+func (pb *ParamsBuilder) composeSortKeyOperand(facetDef FacetDefinition, skFacets []interface{}, operand interface{}, padOperand bool) (string, error) {
+	var keys map[string]interface{}
+	switch v := operand.(type) {
+	case Keys:
+		keys = v
+	case map[string]interface{}:
+		keys = v
+	default:
+		return fmt.Sprintf("%v", operand), nil
+	}
+
+	supplied := make(map[string]interface{})
+	for i, facetValue := range skFacets {
+		if i < len(facetDef.Facets) {
+			supplied[facetDef.Facets[i]] = facetValue
+		}
+	}
+	for name, value := range keys {
+		supplied[name] = value
+	}
+	for name, value := range supplied {
+		supplied[name] = pb.entity.schema.normalizeFacet(name, value)
+	}
+	if err := checkContiguousFacets(facetDef.Facets, supplied); err != nil {
+		return "", err
+	}
+	if err := checkEmptyFacets(facetDef.Facets, supplied); err != nil {
+		return "", err
+	}
+	if err := checkFacetDelimiters(facetDef.Facets, supplied, pb.entity.keyDelimiters()); err != nil {
+		return "", err
+	}
+	for name, value := range supplied {
+		if _, inOperand := keys[name]; inOperand && !padOperand {
+			continue
+		}
+		if padding := pb.entity.schema.paddingFor(name); padding != nil {
+			supplied[name] = padValue(value, padding)
+		}
+	}
+
+	delimiters := pb.entity.keyDelimiters()
 	options := internal.KeyOptions{
-		Prefix:           prefix,
-		IsCustom:         false,
-		ExcludeLabelTail: false,
+		Prefix:           pb.sortKeyPrefix(),
+		ExcludeLabelTail: true,
+		Casing:           facetDef.Casing,
+		Delimiters:       delimiters,
+	}
+
+	return internal.MakeKey(options, facetDef.Facets, supplied, internal.BuildLabels(facetDef.Facets)).Key, nil
+}
+
+// checkContiguousFacets requires the supplied facets to form a leading prefix
+// of facets, since a composite key cannot skip a facet and continue
+func checkContiguousFacets(facets []string, supplied map[string]interface{}) error {
+	missing := ""
+	for _, facet := range facets {
+		if _, exists := supplied[facet]; !exists {
+			if missing == "" {
+				missing = facet
+			}
+			continue
+		}
+		if missing != "" {
+			return NewElectroError("NonContiguousFacets",
+				fmt.Sprintf("Facet '%s' was supplied without the preceding facet '%s'; sort key facets %v must be supplied as a contiguous prefix", facet, missing, facets), nil)
+		}
 	}
+	return nil
+}
 
-	if facetDef.Casing != nil {
-		options.Casing = facetDef.Casing
+// checkEmptyFacets rejects empty string facet values, which would compose
+// an ambiguous key such as "#name_" that cannot be told apart from a key
+// missing the facet
+func checkEmptyFacets(facets []string, supplied map[string]interface{}) error {
+	for _, facet := range facets {
+		if value, exists := supplied[facet]; exists && fmt.Sprintf("%v", value) == "" {
+			return NewElectroError("EmptyFacetValue",
+				fmt.Sprintf("Facet '%s' cannot be an empty string", facet), nil)
+		}
 	}
+	return nil
+}
 
-	return internal.MakeKey(options, facetDef.Facets, supplied, labels), nil
+// checkFacetDelimiters rejects facet values containing the facet delimiter
+// when escaping is off, since such a value could compose the same key as
+// other facets or be split apart when the key is parsed
+func checkFacetDelimiters(facets []string, supplied map[string]interface{}, delimiters internal.Delimiters) error {
+	if delimiters.Escape {
+		return nil
+	}
+	for _, facet := range facets {
+		if value, exists := supplied[facet]; exists && strings.Contains(fmt.Sprintf("%v", value), delimiters.Facet) {
+			return NewElectroError("InvalidFacetValue",
+				fmt.Sprintf("Facet '%s' cannot contain the key delimiter %q unless Delimiters.Escape is set", facet, delimiters.Facet), nil)
+		}
+	}
+	return nil
 }
 
 func (pb *ParamsBuilder) getTableName() string {
@@ -631,31 +1061,69 @@ func (pb *ParamsBuilder) validateRequiredAttributes(item Item) error {
 	return nil
 }
 
-func (pb *ParamsBuilder) applyDefaults(item Item) Item {
+func (pb *ParamsBuilder) applyDefaults(item Item) (Item, error) {
 	result := make(Item)
 	for k, v := range item {
 		result[k] = v
 	}
 
+	ctx := pb.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	for name, attr := range pb.entity.schema.Attributes {
-		if _, exists := result[name]; !exists && attr.Default != nil {
+		if _, exists := result[name]; exists {
+			continue
+		}
+		if attr.DefaultFromContext != nil {
+			result[name] = attr.DefaultFromContext(ctx)
+		} else if attr.Default != nil {
 			result[name] = attr.Default()
+		} else if attr.Generate != "" {
+			id, err := generateID(attr.Generate, pb.entity.now())
+			if err != nil {
+				return nil, NewElectroError("GenerationError",
+					fmt.Sprintf("Failed to generate a value for '%s'", name), err)
+			}
+			result[name] = id
 		}
 	}
 
-	return result
+	return result, nil
 }
 
-func (pb *ParamsBuilder) addKeysToItem(item Item) (Item, error) {
+func (pb *ParamsBuilder) addKeysToItem(item Item, skipIndexes []string) (Item, error) {
+	skip := make(map[string]bool, len(skipIndexes))
+	for _, indexName := range skipIndexes {
+		index, exists := pb.entity.schema.Indexes[indexName]
+		if !exists {
+			return nil, NewElectroError("InvalidIndex", fmt.Sprintf("Index '%s' not found", indexName), nil)
+		}
+		if index.Index == nil {
+			return nil, NewElectroError("InvalidOperation",
+				fmt.Sprintf("The primary index '%s' cannot be skipped", indexName), nil)
+		}
+		skip[indexName] = true
+	}
+
 	result := make(Item)
 	for k, v := range item {
 		result[k] = v
 	}
 
 	// Add keys for all indexes
-	for _, index := range pb.entity.schema.Indexes {
+	for indexName, index := range pb.entity.schema.Indexes {
+		if skip[indexName] {
+			continue
+		}
+
+		templates, ok := pb.entity.keyTemplates[indexName]
+		if !ok {
+			templates = pb.entity.newIndexKeyTemplates(index)
+		}
+
 		// Build partition key
-		pkKey, err := pb.buildKey(index.PK, item)
+		pkKey, err := pb.composeKey(templates.pk, index.PK.Facets, item, false)
 		if err != nil {
 			return nil, err
 		}
@@ -663,7 +1131,7 @@ func (pb *ParamsBuilder) addKeysToItem(item Item) (Item, error) {
 
 		// Build sort key if it exists
 		if index.SK != nil {
-			skKey, err := pb.buildKeyWithType(*index.SK, item, true)
+			skKey, err := pb.composeKey(templates.sk, index.SK.Facets, item, true)
 			if err != nil {
 				return nil, err
 			}
@@ -676,6 +1144,103 @@ func (pb *ParamsBuilder) addKeysToItem(item Item) (Item, error) {
 	return result, nil
 }
 
+// recomputeIndexKeys adds SET values for every secondary index key field whose
+// facets appear in setOps, composing the key from setOps and the item's keys.
+// All of a key's facets must be available, otherwise the stored key would be
+// truncated.
+func (pb *ParamsBuilder) recomputeIndexKeys(keys Keys, setOps map[string]interface{}) (map[string]interface{}, error) {
+	if len(setOps) == 0 {
+		return setOps, nil
+	}
+
+	supplied := make(map[string]interface{}, len(keys)+len(setOps))
+	for k, v := range keys {
+		supplied[k] = v
+	}
+	for k, v := range setOps {
+		supplied[k] = v
+	}
+
+	result := make(map[string]interface{}, len(setOps))
+	for k, v := range setOps {
+		result[k] = v
+	}
+
+	for _, indexName := range sortedIndexNames(pb.entity.schema.Indexes) {
+		index := pb.entity.schema.Indexes[indexName]
+		if index.Index == nil {
+			continue
+		}
+
+		facetDefs := []FacetDefinition{index.PK}
+		if index.SK != nil {
+			facetDefs = append(facetDefs, *index.SK)
+		}
+
+		for i, facetDef := range facetDefs {
+			if !setsAnyFacet(setOps, facetDef.Facets) {
+				continue
+			}
+
+			key, err := pb.buildKeyWithType(facetDef, supplied, i == 1)
+			if err != nil {
+				return nil, err
+			}
+			if !key.Fulfilled {
+				return nil, NewElectroError("InvalidKeys",
+					fmt.Sprintf("Updating a facet of index '%s' requires all of its %s facets %v", indexName, facetDef.Field, facetDef.Facets), nil)
+			}
+			result[facetDef.Field] = key.Key
+		}
+	}
+
+	return result, nil
+}
+
+// checkFacetMutations rejects SET values for key facets. Primary index facets
+// can never change through an update since they identify the item, unless the
+// value matches the item's key; secondary index facets may change only when
+// recompute is enabled.
+func (pb *ParamsBuilder) checkFacetMutations(keys Keys, setOps map[string]interface{}, recompute bool) error {
+	for _, indexName := range sortedIndexNames(pb.entity.schema.Indexes) {
+		index := pb.entity.schema.Indexes[indexName]
+		facets := index.PK.Facets
+		if index.SK != nil {
+			facets = append(append([]string{}, facets...), index.SK.Facets...)
+		}
+
+		for _, facet := range facets {
+			value, ok := setOps[facet]
+			if !ok {
+				continue
+			}
+
+			if index.Index == nil {
+				if current, ok := keys[facet]; ok && fmt.Sprint(current) == fmt.Sprint(value) {
+					continue
+				}
+				return NewElectroError("FacetMutationNotAllowed",
+					fmt.Sprintf("Cannot update '%s': it is a facet of the primary key", facet), nil)
+			}
+			if !recompute {
+				return NewElectroError("FacetMutationNotAllowed",
+					fmt.Sprintf("Cannot update '%s': it is a facet of index '%s'; set UpdateOptions.RecomputeKeys to recompute the index keys", facet, indexName), nil)
+			}
+		}
+	}
+	return nil
+}
+
+// setsAnyFacet reports whether setOps assigns any of the given facets
+func setsAnyFacet(setOps map[string]interface{}, facets []string) bool {
+	for _, facet := range facets {
+		if _, ok := setOps[facet]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // contains checks if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && findSubstring(s, substr))
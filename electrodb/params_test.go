@@ -1,8 +1,17 @@
 package electrodb
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
@@ -318,7 +327,7 @@ func TestBuildQueryParams(t *testing.T) {
 
 	pkFacets := []interface{}{"EastPointe"}
 
-	params, err := builder.BuildQueryParams("units", pkFacets, nil, nil, nil)
+	params, err := builder.BuildQueryParams("units", pkFacets, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to build params: %v", err)
 	}
@@ -336,8 +345,9 @@ func TestBuildQueryParams(t *testing.T) {
 		t.Fatal("KeyConditionExpression is not a string")
 	}
 
-	if keyCondition != "gsi1pk = :pk" {
-		t.Errorf("Expected KeyConditionExpression 'gsi1pk = :pk', got '%s'", keyCondition)
+	// With no SK condition, the entity prefix is applied to the sort key
+	if keyCondition != "gsi1pk = :pk AND begins_with(gsi1sk, :sk)" {
+		t.Errorf("Expected KeyConditionExpression 'gsi1pk = :pk AND begins_with(gsi1sk, :sk)', got '%s'", keyCondition)
 	}
 }
 
@@ -373,7 +383,7 @@ func TestBuildQueryParamsWithSortKey(t *testing.T) {
 		values:    []interface{}{"Building"},
 	}
 
-	params, err := builder.BuildQueryParams("units", pkFacets, skCondition, nil, nil)
+	params, err := builder.BuildQueryParams("units", pkFacets, nil, skCondition, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to build params: %v", err)
 	}
@@ -388,3 +398,1261 @@ func TestBuildQueryParamsWithSortKey(t *testing.T) {
 		t.Errorf("Expected KeyConditionExpression '%s', got '%s'", expected, keyCondition)
 	}
 }
+
+func TestQueryKeysWithPartialSortKeyBetween(t *testing.T) {
+	schema := &Schema{
+		Service: "MallStoreDirectory",
+		Entity:  "MallStores",
+		Table:   "StoreDirectory",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"mall":     {Type: AttributeTypeString, Required: true},
+			"building": {Type: AttributeTypeString, Required: true},
+			"unit":     {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"units": {
+				Index: stringPtr("gsi1pk-gsi1sk-index"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"mall"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"building", "unit"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	params, err := entity.Query("units").
+		QueryKeys(Keys{"mall": "EastPointe"}).
+		Between(Keys{"building": "A"}, Keys{"building": "M"}).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+
+	if params["KeyConditionExpression"] != "gsi1pk = :pk AND gsi1sk BETWEEN :sk1 AND :sk2" {
+		t.Errorf("Unexpected key condition: %v", params["KeyConditionExpression"])
+	}
+
+	values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	expected := map[string]string{
+		":pk":  "$mallstoredirectory#mall_eastpointe",
+		":sk1": "$mallstores_1#building_a",
+		":sk2": "$mallstores_1#building_m",
+	}
+	for placeholder, want := range expected {
+		got := values[placeholder].(*types.AttributeValueMemberS).Value
+		if got != want {
+			t.Errorf("Expected %s to be %q, got %q", placeholder, want, got)
+		}
+	}
+
+	// SK facets supplied through QueryKeys combine with the condition operand
+	params, err = entity.Query("units").
+		QueryKeys(Keys{"mall": "EastPointe", "building": "A"}).
+		Gte(Keys{"unit": "10"}).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	values = params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if got := values[":sk"].(*types.AttributeValueMemberS).Value; got != "$mallstores_1#building_a#unit_10" {
+		t.Errorf("Expected composed sk, got %q", got)
+	}
+
+	// Without a condition, supplied SK facets become a begins_with prefix
+	params, err = entity.Query("units").QueryKeys(Keys{"mall": "EastPointe", "building": "A"}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	values = params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if got := values[":sk"].(*types.AttributeValueMemberS).Value; got != "$mallstores_1#building_a" {
+		t.Errorf("Expected begins_with prefix, got %q", got)
+	}
+}
+
+func TestSortKeyOperandsArePadded(t *testing.T) {
+	schema := &Schema{
+		Service: "MallStoreDirectory",
+		Entity:  "MallStores",
+		Table:   "StoreDirectory",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"mall":     {Type: AttributeTypeString, Required: true},
+			"building": {Type: AttributeTypeString, Required: true},
+			"floor":    {Type: AttributeTypeNumber, Required: true, Padding: &PaddingConfig{Length: 3, Char: "0"}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"mall"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"building", "floor"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	put, err := entity.Put(Item{"mall": "m1", "building": "a", "floor": 7}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build put params: %v", err)
+	}
+	stored := put["Item"].(map[string]types.AttributeValue)["sk"].(*types.AttributeValueMemberS).Value
+	if stored != "$mallstores_1#building_a#floor_007" {
+		t.Fatalf("Unexpected stored sk %q", stored)
+	}
+
+	// Keys operands and SK facets supplied to Query compose the key as stored
+	tests := []struct {
+		name  string
+		query *QueryChain
+	}{
+		{"Keys operand", entity.Query("primary").Query("m1", "a").Gte(Keys{"floor": 7})},
+		{"QueryKeys facets", entity.Query("primary").QueryKeys(Keys{"mall": "m1", "building": "a", "floor": 7})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := tt.query.Params()
+			if err != nil {
+				t.Fatalf("Failed to build params: %v", err)
+			}
+			values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+			if got := values[":sk"].(*types.AttributeValueMemberS).Value; got != stored {
+				t.Errorf("Expected sk operand %q, got %q", stored, got)
+			}
+		})
+	}
+}
+
+func TestQueryParamsNoEntityFilter(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "TestEntity",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"sort": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"sort"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	params, err := entity.Query("primary").QueryKeys(Keys{"id": "123"}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	if params["KeyConditionExpression"] != "pk = :pk AND begins_with(sk, :sk)" {
+		t.Errorf("Expected entity prefix condition by default, got %v", params["KeyConditionExpression"])
+	}
+
+	params, err = entity.Query("primary").
+		QueryKeys(Keys{"id": "123"}).
+		Options(&QueryOptions{NoEntityFilter: true}).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	if params["KeyConditionExpression"] != "pk = :pk" {
+		t.Errorf("Expected partition key only condition, got %v", params["KeyConditionExpression"])
+	}
+	values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if _, ok := values[":sk"]; ok {
+		t.Error("Expected no :sk value when NoEntityFilter is set")
+	}
+}
+
+func TestBinaryAttributeMarshaling(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Blob",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":      {Type: AttributeTypeString, Required: true},
+			"payload": {Type: AttributeTypeBinary},
+			"chunks":  {Type: AttributeTypeSet},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	payload := []byte{0x00, 0x01, 0xfe, 0xff}
+	chunks := [][]byte{{0x01}, {0x02}}
+	item := Item{"id": "1", "payload": payload, "chunks": chunks}
+
+	if err := entity.Validate(item); err != nil {
+		t.Fatalf("Expected binary item to validate, got %v", err)
+	}
+
+	params, err := entity.Put(item).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+
+	av := params["Item"].(map[string]types.AttributeValue)
+	b, ok := av["payload"].(*types.AttributeValueMemberB)
+	if !ok {
+		t.Fatalf("Expected payload to be a B member, got %T", av["payload"])
+	}
+	if !bytes.Equal(b.Value, payload) {
+		t.Errorf("Expected payload %v, got %v", payload, b.Value)
+	}
+	if _, ok := av["chunks"].(*types.AttributeValueMemberBS); !ok {
+		t.Errorf("Expected chunks to be a BS member, got %T", av["chunks"])
+	}
+
+	var decoded map[string]interface{}
+	if err := attributevalue.UnmarshalMap(av, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal item: %v", err)
+	}
+	if got, ok := decoded["payload"].([]byte); !ok || !bytes.Equal(got, payload) {
+		t.Errorf("Expected payload to round-trip, got %#v", decoded["payload"])
+	}
+
+	// Element types are validated
+	if err := entity.Validate(Item{"id": "1", "payload": "not bytes"}); err == nil {
+		t.Error("Expected string value for binary attribute to fail validation")
+	}
+	if err := entity.Validate(Item{"id": "1", "chunks": []interface{}{[]byte{0x01}, "mixed"}}); err == nil {
+		t.Error("Expected mixed set element types to fail validation")
+	}
+}
+
+func TestUpdateRecomputesIndexKeys(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"category": {Type: AttributeTypeString},
+			"brand":    {Type: AttributeTypeString},
+			"price":    {Type: AttributeTypeNumber},
+			"stock":    {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"byCategory": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"category"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"id"}},
+			},
+			"byBrand": {
+				Index: stringPtr("gsi2"),
+				PK:    FacetDefinition{Field: "gsi2pk", Facets: []string{"brand", "category"}},
+				SK:    &FacetDefinition{Field: "gsi2sk", Facets: []string{"price"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	setValues := func(params map[string]interface{}) map[string]string {
+		names := params["ExpressionAttributeNames"].(map[string]string)
+		values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+		result := make(map[string]string)
+		for placeholder, name := range names {
+			valueRef := ":val" + strings.TrimPrefix(placeholder, "#attr")
+			if v, ok := values[valueRef].(*types.AttributeValueMemberS); ok {
+				result[name] = v.Value
+			}
+		}
+		return result
+	}
+
+	recompute := &UpdateOptions{RecomputeKeys: true}
+
+	params, err := entity.Update(Keys{"id": "p1"}).
+		Set(map[string]interface{}{"category": "Tools", "brand": "Acme"}).
+		Options(recompute).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	set := setValues(params)
+	expected := map[string]string{
+		"gsi1pk": "$testservice#category_tools",
+		"gsi2pk": "$testservice#brand_acme#category_tools",
+	}
+	for field, want := range expected {
+		if set[field] != want {
+			t.Errorf("Expected %s to be recomputed as %q, got %q", field, want, set[field])
+		}
+	}
+	for _, field := range []string{"gsi1sk", "gsi2sk"} {
+		if _, ok := set[field]; ok {
+			t.Errorf("Expected %s to be left alone since none of its facets changed", field)
+		}
+	}
+
+	// Non-facet updates don't touch index keys
+	params, err = entity.Update(Keys{"id": "p1"}).Set(map[string]interface{}{"stock": 10}).Options(recompute).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	for _, name := range params["ExpressionAttributeNames"].(map[string]string) {
+		if strings.HasPrefix(name, "gsi") {
+			t.Errorf("Expected no index keys in update, found %s", name)
+		}
+	}
+
+	// A key that can't be fully composed is an error rather than a truncated key
+	_, err = entity.Update(Keys{"id": "p1"}).Set(map[string]interface{}{"category": "Tools"}).Options(recompute).Params()
+	if err == nil {
+		t.Fatal("Expected error when an index key can't be fully recomputed")
+	}
+	if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != "InvalidKeys" {
+		t.Errorf("Expected InvalidKeys error, got %v", err)
+	}
+}
+
+func TestUpdateFacetMutationNotAllowed(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Product",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"category": {Type: AttributeTypeString},
+			"name":     {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"byCategory": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"category"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	assertCode := func(err error, code string) {
+		t.Helper()
+		if err == nil {
+			t.Fatalf("Expected %s error, got nil", code)
+		}
+		if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != code {
+			t.Errorf("Expected %s error, got %v", code, err)
+		}
+	}
+
+	// Primary key facets can't change, even when recomputing keys
+	_, err = entity.Update(Keys{"id": "p1"}).Set(map[string]interface{}{"id": "p2"}).Params()
+	assertCode(err, ErrFacetMutationNotAllowed)
+	_, err = entity.Update(Keys{"id": "p1"}).
+		Set(map[string]interface{}{"id": "p2"}).
+		Options(&UpdateOptions{RecomputeKeys: true}).
+		Params()
+	assertCode(err, ErrFacetMutationNotAllowed)
+
+	// Secondary index facets require opting into recompute
+	_, err = entity.Update(Keys{"id": "p1"}).Set(map[string]interface{}{"category": "Tools"}).Params()
+	assertCode(err, ErrFacetMutationNotAllowed)
+	if _, err := entity.Update(Keys{"id": "p1"}).
+		Set(map[string]interface{}{"category": "Tools"}).
+		Options(&UpdateOptions{RecomputeKeys: true}).
+		Params(); err != nil {
+		t.Errorf("Expected recompute to allow facet update, got %v", err)
+	}
+
+	// Re-setting a primary facet to its current value and non-facet updates are fine
+	if _, err := entity.Update(Keys{"id": "p1"}).Set(map[string]interface{}{"id": "p1", "name": "Hammer"}).Params(); err != nil {
+		t.Errorf("Expected unchanged facet and non-facet update to succeed, got %v", err)
+	}
+}
+
+func TestCustomKeyDelimiters(t *testing.T) {
+	schema := &Schema{
+		Service:    "TestService",
+		Entity:     "Order",
+		Table:      "TestTable",
+		Version:    "1",
+		Delimiters: &KeyDelimiters{Prefix: "!", Facet: "|", Value: "=", Escape: true},
+		Attributes: map[string]*AttributeDefinition{
+			"store":   {Type: AttributeTypeString, Required: true},
+			"orderId": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"store"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"orderId"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// The default delimiter is an ordinary character under custom delimiters
+	params, err := entity.Get(Keys{"store": "east#1", "orderId": "a_7"}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	key := params["Key"].(map[string]types.AttributeValue)
+	if pk := key["pk"].(*types.AttributeValueMemberS).Value; pk != "!testservice|store=east#1" {
+		t.Errorf("Unexpected pk: %s", pk)
+	}
+	if sk := key["sk"].(*types.AttributeValueMemberS).Value; sk != "!order=1|orderid=a_7" {
+		t.Errorf("Unexpected sk: %s", sk)
+	}
+
+	params, err = entity.Query("primary").QueryKeys(Keys{"store": "east"}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build query params: %v", err)
+	}
+	values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if sk := values[":sk"].(*types.AttributeValueMemberS).Value; sk != "!order=1|orderid=" {
+		t.Errorf("Unexpected entity prefix: %s", sk)
+	}
+
+	// A value containing the custom facet delimiter is escaped
+	params, err = entity.Get(Keys{"store": "east|1", "orderId": "a"}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	key = params["Key"].(map[string]types.AttributeValue)
+	if pk := key["pk"].(*types.AttributeValueMemberS).Value; pk != `!testservice|store=east\|1` {
+		t.Errorf("Expected escaped delimiter in pk, got %s", pk)
+	}
+}
+
+func TestFacetValueEscaping(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Doc",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"path": {Type: AttributeTypeString, Required: true},
+			"rev":  {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"path"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"rev"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// Escaping is off by default so existing keys keep their bytes, and a
+	// value holding the facet delimiter is rejected rather than composed
+	// into an ambiguous key
+	unescaped := map[string]func() error{
+		"put": func() error {
+			_, err := entity.Put(Item{"path": "a#b", "rev": "r_1"}).Params()
+			return err
+		},
+		"query": func() error {
+			_, err := entity.Query("primary").Query("a", "r#1").Params()
+			return err
+		},
+		"condition": func() error {
+			_, err := entity.Query("primary").Query("a").Gte(Keys{"rev": "r#1"}).Params()
+			return err
+		},
+	}
+	for name, build := range unescaped {
+		var electroErr *ElectroError
+		if err := build(); !errors.As(err, &electroErr) || electroErr.Code != ErrInvalidFacetValue {
+			t.Errorf("%s: expected InvalidFacetValue, got %v", name, err)
+		}
+	}
+	params, err := entity.Put(Item{"path": "a", "rev": "r_1"}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	item := params["Item"].(map[string]types.AttributeValue)
+	if sk := item["sk"].(*types.AttributeValueMemberS).Value; sk != "$doc#rev_r_1" {
+		t.Errorf("Expected the value delimiter to be allowed in sk, got %s", sk)
+	}
+
+	schema.Delimiters = &KeyDelimiters{Escape: true}
+	entity, err = NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	params, err = entity.Put(Item{"path": "a#b", "rev": "r_1"}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	item = params["Item"].(map[string]types.AttributeValue)
+	if pk := item["pk"].(*types.AttributeValueMemberS).Value; pk != `$testservice#path_a\#b` {
+		t.Errorf("Expected escaped facet value in pk, got %s", pk)
+	}
+	if sk := item["sk"].(*types.AttributeValueMemberS).Value; sk != "$doc#rev_r_1" {
+		t.Errorf("Expected value delimiter to be kept in sk, got %s", sk)
+	}
+
+	// The escaped key parses back into the entity's layout
+	decoded, err := entity.FromStreamImage(item)
+	if err != nil {
+		t.Fatalf("Failed to decode item with escaped key: %v", err)
+	}
+	if decoded["path"] != "a#b" {
+		t.Errorf("Expected path a#b, got %v", decoded["path"])
+	}
+
+	params, err = entity.Query("primary").Query("a#b").Params()
+	if err != nil {
+		t.Fatalf("Failed to build query params: %v", err)
+	}
+	values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if pk := values[":pk"].(*types.AttributeValueMemberS).Value; pk != `$testservice#path_a\#b` {
+		t.Errorf("Expected query to use the escaped key, got %s", pk)
+	}
+}
+
+func TestQueryNonContiguousFacets(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Store",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"mall":     {Type: AttributeTypeString, Required: true},
+			"building": {Type: AttributeTypeString, Required: true},
+			"floor":    {Type: AttributeTypeString, Required: true},
+			"unit":     {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"mall"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"building", "floor", "unit"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	isNonContiguous := func(err error) bool {
+		electroErr, ok := err.(*ElectroError)
+		return ok && electroErr.Code == ErrNonContiguousFacets
+	}
+
+	// QueryKeys with a gap between building and unit
+	_, err = entity.Query("primary").QueryKeys(Keys{"mall": "m1", "building": "a", "unit": "7"}).Params()
+	if !isNonContiguous(err) {
+		t.Errorf("Expected NonContiguousFacets from QueryKeys, got %v", err)
+	}
+	if _, err := entity.Query("primary").QueryKeys(Keys{"mall": "m1", "building": "a", "unit": "7"}).Go(); !isNonContiguous(err) {
+		t.Errorf("Expected NonContiguousFacets from Go, got %v", err)
+	}
+
+	// Keys operand skipping floor
+	_, err = entity.Query("primary").Query("m1").Gte(Keys{"building": "a", "unit": "7"}).Params()
+	if !isNonContiguous(err) {
+		t.Errorf("Expected NonContiguousFacets from a Keys operand, got %v", err)
+	}
+
+	// Contiguous prefixes are fine
+	if _, err := entity.Query("primary").QueryKeys(Keys{"mall": "m1", "building": "a", "floor": "2"}).Params(); err != nil {
+		t.Errorf("Expected contiguous facets to succeed, got %v", err)
+	}
+	if _, err := entity.Query("primary").Query("m1").Gte(Keys{"building": "a"}).Params(); err != nil {
+		t.Errorf("Expected a leading Keys operand to succeed, got %v", err)
+	}
+}
+
+func TestSchemaDefaultPadding(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Order",
+		Table:   "TestTable",
+		Version: "1",
+		Padding: &PaddingConfig{Length: 6, Char: "0"},
+		Attributes: map[string]*AttributeDefinition{
+			"store":  {Type: AttributeTypeString, Required: true},
+			"rank":   {Type: AttributeTypeNumber},
+			"seq":    {Type: AttributeTypeNumber, Padding: &PaddingConfig{Length: 4}},
+			"code":   {Type: AttributeTypeNumber, Padding: &PaddingConfig{Length: 3, Char: "x"}},
+			"amount": {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"store"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"rank", "seq", "code"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	params, err := entity.Put(Item{"store": "s1", "rank": 7, "seq": 3, "code": 5, "amount": 12}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build put params: %v", err)
+	}
+	item := params["Item"].(map[string]types.AttributeValue)
+
+	// rank uses the schema default, seq takes Char from it, code keeps its own
+	expectedSK := "$order_1#rank_000007#seq_0003#code_xx5"
+	if sk := item["sk"].(*types.AttributeValueMemberS).Value; sk != expectedSK {
+		t.Errorf("Expected sk %s, got %s", expectedSK, sk)
+	}
+
+	// Non-facet numbers are left alone, and the schema default only pads the
+	// composed key, so rank is still stored as a number
+	if _, ok := item["amount"].(*types.AttributeValueMemberN); !ok {
+		t.Errorf("Expected amount to stay a number, got %#v", item["amount"])
+	}
+	if rank, ok := item["rank"].(*types.AttributeValueMemberN); !ok || rank.Value != "7" {
+		t.Errorf("Expected rank to be stored as the number 7, got %#v", item["rank"])
+	}
+
+	// Key lookups pad facets the same way as writes
+	getParams, err := entity.Get(Keys{"store": "s1", "rank": 7, "seq": 3, "code": 5}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build get params: %v", err)
+	}
+	key := getParams["Key"].(map[string]types.AttributeValue)
+	if sk := key["sk"].(*types.AttributeValueMemberS).Value; sk != expectedSK {
+		t.Errorf("Expected get sk %s, got %s", expectedSK, sk)
+	}
+
+	// A fraction is kept whole rather than truncated to pad it
+	params, err = entity.Put(Item{"store": "s1", "rank": 3.75, "seq": 3, "code": 5}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build put params: %v", err)
+	}
+	item = params["Item"].(map[string]types.AttributeValue)
+	if sk := item["sk"].(*types.AttributeValueMemberS).Value; sk != "$order_1#rank_3.75#seq_0003#code_xx5" {
+		t.Errorf("Expected the fractional rank unpadded in sk, got %s", sk)
+	}
+	if rank, ok := item["rank"].(*types.AttributeValueMemberN); !ok || rank.Value != "3.75" {
+		t.Errorf("Expected rank to be stored as 3.75, got %#v", item["rank"])
+	}
+}
+
+func TestEmptyStringValues(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"org":      {Type: AttributeTypeString, Required: true},
+			"id":       {Type: AttributeTypeString, Required: true},
+			"nickname": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"org"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// Empty non-key strings are stored as-is
+	params, err := entity.Put(Item{"org": "acme", "id": "u1", "nickname": ""}).Params()
+	if err != nil {
+		t.Fatalf("Expected an empty non-key string to be accepted, got %v", err)
+	}
+	item := params["Item"].(map[string]types.AttributeValue)
+	if nickname, ok := item["nickname"].(*types.AttributeValueMemberS); !ok || nickname.Value != "" {
+		t.Errorf("Expected nickname to be stored as an empty string, got %#v", item["nickname"])
+	}
+
+	isEmptyFacet := func(err error) bool {
+		electroErr, ok := err.(*ElectroError)
+		return ok && electroErr.Code == ErrEmptyFacetValue
+	}
+
+	// Empty facets fail wherever a key is composed
+	if _, err := entity.Put(Item{"org": "acme", "id": ""}).Params(); !isEmptyFacet(err) {
+		t.Errorf("Expected EmptyFacetValue from Put, got %v", err)
+	}
+	if _, err := entity.Get(Keys{"org": "", "id": "u1"}).Params(); !isEmptyFacet(err) {
+		t.Errorf("Expected EmptyFacetValue from Get, got %v", err)
+	}
+	if _, err := entity.Query("primary").Query("acme", "").Params(); !isEmptyFacet(err) {
+		t.Errorf("Expected EmptyFacetValue from a query facet, got %v", err)
+	}
+	if _, err := entity.Query("primary").Query("acme").Gte(Keys{"id": ""}).Params(); !isEmptyFacet(err) {
+		t.Errorf("Expected EmptyFacetValue from a Keys operand, got %v", err)
+	}
+}
+
+func TestUpdateRemoveValidation(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"org":      {Type: AttributeTypeString, Required: true},
+			"id":       {Type: AttributeTypeString, Required: true},
+			"email":    {Type: AttributeTypeString, Required: true},
+			"nickname": {Type: AttributeTypeString},
+			"bio":      {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"org"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	keys := Keys{"org": "acme", "id": "u1"}
+
+	params, err := entity.Update(keys).RemoveAttributes("nickname", "bio").Params()
+	if err != nil {
+		t.Fatalf("Expected optional attributes to be removable, got %v", err)
+	}
+	if expr := params["UpdateExpression"].(string); !strings.HasPrefix(expr, "REMOVE ") {
+		t.Errorf("Expected a REMOVE expression, got %q", expr)
+	}
+
+	tests := []struct {
+		attribute string
+		code      string
+	}{
+		{"id", ErrCannotRemoveFacet},
+		{"email", ErrCannotRemoveRequired},
+		{"unknown", ErrUnknownAttribute},
+	}
+	for _, tt := range tests {
+		_, err := entity.Update(keys).RemoveAttributes("nickname", tt.attribute).Params()
+		if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != tt.code {
+			t.Errorf("Removing %s: expected %s error, got %v", tt.attribute, tt.code, err)
+		}
+	}
+}
+
+func TestUpdateNilMeansRemove(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"email":    {Type: AttributeTypeString, Required: true},
+			"nickname": {Type: AttributeTypeString},
+			"bio":      {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	keys := Keys{"id": "u1"}
+	set := map[string]interface{}{"nickname": nil, "bio": "hello"}
+
+	// By default nil is stored as NULL
+	params, err := entity.Update(keys).Set(set).Params()
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+	if expr := params["UpdateExpression"].(string); expr != "SET #attr0 = :val0, #attr1 = :val1" {
+		t.Errorf("Expected both attributes in SET, got %q", expr)
+	}
+	if _, ok := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)[":val1"].(*types.AttributeValueMemberNULL); !ok {
+		t.Errorf("Expected nickname to be set to NULL, got %v", params["ExpressionAttributeValues"])
+	}
+
+	params, err = entity.Update(keys).Set(set).Options(&UpdateOptions{NilMeansRemove: true}).Params()
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+	names := params["ExpressionAttributeNames"].(map[string]string)
+	if expr := params["UpdateExpression"].(string); expr != "SET #attr0 = :val0 REMOVE #attr1" || names["#attr0"] != "bio" || names["#attr1"] != "nickname" {
+		t.Errorf("Expected nickname in REMOVE, got %q with names %v", expr, names)
+	}
+
+	// Removals are validated like RemoveAttributes
+	_, err = entity.Update(keys).Set(map[string]interface{}{"email": nil}).Options(&UpdateOptions{NilMeansRemove: true}).Params()
+	if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != ErrCannotRemoveRequired {
+		t.Errorf("Expected CannotRemoveRequired, got %v", err)
+	}
+}
+
+func TestUpdateExpressionDeterministic(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":     {Type: AttributeTypeString, Required: true},
+			"name":   {Type: AttributeTypeString},
+			"email":  {Type: AttributeTypeString},
+			"city":   {Type: AttributeTypeString},
+			"logins": {Type: AttributeTypeNumber},
+			"score":  {Type: AttributeTypeNumber},
+			"tags":   {Type: AttributeTypeList},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	build := func() string {
+		params, err := entity.Update(Keys{"id": "1"}).
+			Set(map[string]interface{}{"name": "alice", "email": "a@example.com", "city": "oslo"}).
+			Add(map[string]interface{}{"score": 2, "logins": 1}).
+			Remove([]string{"tags"}).
+			Params()
+		if err != nil {
+			t.Fatalf("Failed to build params: %v", err)
+		}
+		names := params["ExpressionAttributeNames"].(map[string]string)
+		return fmt.Sprintf("%s %v", params["UpdateExpression"], []string{names["#attr0"], names["#attr1"], names["#attr2"], names["#attr3"], names["#attr4"]})
+	}
+
+	expected := "SET #attr0 = :val0, #attr1 = :val1, #attr2 = :val2 ADD #attr3 :val3, #attr4 :val4 REMOVE #attr5 [city email name logins score]"
+	for i := 0; i < 20; i++ {
+		if got := build(); got != expected {
+			t.Fatalf("Run %d: expected %q, got %q", i, expected, got)
+		}
+	}
+}
+
+func TestFacetNormalization(t *testing.T) {
+	schema := &Schema{
+		Service: "MallService",
+		Entity:  "Store",
+		Table:   "MallTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"mall":  {Type: AttributeTypeString, Required: true, Normalize: &Normalization{Trim: true}},
+			"store": {Type: AttributeTypeString, Required: true, Normalize: &Normalization{Trim: true, Casing: "upper"}},
+			"name":  {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"mall"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"store"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	putParams, err := entity.Put(Item{"mall": " EastPointe ", "store": " a1 ", "name": " Shop "}).Params()
+	if err != nil {
+		t.Fatalf("Put params failed: %v", err)
+	}
+	item := putParams["Item"].(map[string]types.AttributeValue)
+	pk := item["pk"].(*types.AttributeValueMemberS).Value
+	sk := item["sk"].(*types.AttributeValueMemberS).Value
+	if pk != "$mallservice#mall_eastpointe" || sk != "$store_1#store_a1" {
+		t.Errorf("Expected normalized keys, got %s / %s", pk, sk)
+	}
+	if mall := item["mall"].(*types.AttributeValueMemberS).Value; mall != "EastPointe" {
+		t.Errorf("Expected the stored facet to be trimmed, got %q", mall)
+	}
+	if store := item["store"].(*types.AttributeValueMemberS).Value; store != "A1" {
+		t.Errorf("Expected the stored facet to be trimmed and upper-cased, got %q", store)
+	}
+	if name := item["name"].(*types.AttributeValueMemberS).Value; name != " Shop " {
+		t.Errorf("Expected attributes without Normalize to be left alone, got %q", name)
+	}
+
+	// Lookups normalize the same way
+	getParams, err := entity.Get(Keys{"mall": "eastpointe\t", "store": "A1 "}).Params()
+	if err != nil {
+		t.Fatalf("Get params failed: %v", err)
+	}
+	key := getParams["Key"].(map[string]types.AttributeValue)
+	if key["pk"].(*types.AttributeValueMemberS).Value != pk || key["sk"].(*types.AttributeValueMemberS).Value != sk {
+		t.Errorf("Expected Get to compose the put's keys, got %v", key)
+	}
+
+	queryParams, err := entity.Query("primary").Query("  EASTPOINTE", " a1").Params()
+	if err != nil {
+		t.Fatalf("Query params failed: %v", err)
+	}
+	values := queryParams["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if values[":pk"].(*types.AttributeValueMemberS).Value != pk {
+		t.Errorf("Expected the query partition key %s, got %v", pk, values[":pk"])
+	}
+	if values[":sk"].(*types.AttributeValueMemberS).Value != sk {
+		t.Errorf("Expected the query sort key prefix %s, got %v", sk, values[":sk"])
+	}
+
+	// A facet of only whitespace is empty once trimmed
+	if _, err := entity.Get(Keys{"mall": "  ", "store": "a1"}).Params(); err == nil {
+		t.Error("Expected a blank facet to be rejected")
+	}
+}
+
+func TestProjectionIncludesKeyFields(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "TestEntity",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"mall":     {Type: AttributeTypeString, Required: true},
+			"building": {Type: AttributeTypeString, Required: true},
+			"name":     {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"units": {
+				Index: stringPtr("gsi1pk-gsi1sk-index"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"mall"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"building"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	projected := func(params map[string]interface{}) []string {
+		names := params["ExpressionAttributeNames"].(map[string]string)
+		var fields []string
+		for _, placeholder := range strings.Split(params["ProjectionExpression"].(string), ", ") {
+			fields = append(fields, names[placeholder])
+		}
+		return fields
+	}
+
+	queryParams, err := entity.Query("units").Query("EastPointe").
+		Options(&QueryOptions{Attributes: []string{"name"}}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build query params: %v", err)
+	}
+	expected := []string{"name", "gsi1pk", "mall", "gsi1sk", "building", "pk", "id"}
+	if fields := projected(queryParams); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected query projection %v, got %v", expected, fields)
+	}
+
+	getParams, err := entity.Get(Keys{"id": "1"}).Options(&GetOptions{Attributes: []string{"name", "id"}}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build get params: %v", err)
+	}
+	expected = []string{"name", "id", "pk"}
+	if fields := projected(getParams); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected get projection %v, got %v", expected, fields)
+	}
+}
+
+func TestQueryParamsAccumulateNames(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "TestEntity",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":     {Type: AttributeTypeString, Required: true},
+			"mall":   {Type: AttributeTypeString, Required: true},
+			"status": {Type: AttributeTypeString},
+			"name":   {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"byMall": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "key", Facets: []string{"mall"}},
+				SK:    &FacetDefinition{Field: "gsi1-sk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	params, err := entity.Query("byMall").Query("EastPointe").
+		Options(&QueryOptions{Attributes: []string{"name"}}).
+		Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return attrs["status"].Eq("open")
+		}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+
+	if params["KeyConditionExpression"] != "#pk = :pk AND begins_with(#sk, :sk)" {
+		t.Errorf("Expected aliased key fields, got %v", params["KeyConditionExpression"])
+	}
+	if params["FilterExpression"] != "#attr0 = :val0" {
+		t.Errorf("Unexpected filter: %v", params["FilterExpression"])
+	}
+
+	names := params["ExpressionAttributeNames"].(map[string]string)
+	expected := map[string]string{
+		"#pk": "key", "#sk": "gsi1-sk", "#attr0": "status",
+		"#proj0": "name", "#proj1": "key", "#proj2": "mall", "#proj3": "gsi1-sk", "#proj4": "id", "#proj5": "pk",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected names %v, got %v", expected, names)
+	}
+	values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	for _, placeholder := range []string{":pk", ":sk", ":val0"} {
+		if _, ok := values[placeholder]; !ok {
+			t.Errorf("Expected value %s, got %v", placeholder, values)
+		}
+	}
+
+	names = map[string]string{"#attr0": "status"}
+	if err := mergeNames(names, map[string]string{"#attr0": "name"}); err == nil {
+		t.Error("Expected a placeholder bound to two names to be rejected")
+	}
+}
+
+func TestPutSkipIndexes(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "TestEntity",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":     {Type: AttributeTypeString, Required: true},
+			"mall":   {Type: AttributeTypeString, Required: true},
+			"status": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+			"byMall": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"mall"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"id"}},
+			},
+			"byStatus": {
+				Index: stringPtr("gsi2"),
+				PK:    FacetDefinition{Field: "gsi2pk", Facets: []string{"status"}},
+				SK:    &FacetDefinition{Field: "gsi2sk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	item := Item{"id": "1", "mall": "EastPointe", "status": "draft"}
+	params, err := entity.Put(item).Options(&PutOptions{SkipIndexes: []string{"byStatus"}}).Params()
+	if err != nil {
+		t.Fatalf("Put params failed: %v", err)
+	}
+	stored := params["Item"].(map[string]types.AttributeValue)
+	for _, field := range []string{"gsi2pk", "gsi2sk"} {
+		if _, ok := stored[field]; ok {
+			t.Errorf("Expected %s to be omitted for a skipped index", field)
+		}
+	}
+	for _, field := range []string{"pk", "sk", "gsi1pk", "gsi1sk", "status"} {
+		if _, ok := stored[field]; !ok {
+			t.Errorf("Expected %s to be written", field)
+		}
+	}
+
+	if _, err := entity.Put(item).Options(&PutOptions{SkipIndexes: []string{"primary"}}).Params(); err == nil {
+		t.Error("Expected skipping the primary index to be rejected")
+	}
+	if _, err := entity.Put(item).Options(&PutOptions{SkipIndexes: []string{"byOwner"}}).Params(); err == nil {
+		t.Error("Expected an unknown index to be rejected")
+	}
+}
+
+// clockKey is the context key of the fixed clock in TestDefaultFromContext
+type clockKey struct{}
+
+func TestDefaultFromContext(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Task",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id": {Type: AttributeTypeString, Required: true},
+			"receivedAt": {
+				Type: AttributeTypeString,
+				Default: func() interface{} {
+					return "default"
+				},
+				DefaultFromContext: func(ctx context.Context) interface{} {
+					if now, ok := ctx.Value(clockKey{}).(time.Time); ok {
+						return now.Format(time.RFC3339)
+					}
+					return "no clock"
+				},
+			},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	var written []map[string]types.AttributeValue
+	client := &mockClient{
+		putItem: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			written = append(written, input.Item)
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	ctx := context.WithValue(context.Background(), clockKey{}, fixed)
+	want := fixed.Format(time.RFC3339)
+
+	receivedAt := func(item map[string]types.AttributeValue) string {
+		s, _ := item["receivedAt"].(*types.AttributeValueMemberS)
+		if s == nil {
+			return ""
+		}
+		return s.Value
+	}
+
+	params, err := entity.Put(Item{"id": "1"}).WithContext(ctx).Params()
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+	if got := receivedAt(params["Item"].(map[string]types.AttributeValue)); got != want {
+		t.Errorf("Expected Params to use the context clock %q, got %q", want, got)
+	}
+
+	if _, err := entity.Put(Item{"id": "1"}).WithContext(ctx).Go(); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	transactItem, err := entity.Put(Item{"id": "2"}).WithContext(ctx).Commit().BuildTransactItem()
+	if err != nil {
+		t.Fatalf("BuildTransactItem failed: %v", err)
+	}
+	written = append(written, transactItem.Put.Item)
+	if len(written) != 2 {
+		t.Fatalf("Expected 2 writes, got %d", len(written))
+	}
+	for i, item := range written {
+		if got := receivedAt(item); got != want {
+			t.Errorf("Write %d: expected the context clock %q, got %q", i, want, got)
+		}
+	}
+
+	// Without a context the function sees context.Background(), and explicit
+	// values still win
+	params, _ = entity.Put(Item{"id": "1"}).Params()
+	if got := receivedAt(params["Item"].(map[string]types.AttributeValue)); got != "no clock" {
+		t.Errorf("Expected the background context, got %q", got)
+	}
+	params, _ = entity.Put(Item{"id": "1", "receivedAt": "explicit"}).WithContext(ctx).Params()
+	if got := receivedAt(params["Item"].(map[string]types.AttributeValue)); got != "explicit" {
+		t.Errorf("Expected the explicit value, got %q", got)
+	}
+}
+
+func BenchmarkBuildPutItemParams(b *testing.B) {
+	schema := &Schema{
+		Service: "bench",
+		Entity:  "user",
+		Table:   "table",
+		Attributes: map[string]*AttributeDefinition{
+			"tenant":    {Type: AttributeTypeString, Required: true},
+			"userId":    {Type: AttributeTypeString, Required: true},
+			"email":     {Type: AttributeTypeString},
+			"name":      {Type: AttributeTypeString},
+			"createdAt": {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"tenant"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"userId"}},
+			},
+			"byEmail": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"tenant", "email"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"createdAt"}},
+			},
+		},
+	}
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		b.Fatalf("Failed to create entity: %v", err)
+	}
+	item := Item{"tenant": "acme", "userId": "u-123", "email": "a@example.com", "name": "Alice", "createdAt": 1700000000}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewParamsBuilder(entity).BuildPutItemParams(item, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
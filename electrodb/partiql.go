@@ -0,0 +1,98 @@
+package electrodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PartiQLResponse represents the rows of an ExecuteStatement call. Rows owned
+// by a joined entity are decoded through that entity's read path and grouped
+// under its name in Data; rows no entity owns are unmarshaled as-is into
+// Unrecognized.
+type PartiQLResponse struct {
+	Data         map[string][]map[string]interface{}
+	Unrecognized []map[string]interface{}
+	NextToken    *string
+}
+
+// StatementExecutor is the part of the DynamoDB client used to run PartiQL
+// statements; *dynamodb.Client satisfies it
+type StatementExecutor interface {
+	ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error)
+}
+
+// ExecuteStatement runs a raw PartiQL statement for ad-hoc access. Params are
+// marshaled in order for the statement's ? placeholders. Only the first page
+// is read; pass NextToken back through the client directly to continue. The
+// service's client must implement StatementExecutor.
+func (s *Service) ExecuteStatement(ctx context.Context, statement string, params ...interface{}) (*PartiQLResponse, error) {
+	if s.client == nil {
+		return nil, NewElectroError("NoClientProvided",
+			"No DynamoDB client was provided to the service", nil)
+	}
+	executor, ok := s.client.(StatementExecutor)
+	if !ok {
+		return nil, NewElectroError("InvalidOperation",
+			"The service's DynamoDB client does not implement ExecuteStatement", nil)
+	}
+	if s.config.ReadOnly && !isSelectStatement(statement) {
+		return nil, NewElectroError("ReadOnlyMode",
+			"Service is read-only; only SELECT statements are allowed", nil)
+	}
+
+	input := &dynamodb.ExecuteStatementInput{Statement: &statement}
+	for i, param := range params {
+		av, err := attributevalue.Marshal(param)
+		if err != nil {
+			return nil, NewElectroError("MarshalError",
+				fmt.Sprintf("Failed to marshal statement parameter %d", i), err)
+		}
+		input.Parameters = append(input.Parameters, av)
+	}
+
+	output, err := executor.ExecuteStatement(ctx, input)
+	if err != nil {
+		return nil, NewElectroError("DynamoDBError", "Failed to execute ExecuteStatement", err)
+	}
+
+	result := &PartiQLResponse{
+		Data:      make(map[string][]map[string]interface{}),
+		NextToken: output.NextToken,
+	}
+	for _, image := range output.Items {
+		name, item, err := s.ClassifyItem(image)
+		if err == nil {
+			result.Data[name] = append(result.Data[name], item)
+			continue
+		}
+		if !isEntityNotFound(err) {
+			return nil, err
+		}
+		row, err := unmarshalRow(image)
+		if err != nil {
+			return nil, err
+		}
+		result.Unrecognized = append(result.Unrecognized, row)
+	}
+	return result, nil
+}
+
+// unmarshalRow converts a row no entity owns into a plain map
+func unmarshalRow(image map[string]types.AttributeValue) (map[string]interface{}, error) {
+	var row map[string]interface{}
+	if err := attributevalue.UnmarshalMap(image, &row); err != nil {
+		return nil, NewElectroError("UnmarshalError", "Failed to unmarshal statement row", err)
+	}
+	return row, nil
+}
+
+// isSelectStatement reports whether a PartiQL statement only reads
+func isSelectStatement(statement string) bool {
+	fields := strings.Fields(statement)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "SELECT")
+}
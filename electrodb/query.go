@@ -2,15 +2,27 @@ package electrodb
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 // QueryBuilder is an interface for building queries
 type QueryBuilder interface {
 	// Query starts a query with partition key facets
 	Query(facets ...interface{}) *QueryChain
+	// QueryKeys starts a query with named facets
+	QueryKeys(keys Keys) *QueryChain
 }
 
-// QueryChain represents a chainable query
+// QueryChain represents a chainable query.
+//
+// QueryChain has value semantics: every chained method returns a new chain and
+// leaves the receiver unchanged, so a base query can be forked into variants:
+//
+//	base := entity.Query("byTenant").Query("tenant1")
+//	adults := base.Where(func(a map[string]*AttributeRef, o *OperationBuilder) string { return a["age"].Gte(18) })
+//	minors := base.Where(func(a map[string]*AttributeRef, o *OperationBuilder) string { return a["age"].Lt(18) })
 type QueryChain struct {
 	entity        *Entity
 	accessPattern string
@@ -21,11 +33,15 @@ type QueryChain struct {
 	filters       []string
 	options       *QueryOptions
 	filterBuilder *FilterBuilder
+	sortBy        string // client-side sort attribute applied by Pages
+	sortDesc      bool
+	err           error // deferred error from building the chain, returned by Go and Params
 }
 
 type sortKeyCondition struct {
 	operation string
 	values    []interface{}
+	prefix    bool // the Keys operand holds a value prefix, which is not padded
 }
 
 // queryBuilderImpl implements QueryBuilder
@@ -68,99 +84,199 @@ func (qb *queryBuilderImpl) Query(facets ...interface{}) *QueryChain {
 	}
 }
 
+// QueryKeys starts a query from named facets instead of positional values.
+// All PK facets must be supplied; SK facets are used as a prefix in index order,
+// stopping at the first SK facet not present in keys.
+func (qb *queryBuilderImpl) QueryKeys(keys Keys) *QueryChain {
+	var facets []interface{}
+	for _, facet := range qb.index.PK.Facets {
+		value, exists := keys[facet]
+		if !exists {
+			break
+		}
+		facets = append(facets, value)
+	}
+
+	// SK facets only apply once the full partition key is known
+	var err error
+	if len(facets) == len(qb.index.PK.Facets) && qb.index.SK != nil {
+		err = checkContiguousFacets(qb.index.SK.Facets, keys)
+		for _, facet := range qb.index.SK.Facets {
+			value, exists := keys[facet]
+			if !exists {
+				break
+			}
+			facets = append(facets, value)
+		}
+	}
+
+	chain := qb.Query(facets...)
+	chain.err = err
+	return chain
+}
+
+// clone returns a copy of the query chain that can be modified without
+// affecting the receiver. Filter builders are cloned lazily by the methods
+// that extend them, so sharing the pointer here is safe.
+func (qc *QueryChain) clone() *QueryChain {
+	next := *qc
+	next.pkFacets = append([]interface{}(nil), qc.pkFacets...)
+	next.skFacets = append([]interface{}(nil), qc.skFacets...)
+	next.filters = append([]string(nil), qc.filters...)
+	if qc.options != nil {
+		opts := *qc.options
+		next.options = &opts
+	}
+	return &next
+}
+
+// withSortKeyCondition returns a copy of the chain with the given sort key
+// condition. DynamoDB allows one sort key condition per query, so the first
+// becomes the key condition and later ones filter on the facet they name,
+// e.g. Begins(Keys{"building": "A"}).Between(Keys{"floor": 1}, Keys{"floor": 3}).
+func (qc *QueryChain) withSortKeyCondition(operation string, values ...interface{}) *QueryChain {
+	if qc.skCondition != nil {
+		return qc.withFacetFilter(operation, values)
+	}
+
+	next := qc.clone()
+	next.skCondition = &sortKeyCondition{
+		operation: operation,
+		values:    values,
+	}
+	return next
+}
+
+// withSortKeyPrefix returns a copy of the chain whose sort key condition is
+// a begins_with on the start of facet's value. The prefix is normalized but
+// not padded, since it is not a whole value.
+func (qc *QueryChain) withSortKeyPrefix(facet string, prefix string) *QueryChain {
+	next := qc.clone()
+	next.skCondition = &sortKeyCondition{
+		operation: "begins_with",
+		values:    []interface{}{Keys{facet: prefix}},
+		prefix:    true,
+	}
+	return next
+}
+
+// withFacetFilter returns a copy of the chain filtering on the single sort
+// key facet named by the Keys operands of a sort key condition. Operands are
+// normalized and padded, since the filter compares them with stored values.
+func (qc *QueryChain) withFacetFilter(operation string, values []interface{}) *QueryChain {
+	next := qc.clone()
+	facet, operands, err := qc.facetOperands(values)
+	if err != nil {
+		if next.err == nil {
+			next.err = err
+		}
+		return next
+	}
+	schema := qc.entity.schema
+	for i, operand := range operands {
+		operands[i] = ApplyPadding(ApplyNormalization(Item{facet: operand}, schema), schema)[facet]
+	}
+
+	next.filterBuilder = qc.extendFilter()
+	next.filterBuilder.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		attr := attrs[facet]
+		switch operation {
+		case ">":
+			return attr.Gt(operands[0])
+		case ">=":
+			return attr.Gte(operands[0])
+		case "<":
+			return attr.Lt(operands[0])
+		case "<=":
+			return attr.Lte(operands[0])
+		case "BETWEEN":
+			return attr.Between(operands[0], operands[1])
+		case "begins_with":
+			return attr.Begins(operands[0])
+		}
+		return attr.Eq(operands[0])
+	})
+	return next
+}
+
+// facetOperands returns the facet named by Keys operands that each hold one
+// value for the same sort key facet, and those values
+func (qc *QueryChain) facetOperands(values []interface{}) (string, []interface{}, error) {
+	invalid := NewElectroError("InvalidOperation",
+		"A query has one sort key condition; additional conditions must name a single sort key facet, e.g. Keys{\"floor\": 3}", nil)
+	if qc.index.SK == nil {
+		return "", nil, invalid
+	}
+
+	var facet string
+	operands := make([]interface{}, len(values))
+	for i, value := range values {
+		keys, ok := value.(Keys)
+		if !ok || len(keys) != 1 {
+			return "", nil, invalid
+		}
+		for name, operand := range keys {
+			if facet != "" && name != facet {
+				return "", nil, invalid
+			}
+			facet = name
+			operands[i] = operand
+		}
+	}
+	for _, skFacet := range qc.index.SK.Facets {
+		if skFacet == facet {
+			return facet, operands, nil
+		}
+	}
+	return "", nil, NewElectroError("InvalidOperation",
+		fmt.Sprintf("'%s' is not a sort key facet of index '%s'", facet, qc.accessPattern), nil)
+}
+
 // Eq adds an equals condition on the sort key
 func (qc *QueryChain) Eq(value interface{}) *QueryChain {
-	qc.skCondition = &sortKeyCondition{
-		operation: "=",
-		values:    []interface{}{value},
-	}
-	return qc
+	return qc.withSortKeyCondition("=", value)
 }
 
 // Gt adds a greater-than condition on the sort key
 func (qc *QueryChain) Gt(value interface{}) *QueryChain {
-	qc.skCondition = &sortKeyCondition{
-		operation: ">",
-		values:    []interface{}{value},
-	}
-	return qc
+	return qc.withSortKeyCondition(">", value)
 }
 
 // Gte adds a greater-than-or-equal condition on the sort key
 func (qc *QueryChain) Gte(value interface{}) *QueryChain {
-	qc.skCondition = &sortKeyCondition{
-		operation: ">=",
-		values:    []interface{}{value},
-	}
-	return qc
+	return qc.withSortKeyCondition(">=", value)
 }
 
 // Lt adds a less-than condition on the sort key
 func (qc *QueryChain) Lt(value interface{}) *QueryChain {
-	qc.skCondition = &sortKeyCondition{
-		operation: "<",
-		values:    []interface{}{value},
-	}
-	return qc
+	return qc.withSortKeyCondition("<", value)
 }
 
 // Lte adds a less-than-or-equal condition on the sort key
 func (qc *QueryChain) Lte(value interface{}) *QueryChain {
-	qc.skCondition = &sortKeyCondition{
-		operation: "<=",
-		values:    []interface{}{value},
-	}
-	return qc
+	return qc.withSortKeyCondition("<=", value)
 }
 
-// Between adds a between condition on the sort key
+// Between adds a between condition on the sort key.
+// Like the other sort key conditions, start and end may be Keys naming a
+// leading subset of the SK facets, e.g. Between(Keys{"building": "A"}, Keys{"building": "M"}),
+// in which case the composite sort key is composed up to the last supplied facet.
 func (qc *QueryChain) Between(start, end interface{}) *QueryChain {
-	qc.skCondition = &sortKeyCondition{
-		operation: "BETWEEN",
-		values:    []interface{}{start, end},
-	}
-	return qc
+	return qc.withSortKeyCondition("BETWEEN", start, end)
 }
 
 // Begins adds a begins-with condition on the sort key
 func (qc *QueryChain) Begins(value interface{}) *QueryChain {
-	qc.skCondition = &sortKeyCondition{
-		operation: "begins_with",
-		values:    []interface{}{value},
-	}
-	return qc
+	return qc.withSortKeyCondition("begins_with", value)
 }
 
 // Where adds a custom filter expression
+// Multiple calls are combined with AND
 func (qc *QueryChain) Where(callback WhereCallback) *QueryChain {
-	fb := NewFilterBuilder(qc.entity.schema.Attributes)
-	fb.Where(callback)
-
-	// Merge with existing filter builder if present
-	if qc.filterBuilder != nil {
-		// Combine expressions with AND
-		existingExpr, existingNames, existingValues := qc.filterBuilder.Build()
-		newExpr, newNames, newValues := fb.Build()
-
-		combined := NewFilterBuilder(qc.entity.schema.Attributes)
-		combined.builder.expression = existingExpr + " AND " + newExpr
-
-		// Merge names and values
-		mergedNames, mergedValues := MergeExpressionAttributes(
-			existingNames,
-			existingValues,
-			newNames,
-			newValues,
-		)
-		combined.builder.names = mergedNames
-		combined.builder.values = mergedValues
-
-		qc.filterBuilder = combined
-	} else {
-		qc.filterBuilder = fb
-	}
-
-	return qc
+	next := qc.clone()
+	next.filterBuilder = qc.extendFilter()
+	next.filterBuilder.Where(callback)
+	return next
 }
 
 // Filter adds a filter using a named filter from schema
@@ -175,9 +291,10 @@ func (qc *QueryChain) Filter(filterName string, params map[string]interface{}) *
 		return qc
 	}
 
-	// Create a filter builder and execute the named filter
-	fb := NewFilterBuilder(qc.entity.schema.Attributes)
-	fb.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+	// Execute the named filter on a copy of the current filter builder
+	next := qc.clone()
+	next.filterBuilder = qc.extendFilter()
+	next.filterBuilder.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
 		// Convert AttributeRef map to AttributeOperations for the filter function
 		attrOps := make(AttributeOperations)
 		for name, ref := range attrs {
@@ -189,47 +306,288 @@ func (qc *QueryChain) Filter(filterName string, params map[string]interface{}) *
 		return filterFunc(attrOps, params)
 	})
 
-	// Merge with existing filter builder if present
-	if qc.filterBuilder != nil {
-		// Combine expressions with AND
-		existingExpr, existingNames, existingValues := qc.filterBuilder.Build()
-		newExpr, newNames, newValues := fb.Build()
+	return next
+}
 
-		combined := NewFilterBuilder(qc.entity.schema.Attributes)
-		combined.builder.expression = existingExpr + " AND " + newExpr
+// extendFilter returns a filter builder that continues from the chain's
+// current filter, so placeholders never collide and the original is untouched
+func (qc *QueryChain) extendFilter() *FilterBuilder {
+	if qc.filterBuilder == nil {
+		fb := NewFilterBuilder(qc.entity.schema.Attributes)
+		fb.builder.timeFormat = qc.entity.timeFormat()
+		return fb
+	}
+	return qc.filterBuilder.clone()
+}
 
-		// Merge names and values
-		mergedNames, mergedValues := MergeExpressionAttributes(
-			existingNames,
-			existingValues,
-			newNames,
-			newValues,
-		)
-		combined.builder.names = mergedNames
-		combined.builder.values = mergedValues
+// Options sets query options
+func (qc *QueryChain) Options(opts *QueryOptions) *QueryChain {
+	next := qc.clone()
+	next.options = opts
+	return next
+}
 
-		qc.filterBuilder = combined
-	} else {
-		qc.filterBuilder = fb
+// Limit sets the maximum number of items DynamoDB evaluates per request
+func (qc *QueryChain) Limit(limit int32) *QueryChain {
+	next := qc.clone()
+	if next.options == nil {
+		next.options = &QueryOptions{}
 	}
+	next.options.Limit = &limit
+	return next
+}
 
-	return qc
+// Ascending returns items in ascending sort key order (the DynamoDB default).
+// DynamoDB orders query results by the sort key of the queried index only.
+func (qc *QueryChain) Ascending() *QueryChain {
+	return qc.withOrder("asc")
 }
 
-// Options sets query options
-func (qc *QueryChain) Options(opts *QueryOptions) *QueryChain {
-	qc.options = opts
-	return qc
+// Descending returns items in descending sort key order
+func (qc *QueryChain) Descending() *QueryChain {
+	return qc.withOrder("desc")
+}
+
+// withOrder returns a copy of the chain with the given sort key order
+func (qc *QueryChain) withOrder(order string) *QueryChain {
+	next := qc.clone()
+	if next.options == nil {
+		next.options = &QueryOptions{}
+	}
+	next.options.Order = &order
+	return next
+}
+
+// SortBy sorts the items accumulated by Pages on an attribute, client-side,
+// for orderings other than the sort key that must hold across pages. Items
+// missing the attribute sort last. It does not affect Go, Page or Params.
+func (qc *QueryChain) SortBy(attr string, desc bool) *QueryChain {
+	next := qc.clone()
+	next.sortBy = attr
+	next.sortDesc = desc
+	return next
 }
 
 // Go executes the query
+// With QueryOptions.Pages set, Go follows up to that many pages and returns
+// their concatenated items with the cursor of the last page fetched.
+// QueryOptions.MaxBytes additionally bounds the size of the items returned.
 func (qc *QueryChain) Go() (*QueryResponse, error) {
+	if qc.options != nil && qc.options.MaxBytes != nil && *qc.options.MaxBytes > 0 {
+		return qc.withinBytes(*qc.options.MaxBytes)
+	}
+	if qc.options == nil || qc.options.Pages == nil || *qc.options.Pages <= 1 {
+		return qc.page(qc.options)
+	}
+
+	opts := *qc.options
+	response := &QueryResponse{Data: []map[string]interface{}{}}
+	for i := 0; i < *qc.options.Pages; i++ {
+		result, err := qc.page(&opts)
+		if err != nil {
+			return nil, err
+		}
+		response.Data = append(response.Data, result.Data...)
+		response.Cursor = result.Cursor
+		response.PageInfo.add(result.PageInfo)
+		if result.Cursor == nil || *result.Cursor == "" {
+			break
+		}
+		opts.Cursor = result.Cursor
+		opts.IgnoreCursor = false
+	}
+	return response, nil
+}
+
+// Take executes the query, following pages until n items have been collected
+// or the partition is exhausted. Filters and Limit apply before DynamoDB
+// returns a page, so a single page can hold fewer items than asked for; Take
+// hides that. When it stops partway through a page the cursor resumes after
+// the last item returned.
+func (qc *QueryChain) Take(n int) (*QueryResponse, error) {
+	response := &QueryResponse{Data: []map[string]interface{}{}}
+	if n <= 0 {
+		return response, nil
+	}
+
+	// Keys are needed to resume after the last item taken
+	opts := QueryOptions{}
+	if qc.options != nil {
+		opts = *qc.options
+	}
+	opts.Pages = nil
+	includeKeys := opts.IncludeKeys
+	opts.IncludeKeys = true
+
+	for {
+		result, err := qc.page(&opts)
+		if err != nil {
+			return nil, err
+		}
+
+		response.PageInfo.add(result.PageInfo)
+		remaining := n - len(response.Data)
+		if len(result.Data) > remaining {
+			if err := qc.stopAfter(response, result, remaining); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		response.Data = append(response.Data, result.Data...)
+		response.Cursor = result.Cursor
+		if len(response.Data) == n || result.Cursor == nil || *result.Cursor == "" {
+			break
+		}
+		opts.Cursor = result.Cursor
+		opts.IgnoreCursor = false
+	}
+
+	if !includeKeys && !opts.Raw {
+		for _, item := range response.Data {
+			stripKeyFields(item, qc.entity.schema)
+		}
+	}
+	return response, nil
+}
+
+// withinBytes executes the query like Go, following pages until the stored
+// size of the items returned would exceed maxBytes
+func (qc *QueryChain) withinBytes(maxBytes int) (*QueryResponse, error) {
+	response := &QueryResponse{Data: []map[string]interface{}{}}
+
+	// Keys are needed to resume after the last item returned
+	opts := *qc.options
+	includeKeys := opts.IncludeKeys
+	opts.IncludeKeys = true
+
+	size := 0
+	for pages := 1; ; pages++ {
+		result, err := qc.page(&opts)
+		if err != nil {
+			return nil, err
+		}
+
+		response.PageInfo.add(result.PageInfo)
+		kept := 0
+		for ; kept < len(result.Data); kept++ {
+			if size+result.itemSizes[kept] > maxBytes && len(response.Data)+kept > 0 {
+				break
+			}
+			size += result.itemSizes[kept]
+		}
+		if kept < len(result.Data) {
+			if err := qc.stopAfter(response, result, kept); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		response.Data = append(response.Data, result.Data...)
+		response.Cursor = result.Cursor
+		if result.Cursor == nil || *result.Cursor == "" || (opts.Pages != nil && pages >= *opts.Pages) {
+			break
+		}
+		opts.Cursor = result.Cursor
+		opts.IgnoreCursor = false
+	}
+
+	if !includeKeys && !opts.Raw {
+		for _, item := range response.Data {
+			stripKeyFields(item, qc.entity.schema)
+		}
+	}
+	return response, nil
+}
+
+// stopAfter ends response partway through the page result, after its first
+// kept items, with a cursor resuming after the last item in response
+func (qc *QueryChain) stopAfter(response, result *QueryResponse, kept int) error {
+	response.Data = append(response.Data, result.Data[:kept]...)
+	cursor, err := qc.itemCursor(response.Data[len(response.Data)-1])
+	if err != nil {
+		return err
+	}
+	response.Cursor = &cursor
+
+	// Items past the last one kept were read but not returned
+	response.PageInfo.Count -= int32(len(result.Data) - kept)
+	response.PageInfo.HasMore = true
+	response.PageInfo.LastEvaluatedKey, err = decodeCursor(cursor)
+	return err
+}
+
+// itemCursor builds the cursor that resumes a query after the given item,
+// from the key fields of the queried index and the primary index
+func (qc *QueryChain) itemCursor(item map[string]interface{}) (string, error) {
+	lastKey := make(map[string]types.AttributeValue)
+	indexes := []*IndexDefinition{qc.entity.schema.Indexes[qc.accessPattern], qc.entity.primaryIndex()}
+	for _, index := range indexes {
+		if index == nil {
+			continue
+		}
+		fields := []string{index.PK.Field}
+		if index.SK != nil {
+			fields = append(fields, index.SK.Field)
+		}
+		for _, field := range fields {
+			if value, ok := item[field].(string); ok {
+				lastKey[field] = &types.AttributeValueMemberS{Value: value}
+			}
+		}
+	}
+	return encodeCursor(lastKey)
+}
+
+// page executes a single Query request with the given options
+func (qc *QueryChain) page(options *QueryOptions) (*QueryResponse, error) {
+	if qc.err != nil {
+		return nil, qc.err
+	}
 	executor := NewExecutionHelper(qc.entity)
-	return executor.ExecuteQuery(context.Background(), qc.accessPattern, qc.pkFacets, qc.skFacets, qc.skCondition, qc.options, qc.filterBuilder)
+	return executor.ExecuteQuery(context.Background(), qc.accessPattern, qc.pkFacets, qc.skFacets, qc.skCondition, options, qc.filterBuilder)
+}
+
+// QueryInto executes the query like Go and decodes each formatted item into
+// a T, a struct tagged like those accepted by PutStruct. The cursor for the
+// next page is returned alongside the items.
+//
+//	users, cursor, err := electrodb.QueryInto[User](entity.Query("byOrg").Query("acme"))
+func QueryInto[T any](qc *QueryChain) ([]T, *string, error) {
+	result, err := qc.Go()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make([]T, len(result.Data))
+	for i, item := range result.Data {
+		if err := itemToStruct(item, &out[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return out, result.Cursor, nil
+}
+
+// ByKey indexes the response's items by the value of attr, formatted with
+// %v. Items without the attribute are left out, and when several items share
+// a value the last one in result order wins.
+func (r *QueryResponse) ByKey(attr string) map[string]map[string]interface{} {
+	byKey := make(map[string]map[string]interface{}, len(r.Data))
+	for _, item := range r.Data {
+		value, ok := item[attr]
+		if !ok || value == nil {
+			continue
+		}
+		byKey[fmt.Sprintf("%v", value)] = item
+	}
+	return byKey
 }
 
 // Params returns the DynamoDB parameters without executing
 func (qc *QueryChain) Params() (map[string]interface{}, error) {
+	if qc.err != nil {
+		return nil, qc.err
+	}
 	builder := NewParamsBuilder(qc.entity)
 	return builder.BuildQueryParams(qc.accessPattern, qc.pkFacets, qc.skFacets, qc.skCondition, qc.options, qc.filterBuilder)
 }
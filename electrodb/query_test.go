@@ -1,8 +1,12 @@
 package electrodb
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func TestQueryWithWhereClause(t *testing.T) {
@@ -235,3 +239,335 @@ func TestQueryParamsWithoutWhereClause(t *testing.T) {
 		t.Error("Expected KeyConditionExpression to be set")
 	}
 }
+
+func TestQueryConcurrentUse(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"tenantId": {Type: AttributeTypeString, Required: true},
+			"email":    {Type: AttributeTypeString, Required: true},
+			"age":      {Type: AttributeTypeNumber, Required: false},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"byTenant": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"tenantId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"email"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	const workers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			tenant := fmt.Sprintf("tenant%d", i)
+			params, err := entity.Query("byTenant").Query(tenant).
+				Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+					return attrs["age"].Gt(i)
+				}).
+				Params()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+			pk := values[":pk"].(*types.AttributeValueMemberS).Value
+			if pk != "$testservice#tenantid_"+tenant {
+				errs <- fmt.Errorf("worker %d got pk %q", i, pk)
+				return
+			}
+
+			age := values[":val0"].(*types.AttributeValueMemberN).Value
+			if age != fmt.Sprintf("%d", i) {
+				errs <- fmt.Errorf("worker %d got filter value %q", i, age)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestQueryReturnsFreshBuilder(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"tenantId": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"tenantId"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	if entity.Query("primary") == entity.Query("primary") {
+		t.Error("Expected a new query builder on each call")
+	}
+
+	if entity.Query("missing") != nil {
+		t.Error("Expected nil query builder for unknown access pattern")
+	}
+}
+
+func TestQueryChainForking(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"tenantId": {Type: AttributeTypeString, Required: true},
+			"email":    {Type: AttributeTypeString, Required: true},
+			"age":      {Type: AttributeTypeNumber, Required: false},
+			"active":   {Type: AttributeTypeBoolean, Required: false},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"byTenant": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"tenantId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"email"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	base := entity.Query("byTenant").Query("tenant1").Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return attrs["active"].Eq(true)
+	})
+
+	adults := base.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return attrs["age"].Gte(18)
+	}).Limit(10)
+	minors := base.Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return attrs["age"].Lt(18)
+	}).Begins("a")
+
+	baseParams, err := base.Params()
+	if err != nil {
+		t.Fatalf("Failed to build base params: %v", err)
+	}
+	adultParams, err := adults.Params()
+	if err != nil {
+		t.Fatalf("Failed to build adult params: %v", err)
+	}
+	minorParams, err := minors.Params()
+	if err != nil {
+		t.Fatalf("Failed to build minor params: %v", err)
+	}
+
+	if expr := baseParams["FilterExpression"]; expr != "#attr0 = :val0" {
+		t.Errorf("Expected base filter to be unchanged, got %v", expr)
+	}
+	if _, ok := baseParams["Limit"]; ok {
+		t.Error("Expected base query to have no Limit")
+	}
+
+	if expr := adultParams["FilterExpression"]; expr != "(#attr0 = :val0) AND (#attr1 >= :val1)" {
+		t.Errorf("Unexpected adult filter: %v", expr)
+	}
+	if adultParams["Limit"] != int32(10) {
+		t.Errorf("Expected adult Limit 10, got %v", adultParams["Limit"])
+	}
+
+	if expr := minorParams["FilterExpression"]; expr != "(#attr0 = :val0) AND (#attr1 < :val1)" {
+		t.Errorf("Unexpected minor filter: %v", expr)
+	}
+	if _, ok := minorParams["Limit"]; ok {
+		t.Error("Expected minor query to have no Limit")
+	}
+
+	minorNames := minorParams["ExpressionAttributeNames"].(map[string]string)
+	if minorNames["#attr0"] != "active" || minorNames["#attr1"] != "age" {
+		t.Errorf("Unexpected minor names: %v", minorNames)
+	}
+
+	if baseParams["KeyConditionExpression"] != adultParams["KeyConditionExpression"] {
+		t.Errorf("Expected forked queries to share key condition, got %v and %v",
+			baseParams["KeyConditionExpression"], adultParams["KeyConditionExpression"])
+	}
+	minorValues := minorParams["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if sk := minorValues[":sk"].(*types.AttributeValueMemberS).Value; sk != "a" {
+		t.Errorf("Expected minor sk condition 'a', got %q", sk)
+	}
+}
+
+func TestQueryCompoundSortKeyConditions(t *testing.T) {
+	schema := &Schema{
+		Service: "MallStoreDirectory",
+		Entity:  "MallStores",
+		Table:   "StoreDirectory",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"mall":     {Type: AttributeTypeString, Required: true},
+			"building": {Type: AttributeTypeString, Required: true},
+			"unit":     {Type: AttributeTypeString, Required: true},
+			"category": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"units": {
+				Index: stringPtr("gsi1pk-gsi1sk-index"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"mall"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"building", "unit"}},
+			},
+		},
+	}
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// The first sort key condition is the key condition; the second filters
+	// on the facet it names, alongside Where
+	params, err := entity.Query("units").
+		QueryKeys(Keys{"mall": "EastPointe"}).
+		Begins(Keys{"building": "A"}).
+		Between(Keys{"unit": "10"}, Keys{"unit": "20"}).
+		Where(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return attrs["category"].Eq("food")
+		}).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	if got := params["KeyConditionExpression"]; got != "gsi1pk = :pk AND begins_with(gsi1sk, :sk)" {
+		t.Errorf("Unexpected key condition: %v", got)
+	}
+	if got := params["FilterExpression"]; got != "(#attr0 BETWEEN :val0 AND :val1) AND (#attr1 = :val2)" {
+		t.Errorf("Unexpected filter: %v", got)
+	}
+	names := params["ExpressionAttributeNames"].(map[string]string)
+	values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if names["#attr0"] != "unit" || names["#attr1"] != "category" {
+		t.Errorf("Unexpected names: %v", names)
+	}
+	expected := map[string]string{
+		":sk":   "$mallstores_1#building_a",
+		":val0": "10",
+		":val1": "20",
+		":val2": "food",
+	}
+	for placeholder, want := range expected {
+		if got := values[placeholder].(*types.AttributeValueMemberS).Value; got != want {
+			t.Errorf("Expected %s to be %q, got %q", placeholder, want, got)
+		}
+	}
+
+	// Additional conditions must name a single sort key facet
+	invalid := []*QueryChain{
+		entity.Query("units").Query("EastPointe").Gte(Keys{"building": "A"}).Lte("raw"),
+		entity.Query("units").Query("EastPointe").Gte(Keys{"building": "A"}).Lte(Keys{"building": "M", "unit": "1"}),
+		entity.Query("units").Query("EastPointe").Gte(Keys{"building": "A"}).Eq(Keys{"category": "food"}),
+	}
+	for i, chain := range invalid {
+		_, err := chain.Params()
+		if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != ErrInvalidOperation {
+			t.Errorf("Case %d: expected InvalidOperation, got %v", i, err)
+		}
+	}
+}
+
+func TestQueryFacetFilterUsesStoredValues(t *testing.T) {
+	schema := &Schema{
+		Service: "MallStoreDirectory",
+		Entity:  "MallStores",
+		Table:   "StoreDirectory",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"mall":     {Type: AttributeTypeString, Required: true},
+			"building": {Type: AttributeTypeString, Required: true, Normalize: &Normalization{Casing: "lower"}},
+			"wing":     {Type: AttributeTypeString, Required: true, Normalize: &Normalization{Casing: "lower"}},
+			"floor":    {Type: AttributeTypeNumber, Required: true, Padding: &PaddingConfig{Length: 3, Char: "0"}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"mall"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{"building", "wing", "floor"}},
+			},
+		},
+	}
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// floor is stored as "007" and wing as "n", so the filter compares with those
+	params, err := entity.Query("primary").Query("m1").
+		Begins(Keys{"building": "A"}).
+		Between(Keys{"floor": 1}, Keys{"floor": 9}).
+		Eq(Keys{"wing": "N"}).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	if got := params["FilterExpression"]; got != "(#attr0 BETWEEN :val0 AND :val1) AND (#attr1 = :val2)" {
+		t.Fatalf("Unexpected filter: %v", got)
+	}
+	values := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	expected := map[string]string{
+		":sk":   "$mallstores_1#building_a",
+		":val0": "001",
+		":val1": "009",
+		":val2": "n",
+	}
+	for placeholder, want := range expected {
+		got, ok := values[placeholder].(*types.AttributeValueMemberS)
+		if !ok || got.Value != want {
+			t.Errorf("Expected %s to be S %q, got %#v", placeholder, want, values[placeholder])
+		}
+	}
+}
+
+func TestQueryResponseByKey(t *testing.T) {
+	response := &QueryResponse{Data: []map[string]interface{}{
+		{"id": "a", "version": 1},
+		{"id": "b", "version": 1},
+		{"id": "a", "version": 2},
+		{"name": "no id"},
+		{"id": 7, "version": 1},
+	}}
+
+	byID := response.ByKey("id")
+	if len(byID) != 3 {
+		t.Fatalf("Expected 3 keys, got %d: %v", len(byID), byID)
+	}
+	if byID["a"]["version"] != 2 {
+		t.Errorf("Expected the last item with a duplicate key to win, got %v", byID["a"])
+	}
+	if byID["b"]["version"] != 1 || byID["7"]["version"] != 1 {
+		t.Errorf("Expected items keyed by their formatted id, got %v", byID)
+	}
+	if len((&QueryResponse{}).ByKey("id")) != 0 {
+		t.Error("Expected an empty map for an empty response")
+	}
+}
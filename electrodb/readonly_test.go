@@ -0,0 +1,189 @@
+package electrodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	// Any write reaching the client fails the test
+	writes := 0
+	client := &mockClient{
+		putItem: func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			writes++
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		updateItem: func(*dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			writes++
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+		deleteItem: func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+			writes++
+			return &dynamodb.DeleteItemOutput{}, nil
+		},
+		batchWriteItem: func(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			writes++
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+		transactWriteItems: func(*dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			writes++
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		},
+		executeStatement: func(*dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error) {
+			return &dynamodb.ExecuteStatementOutput{}, nil
+		},
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+				"pk":   &types.AttributeValueMemberS{Value: "$testservice$user#id_u1"},
+				"sk":   &types.AttributeValueMemberS{Value: "$user_1"},
+				"id":   &types.AttributeValueMemberS{Value: "u1"},
+				"name": &types.AttributeValueMemberS{Value: "Alice"},
+			}}, nil
+		},
+	}
+
+	service := NewService("TestService", &ServiceConfig{Client: client, ReadOnly: true})
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if err := service.Join(entity); err != nil {
+		t.Fatalf("Failed to join entity: %v", err)
+	}
+
+	operations := map[string]func() error{
+		"put": func() error {
+			_, err := entity.Put(Item{"id": "u1"}).Go()
+			return err
+		},
+		"update": func() error {
+			_, err := entity.Update(Keys{"id": "u1"}).Set(map[string]interface{}{"name": "Bob"}).Go()
+			return err
+		},
+		"delete": func() error {
+			_, err := entity.Delete(Keys{"id": "u1"}).Go()
+			return err
+		},
+		"batch write": func() error {
+			_, err := entity.BatchWrite().Put([]Item{{"id": "u1"}}).Go()
+			return err
+		},
+		"bulk put": func() error {
+			_, err := entity.BulkPut(context.Background(), []Item{{"id": "u1"}})
+			return err
+		},
+		"service batch write": func() error {
+			_, err := service.BatchWrite().Put("User", []Item{{"id": "u1"}}).Go()
+			return err
+		},
+		"transact write": func() error {
+			_, err := service.TransactWrite(func(entities map[string]*Entity) []TransactionItem {
+				return []TransactionItem{entity.Put(Item{"id": "u1"}).Commit()}
+			}).Go()
+			return err
+		},
+		"partiql write": func() error {
+			_, err := service.ExecuteStatement(context.Background(), `DELETE FROM "TestTable" WHERE pk = ?`, "x")
+			return err
+		},
+	}
+
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			var electroErr *ElectroError
+			if err := operation(); !errors.As(err, &electroErr) || electroErr.Code != ErrReadOnlyMode {
+				t.Errorf("Expected ReadOnlyMode, got %v", err)
+			}
+		})
+	}
+	if writes != 0 {
+		t.Errorf("Expected no writes to reach DynamoDB, got %d", writes)
+	}
+
+	// Reads and Params are unaffected
+	result, err := entity.Get(Keys{"id": "u1"}).Go()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if result.Data["name"] != "Alice" {
+		t.Errorf("Expected the stored item, got %v", result.Data)
+	}
+	if _, err := entity.Put(Item{"id": "u1"}).Params(); err != nil {
+		t.Errorf("Params failed: %v", err)
+	}
+	if _, err := service.ExecuteStatement(context.Background(), `SELECT * FROM "TestTable"`); err != nil {
+		t.Errorf("SELECT failed: %v", err)
+	}
+}
+
+func TestReadOnlyServiceDoesNotLeak(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	config := &Config{Client: &mockClient{}}
+	entity, err := NewEntity(schema, config)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	replica := NewService("TestService", &ServiceConfig{ReadOnly: true})
+	primary := NewService("TestService", &ServiceConfig{})
+	for _, service := range []*Service{replica, primary} {
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join: %v", err)
+		}
+	}
+
+	if config.ReadOnly {
+		t.Error("Expected the entity's own Config to stay writable")
+	}
+	if _, err := entity.Put(Item{"id": "u1"}).Go(); err != nil {
+		t.Errorf("Expected the entity to be writable after joining a writable service, got %v", err)
+	}
+	if _, err := primary.BatchWrite().Put("User", []Item{{"id": "u1"}}).Go(); err != nil {
+		t.Errorf("Expected writes through the writable service to succeed, got %v", err)
+	}
+
+	// The read-only service still rejects its own writes
+	var electroErr *ElectroError
+	if _, err := replica.BatchWrite().Put("User", []Item{{"id": "u1"}}).Go(); !errors.As(err, &electroErr) || electroErr.Code != ErrReadOnlyMode {
+		t.Errorf("Expected ReadOnlyMode from the read-only service, got %v", err)
+	}
+	_, err = replica.TransactWrite(func(entities map[string]*Entity) []TransactionItem {
+		return []TransactionItem{entity.Put(Item{"id": "u1"}).Commit()}
+	}).Go()
+	if !errors.As(err, &electroErr) || electroErr.Code != ErrReadOnlyMode {
+		t.Errorf("Expected ReadOnlyMode from the read-only service, got %v", err)
+	}
+}
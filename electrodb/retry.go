@@ -0,0 +1,61 @@
+package electrodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// retryableErrorCodes are transient error codes that are not modeled as
+// DynamoDB error types
+var retryableErrorCodes = map[string]bool{
+	"ThrottlingException": true,
+	"ServiceUnavailable":  true,
+}
+
+// isRetryable reports whether a DynamoDB error is transient
+func isRetryable(err error) bool {
+	var throughput *types.ProvisionedThroughputExceededException
+	var requestLimit *types.RequestLimitExceeded
+	var internal *types.InternalServerError
+	if errors.As(err, &throughput) || errors.As(err, &requestLimit) || errors.As(err, &internal) {
+		return true
+	}
+
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		return retryableErrorCodes[coded.ErrorCode()]
+	}
+	return false
+}
+
+// retry runs call, retrying transient errors with exponential backoff as
+// configured by Config.Retry. It stops early if ctx is done.
+func (eh *ExecutionHelper) retry(ctx context.Context, call func() error) error {
+	err := call()
+
+	cfg := eh.entity.config.Retry
+	if cfg == nil {
+		return err
+	}
+
+	delay := cfg.BaseDelay
+	for attempt := 1; attempt < cfg.MaxAttempts && err != nil && isRetryable(err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		err = call()
+
+		delay *= 2
+		if cfg.MaxDelay > 0 && delay > cfg.MaxDelay {
+			delay = cfg.MaxDelay
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,97 @@
+package electrodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestRetryTransientErrors(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	newEntity := func(client DynamoDBClient, retry *RetryConfig) *Entity {
+		entity, err := NewEntity(schema, &Config{Client: client, Retry: retry})
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		return entity
+	}
+	retry := &RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	// Throttled once, then succeeds
+	calls := 0
+	client := &mockClient{
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			calls++
+			if calls == 1 {
+				return nil, &types.ProvisionedThroughputExceededException{}
+			}
+			return &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}},
+			}, nil
+		},
+	}
+	result, err := newEntity(client, retry).Get(Keys{"id": "1"}).Go()
+	if err != nil {
+		t.Fatalf("Expected throttled get to succeed after retry, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+	if result.Data["id"] != "1" {
+		t.Errorf("Expected item from retried call, got %v", result.Data)
+	}
+
+	// Conditional check failures are not retried
+	calls = 0
+	client = &mockClient{
+		putItem: func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			calls++
+			return nil, &types.ConditionalCheckFailedException{}
+		},
+	}
+	if _, err := newEntity(client, retry).Put(Item{"id": "1"}).Go(); err == nil {
+		t.Error("Expected conditional check failure to be returned")
+	}
+	if calls != 1 {
+		t.Errorf("Expected conditional check failure not to be retried, got %d calls", calls)
+	}
+
+	// Attempts are bounded by MaxAttempts
+	calls = 0
+	client = &mockClient{
+		deleteItem: func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+			calls++
+			return nil, &types.InternalServerError{}
+		},
+	}
+	if _, err := newEntity(client, retry).Delete(Keys{"id": "1"}).Go(); err == nil {
+		t.Error("Expected error after exhausting retries")
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+
+	// Without a RetryConfig errors are returned immediately
+	calls = 0
+	if _, err := newEntity(client, nil).Delete(Keys{"id": "1"}).Go(); err == nil {
+		t.Error("Expected error without retries")
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single attempt without RetryConfig, got %d", calls)
+	}
+}
@@ -3,6 +3,11 @@ package electrodb
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 // Service manages multiple entities in a single table
@@ -13,12 +18,31 @@ type Service struct {
 	table      *string
 	config     *Config
 	collection map[string]*Collection
+
+	// inheritsClient records joined entities that use the service's client
+	inheritsClient map[string]bool
 }
 
 // ServiceConfig holds configuration for a service
 type ServiceConfig struct {
 	Client DynamoDBClient
 	Table  *string
+
+	// WriteTransforms and ReadTransforms apply to every joined entity and
+	// run before the entity's own global transforms
+	WriteTransforms []AttributeTransformFunc
+	ReadTransforms  []AttributeTransformFunc
+
+	// BeforeWrite and AfterWrite apply to every joined entity and run before
+	// the entity's own hooks
+	BeforeWrite BeforeWriteFunc
+	AfterWrite  AfterWriteFunc
+
+	// ReadOnly rejects batch and transaction writes made through the
+	// service, restricts ExecuteStatement to SELECT statements, and makes
+	// joined entities read-only (see Config.ReadOnly) while they belong to
+	// it; the entities' own Configs are left unchanged
+	ReadOnly bool
 }
 
 // Collection represents a cross-entity query collection
@@ -36,12 +60,21 @@ func NewService(name string, config *ServiceConfig) *Service {
 	}
 
 	return &Service{
-		name:       name,
-		entities:   make(map[string]*Entity),
-		client:     config.Client,
-		table:      config.Table,
-		config:     &Config{Client: config.Client, Table: config.Table},
-		collection: make(map[string]*Collection),
+		name:     name,
+		entities: make(map[string]*Entity),
+		client:   config.Client,
+		table:    config.Table,
+		config: &Config{
+			Client:          config.Client,
+			Table:           config.Table,
+			WriteTransforms: config.WriteTransforms,
+			ReadTransforms:  config.ReadTransforms,
+			BeforeWrite:     config.BeforeWrite,
+			AfterWrite:      config.AfterWrite,
+			ReadOnly:        config.ReadOnly,
+		},
+		collection:     make(map[string]*Collection),
+		inheritsClient: make(map[string]bool),
 	}
 }
 
@@ -62,19 +95,40 @@ func (s *Service) Join(entity *Entity) error {
 			fmt.Sprintf("Entity '%s' already exists in service", entityName), nil)
 	}
 
-	// Update entity config with service's client and table
-	if entity.config == nil {
-		entity.config = &Config{}
+	// Service settings are merged onto a copy of the entity's own config, so
+	// a Config shared between entities, or an entity joined again, never
+	// picks up a service's settings twice
+	config := Config{}
+	if entity.ownConfig != nil {
+		config = *entity.ownConfig
 	}
 
-	if entity.config.Client == nil && s.client != nil {
-		entity.config.Client = s.client
-		entity.client = s.client
+	if config.Client == nil && config.Capture == nil {
+		s.inheritsClient[entityName] = true
+		config.Client = s.client
 	}
 
-	if entity.config.Table == nil && s.table != nil {
-		entity.config.Table = s.table
+	if config.Table == nil && s.table != nil {
+		config.Table = s.table
+	}
+
+	// Service-level transforms run before the entity's own
+	if len(s.config.WriteTransforms) > 0 {
+		config.WriteTransforms = append(
+			append([]AttributeTransformFunc{}, s.config.WriteTransforms...),
+			config.WriteTransforms...)
+	}
+	if len(s.config.ReadTransforms) > 0 {
+		config.ReadTransforms = append(
+			append([]AttributeTransformFunc{}, s.config.ReadTransforms...),
+			config.ReadTransforms...)
 	}
+	config.BeforeWrite = chainBeforeWrite(s.config.BeforeWrite, config.BeforeWrite)
+	config.AfterWrite = chainAfterWrite(s.config.AfterWrite, config.AfterWrite)
+	if s.config.ReadOnly {
+		config.ReadOnly = true
+	}
+	entity.useConfig(&config)
 
 	// Add to entities map
 	s.entities[entityName] = entity
@@ -85,6 +139,52 @@ func (s *Service) Join(entity *Entity) error {
 	return nil
 }
 
+// checkWritable fails with ReadOnlyMode when the service is read-only or the
+// entity's own Config is. Unlike Entity.checkWritable it ignores the settings
+// of other services the entity joined.
+func (s *Service) checkWritable(entity *Entity, operation string) error {
+	if s.config.ReadOnly {
+		return NewElectroError("ReadOnlyMode",
+			fmt.Sprintf("Service is read-only; %s is not allowed", operation), nil)
+	}
+	if entity != nil && entity.ownConfig.ReadOnly {
+		return readOnlyEntityError(entity, operation)
+	}
+	return nil
+}
+
+// chainBeforeWrite runs first and then second, feeding each the previous
+// hook's item; nil hooks are skipped
+func chainBeforeWrite(first, second BeforeWriteFunc) BeforeWriteFunc {
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+	return func(op string, item Item) (Item, error) {
+		item, err := first(op, item)
+		if err != nil {
+			return nil, err
+		}
+		return second(op, item)
+	}
+}
+
+// chainAfterWrite runs first and then second; nil hooks are skipped
+func chainAfterWrite(first, second AfterWriteFunc) AfterWriteFunc {
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+	return func(op string, resp interface{}) {
+		first(op, resp)
+		second(op, resp)
+	}
+}
+
 // buildCollections creates collections from entity indexes
 func (s *Service) buildCollections(entity *Entity) {
 	for indexName, index := range entity.schema.Indexes {
@@ -124,6 +224,20 @@ func (s *Service) buildCollections(entity *Entity) {
 	}
 }
 
+// WithClient sets the service's DynamoDB client and propagates it to every
+// joined entity that did not bring its own client, so a service defined
+// without a client can be wired at runtime
+func (s *Service) WithClient(client DynamoDBClient) *Service {
+	s.client = client
+	s.config.Client = client
+	for name, entity := range s.entities {
+		if s.inheritsClient[name] {
+			entity.useConfig(entity.config.WithClient(client))
+		}
+	}
+	return s
+}
+
 // Entities returns all entities in the service
 func (s *Service) Entities() map[string]*Entity {
 	return s.entities
@@ -159,6 +273,7 @@ type CollectionQuery struct {
 	collection  *Collection
 	pkFacets    []interface{}
 	skCondition *sortKeyCondition
+	skPrefix    *string // value prefix of the leading SK facet, see QueryPrefix
 	options     *QueryOptions
 	ctx         context.Context
 }
@@ -172,6 +287,19 @@ func (c *Collection) Query(facets ...interface{}) *CollectionQuery {
 	}
 }
 
+// QueryPrefix starts a collection query for the items whose leading sort key
+// facet value begins with skPrefix, e.g. every event under a "2024-01" date
+// for a collection whose sort keys start with a date facet. The prefix is
+// normalized but never padded, since it is only the start of a value. Every
+// entity in the collection must share that leading facet, and no other sort
+// key condition may be added; the query fails with InvalidCollectionQuery
+// otherwise.
+func (c *Collection) QueryPrefix(skPrefix string, pkFacets ...interface{}) *CollectionQuery {
+	cq := c.Query(pkFacets...)
+	cq.skPrefix = &skPrefix
+	return cq
+}
+
 // Eq adds an equals condition on the sort key
 func (cq *CollectionQuery) Eq(value interface{}) *CollectionQuery {
 	cq.skCondition = &sortKeyCondition{
@@ -260,45 +388,141 @@ func (cq *CollectionQuery) Go() (*CollectionQueryResponse, error) {
 
 	// Query each entity in the collection
 	for _, entityName := range cq.collection.entities {
-		entity, err := cq.collection.service.Entity(entityName)
+		query, err := cq.entityQuery(entityName)
 		if err != nil {
 			return nil, err
 		}
-
-		// Find the index name for this collection in this entity
-		var indexName string
-		for idx, indexDef := range entity.schema.Indexes {
-			collName := idx
-			if indexDef.Collection != nil {
-				collName = *indexDef.Collection
-			}
-			if collName == cq.collection.name {
-				indexName = idx
-				break
-			}
-		}
-
-		if indexName == "" {
+		if query == nil {
 			continue
 		}
 
-		// Execute query for this entity
-		queryBuilder := entity.Query(indexName)
-		if queryBuilder == nil {
-			continue
+		queryResp, err := query.Go()
+		if err != nil {
+			return nil, err
 		}
 
-		queryResp, err := queryBuilder.Query(cq.pkFacets...).Go()
+		result.Data[entityName] = queryResp.Data
+	}
+
+	return result, nil
+}
+
+// CollectionItem is an item of an ordered collection query, tagged with the
+// name of the entity it belongs to
+type CollectionItem struct {
+	Entity string
+	Data   map[string]interface{}
+}
+
+// OrderedCollectionResponse represents an ordered collection query response
+type OrderedCollectionResponse struct {
+	Data []CollectionItem
+}
+
+// GoOrdered executes the collection query and returns the items of every
+// entity as one list, interleaved in the order of the collection's shared
+// sort key facets (descending with Order "desc") rather than grouped by
+// entity. It fetches every page of every entity before merging, so it
+// returns no cursor, and Limit, Pages, Cursor and MaxBytes are rejected.
+func (cq *CollectionQuery) GoOrdered() (*OrderedCollectionResponse, error) {
+	if cq.collection.service.client == nil {
+		return nil, NewElectroError("NoClientProvided",
+			"No DynamoDB client was provided to the service", nil)
+	}
+	if o := cq.options; o != nil && (o.Limit != nil || o.Pages != nil || o.Cursor != nil || o.MaxBytes != nil) {
+		return nil, NewElectroError("InvalidOperation",
+			"GoOrdered fetches the whole collection and does not support Limit, Pages, Cursor or MaxBytes", nil)
+	}
+
+	// Sort keys are needed to merge the entities' results
+	opts := QueryOptions{}
+	if cq.options != nil {
+		opts = *cq.options
+	}
+	includeKeys := opts.IncludeKeys
+	opts.IncludeKeys = true
+
+	type sortedItem struct {
+		sortKey string
+		item    CollectionItem
+	}
+	var items []sortedItem
+
+	for _, entityName := range cq.collection.entities {
+		query, err := cq.entityQuery(entityName)
 		if err != nil {
 			return nil, err
 		}
+		if query == nil {
+			continue
+		}
 
-		result.Data[entityName] = queryResp.Data
+		// Follow every page so the merge sees the whole collection
+		var fetched []map[string]interface{}
+		pageOpts := opts
+		for {
+			queryResp, err := query.Options(&pageOpts).Go()
+			if err != nil {
+				return nil, err
+			}
+			fetched = append(fetched, queryResp.Data...)
+			if queryResp.Cursor == nil || *queryResp.Cursor == "" {
+				break
+			}
+			pageOpts.Cursor = queryResp.Cursor
+			pageOpts.IgnoreCursor = false
+		}
+
+		// Each entity prefixes its sort keys with its own name, so compare
+		// only the facets that follow
+		schema := query.entity.schema
+		index := schema.Indexes[query.accessPattern]
+		prefix := query.entity.keyDelimiters().SortKeyPrefix(schema.Entity, schema.Version)
+		for _, data := range fetched {
+			sortKey := ""
+			if index.SK != nil {
+				sortKey, _ = data[index.SK.Field].(string)
+				sortKey = strings.TrimPrefix(sortKey, prefix)
+			}
+			if !includeKeys {
+				data = stripKeyFields(data, query.entity.schema)
+			}
+			items = append(items, sortedItem{sortKey: sortKey, item: CollectionItem{Entity: entityName, Data: data}})
+		}
 	}
 
+	descending := opts.Order != nil && *opts.Order == "desc"
+	sort.SliceStable(items, func(i, j int) bool {
+		if descending {
+			return items[i].sortKey > items[j].sortKey
+		}
+		return items[i].sortKey < items[j].sortKey
+	})
+
+	result := &OrderedCollectionResponse{Data: make([]CollectionItem, len(items))}
+	for i, item := range items {
+		result.Data[i] = item.item
+	}
 	return result, nil
 }
 
+// stripKeyFields removes the schema's index key fields, other than declared
+// attributes, from a formatted item
+func stripKeyFields(item map[string]interface{}, schema *Schema) map[string]interface{} {
+	for _, index := range schema.Indexes {
+		fields := []string{index.PK.Field}
+		if index.SK != nil {
+			fields = append(fields, index.SK.Field)
+		}
+		for _, field := range fields {
+			if _, isAttribute := schema.Attributes[field]; !isAttribute {
+				delete(item, field)
+			}
+		}
+	}
+	return item
+}
+
 // Params returns the DynamoDB parameters for the collection query
 func (cq *CollectionQuery) Params() (map[string]interface{}, error) {
 	params := make(map[string]interface{})
@@ -306,41 +530,249 @@ func (cq *CollectionQuery) Params() (map[string]interface{}, error) {
 
 	// Generate params for each entity
 	for _, entityName := range cq.collection.entities {
-		entity, err := cq.collection.service.Entity(entityName)
+		query, err := cq.entityQuery(entityName)
 		if err != nil {
 			return nil, err
 		}
+		if query == nil {
+			continue
+		}
 
-		// Find the index name for this collection in this entity
-		var indexName string
-		for idx, indexDef := range entity.schema.Indexes {
-			collName := idx
-			if indexDef.Collection != nil {
-				collName = *indexDef.Collection
-			}
-			if collName == cq.collection.name {
-				indexName = idx
-				break
-			}
+		entityParams, err := query.Params()
+		if err != nil {
+			return nil, err
+		}
+
+		params["entities"].(map[string]interface{})[entityName] = entityParams
+	}
+
+	return params, nil
+}
+
+// entityQuery builds the query for one entity participating in the
+// collection, composing the sort key condition against that entity's own SK
+// facets. It returns nil if the entity has no index in the collection.
+func (cq *CollectionQuery) entityQuery(entityName string) (*QueryChain, error) {
+	entity, err := cq.collection.service.Entity(entityName)
+	if err != nil {
+		return nil, err
+	}
+
+	indexName := cq.collection.entityIndex(entity)
+	if indexName == "" {
+		return nil, nil
+	}
+
+	queryBuilder := entity.Query(indexName)
+	if queryBuilder == nil {
+		return nil, nil
+	}
+
+	if cq.skPrefix != nil && cq.skCondition != nil {
+		return nil, NewElectroError("InvalidCollectionQuery",
+			"QueryPrefix cannot be combined with another sort key condition", nil)
+	}
+
+	query := queryBuilder.Query(cq.pkFacets...)
+	if cq.skPrefix != nil {
+		facet, err := cq.leadingSortKeyFacet()
+		if err != nil {
+			return nil, err
+		}
+		query = query.withSortKeyPrefix(facet, *cq.skPrefix)
+	}
+	if cq.skCondition != nil {
+		if err := cq.validateSortKeyCondition(entity, entity.schema.Indexes[indexName]); err != nil {
+			return nil, err
 		}
+		query = query.withSortKeyCondition(cq.skCondition.operation, cq.skCondition.values...)
+	}
+	if cq.options != nil {
+		query = query.Options(cq.options)
+	}
+
+	return query, nil
+}
+
+// entityIndex returns the name of the entity's index in the collection, or ""
+// if the entity has none
+func (c *Collection) entityIndex(entity *Entity) string {
+	for idx, indexDef := range entity.schema.Indexes {
+		collName := idx
+		if indexDef.Collection != nil {
+			collName = *indexDef.Collection
+		}
+		if collName == c.name {
+			return idx
+		}
+	}
+	return ""
+}
 
+// leadingSortKeyFacet returns the first SK facet of the collection's index,
+// which every participating entity must share for a QueryPrefix to select
+// the same position in each entity's sort key
+func (cq *CollectionQuery) leadingSortKeyFacet() (string, error) {
+	var leading string
+	for _, entityName := range cq.collection.entities {
+		entity, err := cq.collection.service.Entity(entityName)
+		if err != nil {
+			return "", err
+		}
+		indexName := cq.collection.entityIndex(entity)
 		if indexName == "" {
 			continue
 		}
 
-		// Generate params for this entity
-		queryBuilder := entity.Query(indexName)
-		if queryBuilder == nil {
+		index := entity.schema.Indexes[indexName]
+		if index.SK == nil || len(index.SK.Facets) == 0 {
+			return "", NewElectroError("InvalidCollectionQuery",
+				fmt.Sprintf("Entity '%s' has no sort key facets on collection '%s'", entityName, cq.collection.name), nil)
+		}
+		facet := index.SK.Facets[0]
+		if leading == "" {
+			leading = facet
+		} else if facet != leading {
+			return "", NewElectroError("InvalidCollectionQuery",
+				fmt.Sprintf("Entity '%s' sort key starts with '%s', not '%s' like the rest of collection '%s'", entityName, facet, leading, cq.collection.name), nil)
+		}
+	}
+	return leading, nil
+}
+
+// validateSortKeyCondition checks that every Keys operand of the sort key
+// condition names a leading prefix of the entity's SK facets, so the condition
+// composes to the same position in each entity's sort key
+func (cq *CollectionQuery) validateSortKeyCondition(entity *Entity, index *IndexDefinition) error {
+	if index.SK == nil {
+		return NewElectroError("InvalidCollectionQuery",
+			fmt.Sprintf("Entity '%s' has no sort key on collection '%s'", entity.schema.Entity, cq.collection.name), nil)
+	}
+
+	for _, value := range cq.skCondition.values {
+		var keys map[string]interface{}
+		switch v := value.(type) {
+		case Keys:
+			keys = v
+		case map[string]interface{}:
+			keys = v
+		default:
 			continue
 		}
 
-		entityParams, err := queryBuilder.Query(cq.pkFacets...).Params()
+		if len(keys) > len(index.SK.Facets) {
+			return NewElectroError("InvalidCollectionQuery",
+				fmt.Sprintf("Sort key condition has more facets than entity '%s' defines", entity.schema.Entity), nil)
+		}
+		for _, facet := range index.SK.Facets[:len(keys)] {
+			if _, ok := keys[facet]; !ok {
+				return NewElectroError("InvalidCollectionQuery",
+					fmt.Sprintf("Sort key condition does not match the leading sort key facets of entity '%s'", entity.schema.Entity), nil)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PartitionQuery loads every item of one base table partition across the
+// service's entities
+type PartitionQuery struct {
+	service  *Service
+	pkValues []interface{}
+	ctx      context.Context
+}
+
+// Partition starts a query of the primary index partition identified by the
+// given partition key facet values, in facet order. Entities sharing the
+// partition must compose the same partition key from those values; the key is
+// composed by the first entity, in name order, whose primary partition key
+// has that many facets.
+func (s *Service) Partition(pkValues ...interface{}) *PartitionQuery {
+	return &PartitionQuery{
+		service:  s,
+		pkValues: pkValues,
+		ctx:      context.Background(),
+	}
+}
+
+// Go reads the whole partition, following every page, and returns its items
+// grouped by entity name. Items no joined entity owns are skipped; an owned
+// item that fails to decode (e.g. DecryptionFailed) fails the query.
+func (pq *PartitionQuery) Go() (*CollectionQueryResponse, error) {
+	if pq.service.client == nil {
+		return nil, NewElectroError("NoClientProvided",
+			"No DynamoDB client was provided to the service", nil)
+	}
+
+	entity, indexName := pq.keyEntity()
+	if entity == nil {
+		return nil, NewElectroError("InvalidKeys",
+			fmt.Sprintf("No entity in service '%s' has a primary partition key of %d facets", pq.service.name, len(pq.pkValues)), nil)
+	}
+
+	builder := NewParamsBuilder(entity)
+	params, err := builder.BuildQueryParams(indexName, pq.pkValues, nil, nil, &QueryOptions{NoEntityFilter: true}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 stringPtr(params["TableName"].(string)),
+		KeyConditionExpression:    stringPtr(params["KeyConditionExpression"].(string)),
+		ExpressionAttributeValues: params["ExpressionAttributeValues"].(map[string]types.AttributeValue),
+	}
+	if names, ok := params["ExpressionAttributeNames"].(map[string]string); ok {
+		input.ExpressionAttributeNames = names
+	}
+	if consistent, ok := params["ConsistentRead"].(bool); ok {
+		input.ConsistentRead = &consistent
+	}
+
+	result := &CollectionQueryResponse{
+		Data: make(map[string][]map[string]interface{}),
+	}
+	for {
+		output, err := pq.service.client.Query(pq.ctx, input)
 		if err != nil {
-			return nil, err
+			return nil, NewElectroError("DynamoDBError", "Failed to execute Query", err)
 		}
 
-		params["entities"].(map[string]interface{})[entityName] = entityParams
+		for _, image := range output.Items {
+			name, item, err := pq.service.ClassifyItem(image)
+			if isEntityNotFound(err) {
+				// Items no joined entity owns are skipped
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			result.Data[name] = append(result.Data[name], item)
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return result, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
 	}
+}
 
-	return params, nil
+// keyEntity picks the entity and primary index name used to compose the
+// partition key
+func (pq *PartitionQuery) keyEntity() (*Entity, string) {
+	names := make([]string, 0, len(pq.service.entities))
+	for name := range pq.service.entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entity := pq.service.entities[name]
+		for indexName, index := range entity.schema.Indexes {
+			if index.Index == nil && len(index.PK.Facets) == len(pq.pkValues) {
+				return entity, indexName
+			}
+		}
+	}
+	return nil, ""
 }
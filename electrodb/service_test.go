@@ -1,7 +1,13 @@
 package electrodb
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func TestNewService(t *testing.T) {
@@ -296,3 +302,859 @@ func TestCollectionQuery(t *testing.T) {
 		t.Error("Expected Store entity params")
 	}
 }
+
+func TestCollectionQuerySortKeyCondition(t *testing.T) {
+	service := NewService("MallService", &ServiceConfig{
+		Table: stringPtr("MallTable"),
+	})
+
+	storeSchema := &Schema{
+		Service: "MallService",
+		Entity:  "Store",
+		Table:   "MallTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"mall":     {Type: AttributeTypeString, Required: true},
+			"building": {Type: AttributeTypeString, Required: true},
+			"unit":     {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"byMall": {
+				Index:      stringPtr("gsi1pk-gsi1sk-index"),
+				Collection: stringPtr("mall"),
+				PK:         FacetDefinition{Field: "gsi1pk", Facets: []string{"mall"}},
+				SK:         &FacetDefinition{Field: "gsi1sk", Facets: []string{"building", "unit"}},
+			},
+		},
+	}
+
+	employeeSchema := &Schema{
+		Service: "MallService",
+		Entity:  "Employee",
+		Table:   "MallTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":       {Type: AttributeTypeString, Required: true},
+			"mall":     {Type: AttributeTypeString, Required: true},
+			"building": {Type: AttributeTypeString, Required: true},
+			"name":     {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"staff": {
+				Index:      stringPtr("gsi1pk-gsi1sk-index"),
+				Collection: stringPtr("mall"),
+				PK:         FacetDefinition{Field: "gsi1pk", Facets: []string{"mall"}},
+				SK:         &FacetDefinition{Field: "gsi1sk", Facets: []string{"building", "name"}},
+			},
+		},
+	}
+
+	for _, schema := range []*Schema{storeSchema, employeeSchema} {
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	mallCollection, err := service.Collection("mall")
+	if err != nil {
+		t.Fatalf("Failed to get mall collection: %v", err)
+	}
+
+	params, err := mallCollection.Query("EastPointe").
+		Between(Keys{"building": "A"}, Keys{"building": "C"}).
+		Params()
+	if err != nil {
+		t.Fatalf("Failed to generate collection query params: %v", err)
+	}
+
+	expected := map[string][2]string{
+		"Store":    {"$store_1#building_a", "$store_1#building_c"},
+		"Employee": {"$employee_1#building_a", "$employee_1#building_c"},
+	}
+	entitiesParams := params["entities"].(map[string]interface{})
+	for entityName, want := range expected {
+		entityParams := entitiesParams[entityName].(map[string]interface{})
+		if entityParams["KeyConditionExpression"] != "gsi1pk = :pk AND gsi1sk BETWEEN :sk1 AND :sk2" {
+			t.Errorf("%s: unexpected key condition %v", entityName, entityParams["KeyConditionExpression"])
+		}
+		values := entityParams["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+		if got := values[":sk1"].(*types.AttributeValueMemberS).Value; got != want[0] {
+			t.Errorf("%s: expected :sk1 %q, got %q", entityName, want[0], got)
+		}
+		if got := values[":sk2"].(*types.AttributeValueMemberS).Value; got != want[1] {
+			t.Errorf("%s: expected :sk2 %q, got %q", entityName, want[1], got)
+		}
+	}
+
+	// A facet only one entity defines cannot be composed for the other
+	_, err = mallCollection.Query("EastPointe").
+		Begins(Keys{"building": "A", "unit": "101"}).
+		Params()
+	if err == nil {
+		t.Fatal("Expected error for sort key facets incompatible with a collection entity")
+	}
+	if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != "InvalidCollectionQuery" {
+		t.Errorf("Expected InvalidCollectionQuery error, got %v", err)
+	}
+}
+
+func TestServiceWithClient(t *testing.T) {
+	service := NewService("TestService", &ServiceConfig{
+		Table: stringPtr("TestTable"),
+	})
+
+	newSchema := func(entity string) *Schema {
+		return &Schema{
+			Service: "TestService",
+			Entity:  entity,
+			Table:   "TestTable",
+			Attributes: map[string]*AttributeDefinition{
+				"id": {Type: AttributeTypeString, Required: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				},
+			},
+		}
+	}
+
+	ownCalls := 0
+	ownClient := &mockClient{
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			ownCalls++
+			return &dynamodb.GetItemOutput{}, nil
+		},
+	}
+
+	user, err := NewEntity(newSchema("User"), nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	product, err := NewEntity(newSchema("Product"), &Config{Client: ownClient})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	for _, entity := range []*Entity{user, product} {
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	if _, err := user.Get(Keys{"id": "1"}).Go(); err == nil {
+		t.Fatal("Expected NoClientProvided before the service has a client")
+	}
+
+	serviceCalls := 0
+	service.WithClient(&mockClient{
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			serviceCalls++
+			return &dynamodb.GetItemOutput{}, nil
+		},
+	})
+
+	if _, err := user.Get(Keys{"id": "1"}).Go(); err != nil {
+		t.Fatalf("Expected get to use the service client, got %v", err)
+	}
+	if serviceCalls != 1 {
+		t.Errorf("Expected 1 call on the service client, got %d", serviceCalls)
+	}
+
+	// Entities with their own client keep it
+	if _, err := product.Get(Keys{"id": "1"}).Go(); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if ownCalls != 1 || serviceCalls != 1 {
+		t.Errorf("Expected product to keep its own client, got own=%d service=%d", ownCalls, serviceCalls)
+	}
+}
+
+func TestCollectionQueryGoOrdered(t *testing.T) {
+	item := func(entity, building, facet, value string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"gsi1pk":   &types.AttributeValueMemberS{Value: "$mallservice#mall_eastpointe"},
+			"gsi1sk":   &types.AttributeValueMemberS{Value: "$" + entity + "_1#building_" + building + "#" + facet + "_" + value},
+			"id":       &types.AttributeValueMemberS{Value: entity + "-" + building},
+			"mall":     &types.AttributeValueMemberS{Value: "eastpointe"},
+			"building": &types.AttributeValueMemberS{Value: building},
+			facet:      &types.AttributeValueMemberS{Value: value},
+		}
+	}
+	stored := map[string][]map[string]types.AttributeValue{
+		"$store_1":    {item("store", "a", "unit", "1"), item("store", "b", "unit", "2")},
+		"$employee_1": {item("employee", "a", "name", "bob"), item("employee", "c", "name", "eve")},
+	}
+	// Each entity's items come back one per page
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			for _, value := range input.ExpressionAttributeValues {
+				s, ok := value.(*types.AttributeValueMemberS)
+				if !ok {
+					continue
+				}
+				for prefix, items := range stored {
+					if !strings.HasPrefix(s.Value, prefix) {
+						continue
+					}
+					page := 0
+					if input.ExclusiveStartKey != nil {
+						page = 1
+					}
+					output := &dynamodb.QueryOutput{Items: items[page : page+1]}
+					if page == 0 {
+						output.LastEvaluatedKey = items[0]
+					}
+					return output, nil
+				}
+			}
+			return &dynamodb.QueryOutput{}, nil
+		},
+	}
+
+	service := NewService("MallService", &ServiceConfig{
+		Client: client,
+		Table:  stringPtr("MallTable"),
+	})
+	newSchema := func(entity, facet, index string) *Schema {
+		return &Schema{
+			Service: "MallService",
+			Entity:  entity,
+			Table:   "MallTable",
+			Version: "1",
+			Attributes: map[string]*AttributeDefinition{
+				"id":       {Type: AttributeTypeString, Required: true},
+				"mall":     {Type: AttributeTypeString, Required: true},
+				"building": {Type: AttributeTypeString, Required: true},
+				facet:      {Type: AttributeTypeString, Required: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				},
+				index: {
+					Index:      stringPtr("gsi1pk-gsi1sk-index"),
+					Collection: stringPtr("mall"),
+					PK:         FacetDefinition{Field: "gsi1pk", Facets: []string{"mall"}},
+					SK:         &FacetDefinition{Field: "gsi1sk", Facets: []string{"building", facet}},
+				},
+			},
+		}
+	}
+	for _, schema := range []*Schema{newSchema("Store", "unit", "byMall"), newSchema("Employee", "name", "staff")} {
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	mallCollection, err := service.Collection("mall")
+	if err != nil {
+		t.Fatalf("Failed to get mall collection: %v", err)
+	}
+
+	expected := []string{"employee-a", "store-a", "store-b", "employee-c"}
+	entities := []string{"Employee", "Store", "Store", "Employee"}
+
+	result, err := mallCollection.Query("EastPointe").GoOrdered()
+	if err != nil {
+		t.Fatalf("GoOrdered failed: %v", err)
+	}
+	if len(result.Data) != len(expected) {
+		t.Fatalf("Expected %d items, got %d", len(expected), len(result.Data))
+	}
+	for i, got := range result.Data {
+		if got.Data["id"] != expected[i] || got.Entity != entities[i] {
+			t.Errorf("Item %d: expected %s (%s), got %v (%s)", i, expected[i], entities[i], got.Data["id"], got.Entity)
+		}
+		if _, ok := got.Data["gsi1sk"]; ok {
+			t.Errorf("Item %d: expected key fields to be stripped", i)
+		}
+	}
+
+	// Paging options are rejected, as every page is merged
+	var electroErr *ElectroError
+	limit := int32(1)
+	if _, err := mallCollection.Query("EastPointe").Options(&QueryOptions{Limit: &limit}).GoOrdered(); !errors.As(err, &electroErr) || electroErr.Code != ErrInvalidOperation {
+		t.Errorf("Expected InvalidOperation for Limit, got %v", err)
+	}
+
+	// IgnoreCursor only applies to the first page, so every page is still
+	// followed once
+	result, err = mallCollection.Query("EastPointe").Options(&QueryOptions{IgnoreCursor: true}).GoOrdered()
+	if err != nil {
+		t.Fatalf("GoOrdered failed: %v", err)
+	}
+	if len(result.Data) != len(expected) {
+		t.Errorf("Expected %d items with IgnoreCursor, got %d", len(expected), len(result.Data))
+	}
+
+	// Descending order reverses the interleaving
+	desc := "desc"
+	result, err = mallCollection.Query("EastPointe").Options(&QueryOptions{Order: &desc}).GoOrdered()
+	if err != nil {
+		t.Fatalf("GoOrdered failed: %v", err)
+	}
+	for i, got := range result.Data {
+		if want := expected[len(expected)-1-i]; got.Data["id"] != want {
+			t.Errorf("Descending item %d: expected %s, got %v", i, want, got.Data["id"])
+		}
+	}
+}
+
+func TestServicePartition(t *testing.T) {
+	pk := &types.AttributeValueMemberS{Value: "$orderservice#orderid_o1"}
+	pages := [][]map[string]types.AttributeValue{
+		{
+			{"pk": pk, "sk": &types.AttributeValueMemberS{Value: "$order_1"}, "orderId": &types.AttributeValueMemberS{Value: "o1"}, "status": &types.AttributeValueMemberS{Value: "open"}},
+			{"pk": pk, "sk": &types.AttributeValueMemberS{Value: "$line_1#lineid_l1"}, "orderId": &types.AttributeValueMemberS{Value: "o1"}, "lineId": &types.AttributeValueMemberS{Value: "l1"}},
+		},
+		{
+			{"pk": pk, "sk": &types.AttributeValueMemberS{Value: "$line_1#lineid_l2"}, "orderId": &types.AttributeValueMemberS{Value: "o1"}, "lineId": &types.AttributeValueMemberS{Value: "l2"}},
+			// Written by something outside the service
+			{"pk": pk, "sk": &types.AttributeValueMemberS{Value: "audit#1"}},
+		},
+	}
+	var inputs []*dynamodb.QueryInput
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			inputs = append(inputs, input)
+			output := &dynamodb.QueryOutput{Items: pages[len(inputs)-1]}
+			if len(inputs) < len(pages) {
+				output.LastEvaluatedKey = output.Items[len(output.Items)-1]
+			}
+			return output, nil
+		},
+	}
+
+	service := NewService("OrderService", &ServiceConfig{
+		Client: client,
+		Table:  stringPtr("OrderTable"),
+	})
+	schemas := []*Schema{
+		{
+			Service: "OrderService",
+			Entity:  "Order",
+			Table:   "OrderTable",
+			Version: "1",
+			Attributes: map[string]*AttributeDefinition{
+				"orderId": {Type: AttributeTypeString, Required: true},
+				"status":  {Type: AttributeTypeString},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+				},
+			},
+		},
+		{
+			Service: "OrderService",
+			Entity:  "Line",
+			Table:   "OrderTable",
+			Version: "1",
+			Attributes: map[string]*AttributeDefinition{
+				"orderId": {Type: AttributeTypeString, Required: true},
+				"lineId":  {Type: AttributeTypeString, Required: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{"lineId"}},
+				},
+			},
+		},
+	}
+	for _, schema := range schemas {
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	result, err := service.Partition("o1").Go()
+	if err != nil {
+		t.Fatalf("Partition failed: %v", err)
+	}
+
+	if len(inputs) != 2 {
+		t.Fatalf("Expected both pages to be read, got %d queries", len(inputs))
+	}
+	if input := inputs[0]; *input.KeyConditionExpression != "pk = :pk" || input.IndexName != nil {
+		t.Errorf("Expected a bare partition query on the base table, got %q", *input.KeyConditionExpression)
+	}
+	if got := inputs[0].ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value; got != pk.Value {
+		t.Errorf("Expected partition key %s, got %s", pk.Value, got)
+	}
+
+	if len(result.Data) != 2 || len(result.Data["Order"]) != 1 || len(result.Data["Line"]) != 2 {
+		t.Fatalf("Expected 1 order and 2 lines, got %v", result.Data)
+	}
+	if result.Data["Order"][0]["status"] != "open" {
+		t.Errorf("Expected the order item, got %v", result.Data["Order"][0])
+	}
+	if result.Data["Line"][0]["lineId"] != "l1" || result.Data["Line"][1]["lineId"] != "l2" {
+		t.Errorf("Expected lines in partition order, got %v", result.Data["Line"])
+	}
+	if _, ok := result.Data["Line"][0]["pk"]; ok {
+		t.Error("Expected key fields to be stripped")
+	}
+
+	if _, err := service.Partition("o1", "extra").Go(); err == nil {
+		t.Error("Expected an error for a facet count no entity uses")
+	}
+}
+
+// failingEncryptor encrypts nothing and fails every decryption
+type failingEncryptor struct{}
+
+func (failingEncryptor) Encrypt(attribute string, plaintext []byte) ([]byte, error) {
+	return plaintext, nil
+}
+
+func (failingEncryptor) Decrypt(attribute string, ciphertext []byte) ([]byte, error) {
+	return nil, errors.New("key unavailable")
+}
+
+func TestServicePartitionReturnsReadErrors(t *testing.T) {
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{{
+				"pk":      &types.AttributeValueMemberS{Value: "$orderservice#orderid_o1"},
+				"sk":      &types.AttributeValueMemberS{Value: "$order_1"},
+				"orderId": &types.AttributeValueMemberS{Value: "o1"},
+				"note":    &types.AttributeValueMemberB{Value: []byte("secret")},
+			}}}, nil
+		},
+	}
+	service := NewService("OrderService", &ServiceConfig{Client: client, Table: stringPtr("OrderTable")})
+	entity, err := NewEntity(&Schema{
+		Service: "OrderService",
+		Entity:  "Order",
+		Table:   "OrderTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"orderId": {Type: AttributeTypeString, Required: true},
+			"note":    {Type: AttributeTypeString, Encrypt: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}, &Config{Encryptor: failingEncryptor{}})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if err := service.Join(entity); err != nil {
+		t.Fatalf("Failed to join entity: %v", err)
+	}
+
+	// An owned item that cannot be read fails the query rather than vanishing
+	var electroErr *ElectroError
+	if _, err := service.Partition("o1").Go(); !errors.As(err, &electroErr) || electroErr.Code != ErrDecryptionFailed {
+		t.Errorf("Expected DecryptionFailed, got %v", err)
+	}
+}
+
+func TestServiceExecuteStatement(t *testing.T) {
+	var input *dynamodb.ExecuteStatementInput
+	client := &mockClient{
+		executeStatement: func(in *dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error) {
+			input = in
+			return &dynamodb.ExecuteStatementOutput{
+				Items: []map[string]types.AttributeValue{
+					{
+						"pk":      &types.AttributeValueMemberS{Value: "$orderservice#orderid_o1"},
+						"sk":      &types.AttributeValueMemberS{Value: "$order_1"},
+						"orderId": &types.AttributeValueMemberS{Value: "o1"},
+						"total":   &types.AttributeValueMemberN{Value: "42"},
+					},
+					{"pk": &types.AttributeValueMemberS{Value: "audit#1"}},
+				},
+				NextToken: stringPtr("next"),
+			}, nil
+		},
+	}
+
+	service := NewService("OrderService", &ServiceConfig{
+		Client: client,
+		Table:  stringPtr("OrderTable"),
+	})
+	entity, err := NewEntity(&Schema{
+		Service: "OrderService",
+		Entity:  "Order",
+		Table:   "OrderTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"orderId": {Type: AttributeTypeString, Required: true},
+			"total":   {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if err := service.Join(entity); err != nil {
+		t.Fatalf("Failed to join entity: %v", err)
+	}
+
+	result, err := service.ExecuteStatement(context.Background(), `SELECT * FROM "OrderTable" WHERE pk = ?`, "$orderservice#orderid_o1")
+	if err != nil {
+		t.Fatalf("ExecuteStatement failed: %v", err)
+	}
+
+	if *input.Statement != `SELECT * FROM "OrderTable" WHERE pk = ?` {
+		t.Errorf("Expected the statement to be passed through, got %s", *input.Statement)
+	}
+	if len(input.Parameters) != 1 || input.Parameters[0].(*types.AttributeValueMemberS).Value != "$orderservice#orderid_o1" {
+		t.Errorf("Expected one marshaled parameter, got %v", input.Parameters)
+	}
+
+	if len(result.Data["Order"]) != 1 {
+		t.Fatalf("Expected one decoded order, got %v", result.Data)
+	}
+	order := result.Data["Order"][0]
+	if order["orderId"] != "o1" || order["total"] != float64(42) {
+		t.Errorf("Expected the order decoded through the entity, got %v", order)
+	}
+	if _, ok := order["pk"]; ok {
+		t.Error("Expected key fields to be stripped from decoded rows")
+	}
+	if len(result.Unrecognized) != 1 || result.Unrecognized[0]["pk"] != "audit#1" {
+		t.Errorf("Expected the unowned row to be returned raw, got %v", result.Unrecognized)
+	}
+	if result.NextToken == nil || *result.NextToken != "next" {
+		t.Errorf("Expected NextToken to be returned, got %v", result.NextToken)
+	}
+}
+
+func TestServiceExecuteStatementErrors(t *testing.T) {
+	client := &mockClient{
+		executeStatement: func(*dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error) {
+			return &dynamodb.ExecuteStatementOutput{Items: []map[string]types.AttributeValue{{
+				"pk":      &types.AttributeValueMemberS{Value: "$orderservice#orderid_o1"},
+				"sk":      &types.AttributeValueMemberS{Value: "$order_1"},
+				"orderId": &types.AttributeValueMemberS{Value: "o1"},
+				"note":    &types.AttributeValueMemberB{Value: []byte("secret")},
+			}}}, nil
+		},
+	}
+	schema := &Schema{
+		Service: "OrderService",
+		Entity:  "Order",
+		Table:   "OrderTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"orderId": {Type: AttributeTypeString, Required: true},
+			"note":    {Type: AttributeTypeString, Encrypt: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+	newService := func(client DynamoDBClient) *Service {
+		service := NewService("OrderService", &ServiceConfig{Client: client, Table: stringPtr("OrderTable")})
+		entity, err := NewEntity(schema, &Config{Encryptor: failingEncryptor{}})
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+		return service
+	}
+	statement := `SELECT * FROM "OrderTable"`
+	var electroErr *ElectroError
+
+	// An owned row that cannot be read is an error, not an unrecognized row
+	if _, err := newService(client).ExecuteStatement(context.Background(), statement); !errors.As(err, &electroErr) || electroErr.Code != ErrDecryptionFailed {
+		t.Errorf("Expected DecryptionFailed, got %v", err)
+	}
+
+	// Clients are not required to implement ExecuteStatement
+	withoutStatements := struct{ DynamoDBClient }{client}
+	if _, err := newService(withoutStatements).ExecuteStatement(context.Background(), statement); !errors.As(err, &electroErr) || electroErr.Code != ErrInvalidOperation {
+		t.Errorf("Expected InvalidOperation for a client without ExecuteStatement, got %v", err)
+	}
+}
+
+func TestCollectionQueryPrefix(t *testing.T) {
+	var inputs []*dynamodb.QueryInput
+	client := &mockClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			inputs = append(inputs, input)
+			return &dynamodb.QueryOutput{}, nil
+		},
+	}
+	service := NewService("Calendar", &ServiceConfig{Client: client, Table: stringPtr("CalendarTable")})
+
+	schema := func(name string, facets ...string) *Schema {
+		attributes := map[string]*AttributeDefinition{
+			"id":     {Type: AttributeTypeString, Required: true},
+			"tenant": {Type: AttributeTypeString, Required: true},
+		}
+		for _, facet := range facets {
+			attributes[facet] = &AttributeDefinition{Type: AttributeTypeString, Required: true}
+		}
+		return &Schema{
+			Service:    "Calendar",
+			Entity:     name,
+			Table:      "CalendarTable",
+			Version:    "1",
+			Attributes: attributes,
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				},
+				"byDate": {
+					Index:      stringPtr("gsi1pk-gsi1sk-index"),
+					Collection: stringPtr("timeline"),
+					PK:         FacetDefinition{Field: "gsi1pk", Facets: []string{"tenant"}},
+					SK:         &FacetDefinition{Field: "gsi1sk", Facets: facets},
+				},
+			},
+		}
+	}
+	for _, s := range []*Schema{schema("Event", "date", "eventId"), schema("Note", "date", "noteId")} {
+		entity, err := NewEntity(s, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	timeline, err := service.Collection("timeline")
+	if err != nil {
+		t.Fatalf("Failed to get timeline collection: %v", err)
+	}
+	if _, err := timeline.QueryPrefix("2024-01", "acme").Go(); err != nil {
+		t.Fatalf("Collection query failed: %v", err)
+	}
+
+	if len(inputs) != 2 {
+		t.Fatalf("Expected one query per entity, got %d", len(inputs))
+	}
+	expected := map[string]bool{"$event_1#date_2024-01": true, "$note_1#date_2024-01": true}
+	for _, input := range inputs {
+		if *input.KeyConditionExpression != "gsi1pk = :pk AND begins_with(gsi1sk, :sk)" {
+			t.Errorf("Unexpected key condition %s", *input.KeyConditionExpression)
+		}
+		prefix := input.ExpressionAttributeValues[":sk"].(*types.AttributeValueMemberS).Value
+		if !expected[prefix] {
+			t.Errorf("Unexpected sort key prefix %q", prefix)
+		}
+		delete(expected, prefix)
+	}
+
+	// A second sort key condition would silently become a filter
+	_, err = timeline.QueryPrefix("2024-01", "acme").Gte(Keys{"date": "2024-01-15"}).Params()
+	if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != "InvalidCollectionQuery" {
+		t.Errorf("Expected InvalidCollectionQuery for QueryPrefix with Gte, got %v", err)
+	}
+
+	// Entities whose sort keys start with different facets cannot share a prefix
+	other, err := NewEntity(schema("Task", "dueDate", "taskId"), nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if err := service.Join(other); err != nil {
+		t.Fatalf("Failed to join entity: %v", err)
+	}
+	_, err = timeline.QueryPrefix("2024-01", "acme").Params()
+	if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != "InvalidCollectionQuery" {
+		t.Errorf("Expected InvalidCollectionQuery error, got %v", err)
+	}
+}
+
+func TestCollectionQueryPrefixIsNotPadded(t *testing.T) {
+	service := NewService("Archive", &ServiceConfig{Client: &mockClient{}, Table: stringPtr("ArchiveTable")})
+	schema := &Schema{
+		Service: "Archive",
+		Entity:  "Record",
+		Table:   "ArchiveTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"id":     {Type: AttributeTypeString, Required: true},
+			"tenant": {Type: AttributeTypeString, Required: true},
+			"year":   {Type: AttributeTypeString, Required: true, Padding: &PaddingConfig{Length: 4, Char: "0"}},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+			"byYear": {
+				Index:      stringPtr("gsi1pk-gsi1sk-index"),
+				Collection: stringPtr("history"),
+				PK:         FacetDefinition{Field: "gsi1pk", Facets: []string{"tenant"}},
+				SK:         &FacetDefinition{Field: "gsi1sk", Facets: []string{"year", "id"}},
+			},
+		},
+	}
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if err := service.Join(entity); err != nil {
+		t.Fatalf("Failed to join entity: %v", err)
+	}
+	history, err := service.Collection("history")
+	if err != nil {
+		t.Fatalf("Failed to get history collection: %v", err)
+	}
+
+	// "20" selects the years 2000-2099, so it must not become "0020"
+	params, err := history.QueryPrefix("20", "acme").Params()
+	if err != nil {
+		t.Fatalf("Failed to build params: %v", err)
+	}
+	record := params["entities"].(map[string]interface{})["Record"].(map[string]interface{})
+	values := record["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if sk := values[":sk"].(*types.AttributeValueMemberS).Value; sk != "$record_1#year_20" {
+		t.Errorf("Expected an unpadded prefix, got %s", sk)
+	}
+}
+
+func TestServiceJoinDoesNotModifyEntityConfig(t *testing.T) {
+	newSchema := func(name string) *Schema {
+		return &Schema{
+			Service: "TestService",
+			Entity:  name,
+			Table:   "TestTable",
+			Attributes: map[string]*AttributeDefinition{
+				"id":   {Type: AttributeTypeString, Required: true},
+				"name": {Type: AttributeTypeString},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				},
+			},
+		}
+	}
+	exclaim := func(name string, value interface{}) interface{} {
+		if str, ok := value.(string); ok {
+			return str + "!"
+		}
+		return value
+	}
+
+	// Two entities share one Config
+	shared := &Config{}
+	users, err := NewEntity(newSchema("User"), shared)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	admins, err := NewEntity(newSchema("Admin"), shared)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	first := NewService("TestService", &ServiceConfig{WriteTransforms: []AttributeTransformFunc{exclaim}})
+	second := NewService("TestService", &ServiceConfig{WriteTransforms: []AttributeTransformFunc{exclaim}})
+	for _, join := range []struct {
+		service *Service
+		entity  *Entity
+	}{{first, users}, {first, admins}, {second, users}} {
+		if err := join.service.Join(join.entity); err != nil {
+			t.Fatalf("Failed to join: %v", err)
+		}
+	}
+
+	// Each transform runs once, however often the entity or its Config joins
+	for _, entity := range []*Entity{users, admins} {
+		result, err := NewValidator(entity).ValidateAndTransformForWrite(Item{"id": "u1", "name": "Ada"}, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result["name"] != "Ada!" {
+			t.Errorf("%s: expected the service transform once, got %q", entity.schema.Entity, result["name"])
+		}
+	}
+	if len(shared.WriteTransforms) != 0 {
+		t.Errorf("Expected the shared Config to be left alone, got %d transforms", len(shared.WriteTransforms))
+	}
+}
+
+func TestServiceJoinChainsHooksOnce(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	var calls []string
+	hook := func(label string) BeforeWriteFunc {
+		return func(op string, item Item) (Item, error) {
+			calls = append(calls, label)
+			return item, nil
+		}
+	}
+	afterCalls := 0
+	config := &Config{Client: &mockClient{}, BeforeWrite: hook("entity")}
+	entity, err := NewEntity(schema, config)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	for _, label := range []string{"first", "second"} {
+		service := NewService("TestService", &ServiceConfig{
+			BeforeWrite: hook(label),
+			AfterWrite:  func(op string, resp interface{}) { afterCalls++ },
+		})
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join: %v", err)
+		}
+	}
+
+	if _, err := entity.Put(Item{"id": "u1"}).Go(); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if strings.Join(calls, ",") != "second,entity" {
+		t.Errorf("Expected the last service's hook and the entity's hook once each, got %v", calls)
+	}
+	if afterCalls != 1 {
+		t.Errorf("Expected AfterWrite to run once, got %d", afterCalls)
+	}
+
+	// The entity's own Config keeps only its own hook
+	calls = nil
+	if _, err := config.BeforeWrite("put", Item{}); err != nil || strings.Join(calls, ",") != "entity" {
+		t.Errorf("Expected the Config's hook to be unchanged, got %v", calls)
+	}
+}
@@ -1,6 +1,7 @@
 package electrodb
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -357,3 +358,74 @@ func TestAllUpdateOperations(t *testing.T) {
 		t.Errorf("Expected UpdateExpression to contain 'REMOVE', got: %s", updateExpr)
 	}
 }
+
+func TestSetOperationValidation(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"userId": {Type: AttributeTypeString, Required: true},
+			"name":   {Type: AttributeTypeString},
+			"count":  {Type: AttributeTypeNumber},
+			"tags":   {Type: AttributeTypeSet},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"userId"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		op   func(*UpdateOperation) *UpdateOperation
+		code string
+	}{
+		{"add to string attribute", func(u *UpdateOperation) *UpdateOperation {
+			return u.AddToSet("name", []string{"a"})
+		}, ErrNotASetAttribute},
+		{"add list to undeclared attribute", func(u *UpdateOperation) *UpdateOperation {
+			return u.AddToSet("extra", []string{"a"})
+		}, ErrNotASetAttribute},
+		{"delete from number attribute", func(u *UpdateOperation) *UpdateOperation {
+			return u.DeleteFromSet("count", []int{1})
+		}, ErrNotASetAttribute},
+		{"mixed element types", func(u *UpdateOperation) *UpdateOperation {
+			return u.AddToSet("tags", []interface{}{"a", 1})
+		}, ErrInvalidSetValue},
+		{"scalar set value", func(u *UpdateOperation) *UpdateOperation {
+			return u.DeleteFromSet("tags", "a")
+		}, ErrInvalidSetValue},
+		{"empty set value", func(u *UpdateOperation) *UpdateOperation {
+			return u.AddToSet("tags", []string{})
+		}, ErrInvalidSetValue},
+		{"homogeneous set", func(u *UpdateOperation) *UpdateOperation {
+			return u.AddToSet("tags", []interface{}{"a", "b"})
+		}, ""},
+		{"number increment", func(u *UpdateOperation) *UpdateOperation {
+			return u.Add(map[string]interface{}{"count": 1})
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.op(entity.Update(Keys{"userId": "user123"})).Params()
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			var electroErr *ElectroError
+			if !errors.As(err, &electroErr) || electroErr.Code != tt.code {
+				t.Fatalf("Expected %s error, got %v", tt.code, err)
+			}
+		})
+	}
+}
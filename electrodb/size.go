@@ -0,0 +1,93 @@
+package electrodb
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MaxItemSize is DynamoDB's maximum item size in bytes (400KB)
+const MaxItemSize = 400 * 1024
+
+// ItemSize returns the size in bytes DynamoDB would count for the item as it
+// would be written by Put: defaults, timestamps, padding, transforms and
+// composed keys included. Sizes follow DynamoDB's rules, summing the UTF-8
+// length of each attribute name and the size of its value.
+func (e *Entity) ItemSize(item Item) (int, error) {
+	av, err := NewParamsBuilder(e).buildPutItem(item, nil)
+	if err != nil {
+		return 0, err
+	}
+	return itemSize(av), nil
+}
+
+// itemSize sums the sizes of a marshaled item's attributes
+func itemSize(item map[string]types.AttributeValue) int {
+	size := 0
+	for name, value := range item {
+		size += len(name) + attributeValueSize(value)
+	}
+	return size
+}
+
+// attributeValueSize returns the size of a single value, excluding its name
+func attributeValueSize(value types.AttributeValue) int {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return numberSize(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberBOOL, *types.AttributeValueMemberNULL:
+		return 1
+	case *types.AttributeValueMemberSS:
+		size := 0
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		size := 0
+		for _, n := range v.Value {
+			size += numberSize(n)
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		size := 0
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		// 3 bytes of overhead plus 1 byte per element
+		size := 3
+		for _, element := range v.Value {
+			size += 1 + attributeValueSize(element)
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		// 3 bytes of overhead plus 1 byte per entry
+		size := 3
+		for name, element := range v.Value {
+			size += 1 + len(name) + attributeValueSize(element)
+		}
+		return size
+	}
+	return 0
+}
+
+// numberSize approximates a number's size as 1 byte per two significant
+// digits plus 1 byte
+func numberSize(n string) int {
+	if i := strings.IndexAny(n, "eE"); i >= 0 {
+		n = n[:i]
+	}
+	n = strings.TrimLeft(n, "+-")
+	n = strings.Replace(n, ".", "", 1)
+	n = strings.Trim(n, "0")
+	if n == "" {
+		n = "0"
+	}
+	return (len(n)+1)/2 + 1
+}
@@ -0,0 +1,79 @@
+package electrodb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestItemSize(t *testing.T) {
+	newEntity := func(config *Config) *Entity {
+		schema := &Schema{
+			Service: "TestService",
+			Entity:  "User",
+			Table:   "TestTable",
+			Version: "1",
+			Attributes: map[string]*AttributeDefinition{
+				"id":   {Type: AttributeTypeString, Required: true},
+				"name": {Type: AttributeTypeString},
+				"age":  {Type: AttributeTypeNumber},
+				"tags": {Type: AttributeTypeList},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+				},
+			},
+		}
+		entity, err := NewEntity(schema, config)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		return entity
+	}
+
+	entity := newEntity(nil)
+	size, err := entity.ItemSize(Item{"id": "u1", "name": "alice", "age": 42, "tags": []interface{}{"a", "bc"}})
+	if err != nil {
+		t.Fatalf("ItemSize failed: %v", err)
+	}
+	// pk "$testservice#id_u1" (2+18), sk "$user_1" (2+7), id (2+2),
+	// name (4+5), age 42 (3+2), tags (4) holding a list (3+(1+1)+(1+2))
+	expected := 20 + 9 + 4 + 9 + 5 + 12
+	if size != expected {
+		t.Errorf("Expected size %d, got %d", expected, size)
+	}
+
+	// Number sizes count significant digits
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"0", 2},
+		{"7", 2},
+		{"12345", 4},
+		{"-0.00120", 2},
+		{"1000", 2},
+	}
+	for _, tt := range tests {
+		if got := attributeValueSize(&types.AttributeValueMemberN{Value: tt.value}); got != tt.expected {
+			t.Errorf("Expected size %d for %s, got %d", tt.expected, tt.value, got)
+		}
+	}
+
+	// The guard rejects oversized puts only when enabled
+	large := Item{"id": "u1", "name": strings.Repeat("x", MaxItemSize)}
+	if _, err := entity.Put(large).Params(); err != nil {
+		t.Errorf("Expected no size check without EnforceItemSize, got %v", err)
+	}
+	guarded := newEntity(&Config{EnforceItemSize: true})
+	_, err = guarded.Put(large).Params()
+	if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != ErrItemTooLarge {
+		t.Errorf("Expected ItemTooLarge error, got %v", err)
+	}
+	if _, err := guarded.Put(Item{"id": "u1", "name": "alice"}).Params(); err != nil {
+		t.Errorf("Expected a small item to pass the guard, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package electrodb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/execute008/goelectrodb/electrodb/internal"
+)
+
+// FromStreamImage decodes a DynamoDB Streams NEW_IMAGE or OLD_IMAGE (or any
+// raw item) into the entity's formatted item: keys are stripped, padding is
+// removed and read transforms are applied. Images written by another entity
+// return an EntityMismatch error.
+func (e *Entity) FromStreamImage(image map[string]types.AttributeValue) (map[string]interface{}, error) {
+	if !e.OwnsImage(image) {
+		return nil, NewElectroError("EntityMismatch",
+			fmt.Sprintf("Image does not belong to entity '%s'", e.schema.Entity), nil)
+	}
+
+	var item map[string]interface{}
+	if err := attributevalue.UnmarshalMap(image, &item); err != nil {
+		return nil, NewElectroError("UnmarshalError", "Failed to unmarshal stream image", err)
+	}
+
+	return NewExecutionHelper(e).formatItem(item, false)
+}
+
+// ClassifyItem finds the joined entity that owns a raw item or stream image
+// and returns its name with the decoded item. Entities are checked in name
+// order; an image no entity owns returns an EntityNotFound error.
+func (s *Service) ClassifyItem(image map[string]types.AttributeValue) (string, map[string]interface{}, error) {
+	names := make([]string, 0, len(s.entities))
+	for name := range s.entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entity := s.entities[name]
+		if !entity.OwnsImage(image) {
+			continue
+		}
+		item, err := entity.FromStreamImage(image)
+		if err != nil {
+			return "", nil, err
+		}
+		return name, item, nil
+	}
+
+	return "", nil, NewElectroError("EntityNotFound",
+		fmt.Sprintf("No entity in service '%s' owns the image", s.name), nil)
+}
+
+// isEntityNotFound reports whether err is ClassifyItem's EntityNotFound
+// error for an image no joined entity owns
+func isEntityNotFound(err error) bool {
+	var electroErr *ElectroError
+	return errors.As(err, &electroErr) && electroErr.Code == ErrEntityNotFound
+}
+
+// OwnsImage reports whether a raw item was written by this entity. The
+// configured entity identifier attribute is used when present; otherwise the
+// primary index keys are matched against the entity's key layout.
+func (e *Entity) OwnsImage(image map[string]types.AttributeValue) bool {
+	if e.config.Identifiers != nil && e.config.Identifiers.Entity != "" {
+		if id, ok := image[e.config.Identifiers.Entity].(*types.AttributeValueMemberS); ok {
+			return id.Value == e.schema.Entity
+		}
+	}
+
+	index := e.primaryIndex()
+	if index == nil {
+		return false
+	}
+
+	delimiters := e.keyDelimiters()
+	pk, ok := image[index.PK.Field].(*types.AttributeValueMemberS)
+	if !ok || !matchesKeyLayout(pk.Value, delimiters, delimiters.PartitionKeyPrefix(e.schema.Service), index.PK.Facets) {
+		return false
+	}
+
+	if index.SK != nil {
+		sk, ok := image[index.SK.Field].(*types.AttributeValueMemberS)
+		if !ok || !matchesKeyLayout(sk.Value, delimiters, delimiters.SortKeyPrefix(e.schema.Entity, e.schema.Version), index.SK.Facets) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// primaryIndex returns the index without an Index name, if any
+func (e *Entity) primaryIndex() *IndexDefinition {
+	for _, index := range e.schema.Indexes {
+		if index.Index == nil {
+			return index
+		}
+	}
+	return nil
+}
+
+// matchesKeyLayout reports whether a composed key starts with prefix and
+// carries exactly the facet labels in order
+func matchesKeyLayout(key string, delimiters internal.Delimiters, prefix string, facets []string) bool {
+	key = strings.ToLower(key)
+	if len(facets) == 0 {
+		rest := strings.TrimPrefix(key, prefix)
+		return strings.HasPrefix(key, prefix) && (rest == "" || strings.HasPrefix(rest, delimiters.Facet))
+	}
+	values, ok := internal.ParseKey(key, prefix, internal.BuildLabels(facets), delimiters)
+	return ok && len(values) == len(facets)
+}
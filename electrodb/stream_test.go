@@ -0,0 +1,147 @@
+package electrodb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestFromStreamImage(t *testing.T) {
+	newEntity := func(name string) *Entity {
+		schema := &Schema{
+			Service: "TestService",
+			Entity:  name,
+			Table:   "TestTable",
+			Version: "1",
+			Attributes: map[string]*AttributeDefinition{
+				"id":     {Type: AttributeTypeString, Required: true},
+				"name":   {Type: AttributeTypeString},
+				"rank":   {Type: AttributeTypeNumber, Padding: &PaddingConfig{Length: 4}},
+				"secret": {Type: AttributeTypeString, Hidden: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+				},
+			},
+		}
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		return entity
+	}
+
+	user := newEntity("User")
+	team := newEntity("Team")
+
+	params, err := user.Put(Item{"id": "u1", "name": "alice", "rank": 7, "secret": "x"}).Params()
+	if err != nil {
+		t.Fatalf("Failed to build put params: %v", err)
+	}
+	newImage := params["Item"].(map[string]types.AttributeValue)
+
+	item, err := user.FromStreamImage(newImage)
+	if err != nil {
+		t.Fatalf("Failed to decode stream image: %v", err)
+	}
+	if item["id"] != "u1" || item["name"] != "alice" {
+		t.Errorf("Unexpected decoded item: %v", item)
+	}
+	if item["rank"] != int64(7) {
+		t.Errorf("Expected padding to be removed, got %#v", item["rank"])
+	}
+	for _, field := range []string{"pk", "sk", "secret"} {
+		if _, ok := item[field]; ok {
+			t.Errorf("Expected %s to be stripped from the decoded item", field)
+		}
+	}
+
+	if !user.OwnsImage(newImage) {
+		t.Error("Expected user to own its own image")
+	}
+	if team.OwnsImage(newImage) {
+		t.Error("Expected team not to own a user image")
+	}
+	_, err = team.FromStreamImage(newImage)
+	if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != "EntityMismatch" {
+		t.Errorf("Expected EntityMismatch error, got %v", err)
+	}
+}
+
+func TestServiceClassifyItem(t *testing.T) {
+	newEntity := func(name, facet string) *Entity {
+		schema := &Schema{
+			Service: "TestService",
+			Entity:  name,
+			Table:   "TestTable",
+			Version: "1",
+			Attributes: map[string]*AttributeDefinition{
+				facet:  {Type: AttributeTypeString, Required: true},
+				"name": {Type: AttributeTypeString},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{facet}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+				},
+			},
+		}
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		return entity
+	}
+
+	user := newEntity("User", "userId")
+	team := newEntity("Team", "teamId")
+	service := NewService("TestService", nil)
+	for _, entity := range []*Entity{user, team} {
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	image := func(entity *Entity, item Item) map[string]types.AttributeValue {
+		params, err := entity.Put(item).Params()
+		if err != nil {
+			t.Fatalf("Failed to build put params: %v", err)
+		}
+		return params["Item"].(map[string]types.AttributeValue)
+	}
+
+	tests := []struct {
+		image    map[string]types.AttributeValue
+		expected string
+		field    string
+	}{
+		{image(user, Item{"userId": "u1", "name": "alice"}), "User", "userId"},
+		{image(team, Item{"teamId": "t1", "name": "core"}), "Team", "teamId"},
+	}
+	for _, tt := range tests {
+		name, item, err := service.ClassifyItem(tt.image)
+		if err != nil {
+			t.Fatalf("Failed to classify %s image: %v", tt.expected, err)
+		}
+		if name != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, name)
+		}
+		if _, ok := item[tt.field]; !ok {
+			t.Errorf("Expected decoded %s item to contain %s, got %v", tt.expected, tt.field, item)
+		}
+		if _, ok := item["pk"]; ok {
+			t.Errorf("Expected keys to be stripped from %s item", tt.expected)
+		}
+	}
+
+	unknown := map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "$otherservice#id_1"},
+		"sk": &types.AttributeValueMemberS{Value: "$order_1"},
+	}
+	_, _, err := service.ClassifyItem(unknown)
+	if electroErr, ok := err.(*ElectroError); !ok || electroErr.Code != ErrEntityNotFound {
+		t.Errorf("Expected EntityNotFound error, got %v", err)
+	}
+}
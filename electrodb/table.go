@@ -0,0 +1,183 @@
+package electrodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TableDescriber is the part of the DynamoDB client used to inspect a table;
+// *dynamodb.Client satisfies it
+type TableDescriber interface {
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+}
+
+// ValidateAgainstTable describes the schema's table and checks that the
+// primary key and every secondary index the schema declares exist with the
+// same key fields, typed as strings since keys are composed strings. All
+// problems are reported in one SchemaTableMismatch error.
+func (s *Schema) ValidateAgainstTable(ctx context.Context, client TableDescriber) error {
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &s.Table})
+	if err != nil {
+		return NewElectroError("DynamoDBError", "Failed to execute DescribeTable", err)
+	}
+	table := output.Table
+	if table == nil {
+		return NewElectroError("SchemaTableMismatch", fmt.Sprintf("Table '%s' was not described", s.Table), nil)
+	}
+
+	fieldTypes := make(map[string]types.ScalarAttributeType)
+	for _, def := range table.AttributeDefinitions {
+		if def.AttributeName != nil {
+			fieldTypes[*def.AttributeName] = def.AttributeType
+		}
+	}
+
+	tableIndexes := make(map[string][]types.KeySchemaElement)
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		if gsi.IndexName != nil {
+			tableIndexes[*gsi.IndexName] = gsi.KeySchema
+		}
+	}
+	for _, lsi := range table.LocalSecondaryIndexes {
+		if lsi.IndexName != nil {
+			tableIndexes[*lsi.IndexName] = lsi.KeySchema
+		}
+	}
+
+	var problems []string
+	for _, indexName := range sortedIndexNames(s.Indexes) {
+		index := s.Indexes[indexName]
+		keySchema := table.KeySchema
+		if index.Index != nil {
+			var exists bool
+			if keySchema, exists = tableIndexes[*index.Index]; !exists {
+				problems = append(problems, fmt.Sprintf("index '%s': table has no index named '%s'", indexName, *index.Index))
+				continue
+			}
+		}
+		problems = append(problems, checkKeySchema(indexName, index, keySchema, fieldTypes)...)
+	}
+
+	if len(problems) > 0 {
+		return NewElectroError("SchemaTableMismatch",
+			fmt.Sprintf("Schema does not match table '%s': %s", s.Table, strings.Join(problems, "; ")), nil)
+	}
+	return nil
+}
+
+// checkKeySchema compares an index's key fields with a table key schema
+func checkKeySchema(indexName string, index *IndexDefinition, keySchema []types.KeySchemaElement, fieldTypes map[string]types.ScalarAttributeType) []string {
+	var hash, rang string
+	for _, element := range keySchema {
+		if element.AttributeName == nil {
+			continue
+		}
+		switch element.KeyType {
+		case types.KeyTypeHash:
+			hash = *element.AttributeName
+		case types.KeyTypeRange:
+			rang = *element.AttributeName
+		}
+	}
+
+	var problems []string
+	if hash != index.PK.Field {
+		problems = append(problems, fmt.Sprintf("index '%s': partition key is '%s' in the table but '%s' in the schema", indexName, hash, index.PK.Field))
+	}
+	skField := ""
+	if index.SK != nil {
+		skField = index.SK.Field
+	}
+	if rang != skField {
+		problems = append(problems, fmt.Sprintf("index '%s': sort key is '%s' in the table but '%s' in the schema", indexName, rang, skField))
+	}
+
+	for _, field := range []string{hash, rang} {
+		if field == "" {
+			continue
+		}
+		if fieldType, ok := fieldTypes[field]; ok && fieldType != types.ScalarAttributeTypeS {
+			problems = append(problems, fmt.Sprintf("index '%s': key field '%s' has type %s, expected S", indexName, field, fieldType))
+		}
+	}
+	return problems
+}
+
+// pingKey is the sentinel key value read by Ping when the client cannot
+// describe tables; no item is expected under it
+const pingKey = "$electrodb#ping"
+
+// Ping verifies connectivity and that the entity's table exists. Clients
+// implementing TableDescriber are asked to describe the table; others read a
+// sentinel key. A missing table is reported as TableNotFound.
+func (e *Entity) Ping(ctx context.Context) error {
+	if e.client == nil {
+		return NewElectroError("NoClientProvided", "No DynamoDB client was provided to the entity", nil)
+	}
+
+	tableName := NewParamsBuilder(e).getTableName()
+	if describer, ok := e.client.(TableDescriber); ok {
+		_, err := describer.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &tableName})
+		return pingError(tableName, "DescribeTable", err)
+	}
+
+	index := e.primaryIndex()
+	if index == nil {
+		return NewElectroError("InvalidSchema", "Entity has no primary index", nil)
+	}
+	key := map[string]types.AttributeValue{
+		index.PK.Field: &types.AttributeValueMemberS{Value: pingKey},
+	}
+	if index.SK != nil {
+		key[index.SK.Field] = &types.AttributeValueMemberS{Value: pingKey}
+	}
+	_, err := e.client.GetItem(ctx, &dynamodb.GetItemInput{TableName: &tableName, Key: key})
+	return pingError(tableName, "GetItem", err)
+}
+
+// Ping verifies connectivity and that every table used by the service's
+// entities exists, pinging each table once
+func (s *Service) Ping(ctx context.Context) error {
+	if s.client == nil {
+		return NewElectroError("NoClientProvided", "No DynamoDB client was provided to the service", nil)
+	}
+
+	names := make([]string, 0, len(s.entities))
+	for name := range s.entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pinged := make(map[string]bool)
+	for _, name := range names {
+		entity := s.entities[name]
+		tableName := NewParamsBuilder(entity).getTableName()
+		if pinged[tableName] {
+			continue
+		}
+		pinged[tableName] = true
+
+		if err := entity.Ping(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pingError classifies the error of a ping call
+func pingError(tableName, operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return NewElectroError("TableNotFound", fmt.Sprintf("Table '%s' does not exist", tableName), err)
+	}
+	return NewElectroError("DynamoDBError", fmt.Sprintf("Failed to execute %s", operation), err)
+}
@@ -0,0 +1,185 @@
+package electrodb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type describeFunc func(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+
+func (f describeFunc) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return f(ctx, params, optFns...)
+}
+
+func TestValidateAgainstTable(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Task",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":     {Type: AttributeTypeString, Required: true},
+			"status": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+			"byStatus": {
+				Index: stringPtr("gsi1"),
+				PK:    FacetDefinition{Field: "gsi1pk", Facets: []string{"status"}},
+				SK:    &FacetDefinition{Field: "gsi1sk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	key := func(name string, keyType types.KeyType) types.KeySchemaElement {
+		return types.KeySchemaElement{AttributeName: stringPtr(name), KeyType: keyType}
+	}
+	attr := func(name string, attrType types.ScalarAttributeType) types.AttributeDefinition {
+		return types.AttributeDefinition{AttributeName: stringPtr(name), AttributeType: attrType}
+	}
+	describe := func(table *types.TableDescription) describeFunc {
+		return func(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+			if *params.TableName != "TestTable" {
+				t.Errorf("Expected TestTable to be described, got %s", *params.TableName)
+			}
+			return &dynamodb.DescribeTableOutput{Table: table}, nil
+		}
+	}
+
+	matching := &types.TableDescription{
+		KeySchema: []types.KeySchemaElement{key("pk", types.KeyTypeHash), key("sk", types.KeyTypeRange)},
+		AttributeDefinitions: []types.AttributeDefinition{
+			attr("pk", types.ScalarAttributeTypeS), attr("sk", types.ScalarAttributeTypeS),
+			attr("gsi1pk", types.ScalarAttributeTypeS), attr("gsi1sk", types.ScalarAttributeTypeS),
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{{
+			IndexName: stringPtr("gsi1"),
+			KeySchema: []types.KeySchemaElement{key("gsi1pk", types.KeyTypeHash), key("gsi1sk", types.KeyTypeRange)},
+		}},
+	}
+	if err := schema.ValidateAgainstTable(context.Background(), describe(matching)); err != nil {
+		t.Fatalf("Expected matching table to validate, got %v", err)
+	}
+
+	mismatched := &types.TableDescription{
+		KeySchema: []types.KeySchemaElement{key("pk", types.KeyTypeHash), key("sk", types.KeyTypeRange)},
+		AttributeDefinitions: []types.AttributeDefinition{
+			attr("pk", types.ScalarAttributeTypeS), attr("sk", types.ScalarAttributeTypeN),
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{{
+			IndexName: stringPtr("gsi2"),
+			KeySchema: []types.KeySchemaElement{key("gsi2pk", types.KeyTypeHash)},
+		}},
+	}
+	err := schema.ValidateAgainstTable(context.Background(), describe(mismatched))
+	var electroErr *ElectroError
+	if !errors.As(err, &electroErr) || electroErr.Code != ErrSchemaTableMismatch {
+		t.Fatalf("Expected SchemaTableMismatch, got %v", err)
+	}
+	for _, want := range []string{"no index named 'gsi1'", "key field 'sk' has type N"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %s", want, err.Error())
+		}
+	}
+}
+
+// describingClient is a mock client that can also describe tables
+type describingClient struct {
+	*mockClient
+	describeFunc
+}
+
+func TestPing(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Task",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id": {Type: AttributeTypeString, Required: true},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+	notFound := &types.ResourceNotFoundException{Message: stringPtr("Requested resource not found")}
+	ctx := context.Background()
+
+	// Without DescribeTable, a sentinel key is read
+	var gets []*dynamodb.GetItemInput
+	var getErr error
+	client := &mockClient{
+		getItem: func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			gets = append(gets, input)
+			return &dynamodb.GetItemOutput{}, getErr
+		},
+	}
+	entity, err := NewEntity(schema, &Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if err := entity.Ping(ctx); err != nil {
+		t.Fatalf("Expected ping to succeed, got %v", err)
+	}
+	if len(gets) != 1 || *gets[0].TableName != "TestTable" || gets[0].Key["pk"] == nil || gets[0].Key["sk"] == nil {
+		t.Fatalf("Expected one sentinel GetItem on TestTable, got %v", gets)
+	}
+	getErr = notFound
+	var electroErr *ElectroError
+	if err := entity.Ping(ctx); !errors.As(err, &electroErr) || electroErr.Code != ErrTableNotFound {
+		t.Errorf("Expected TableNotFound, got %v", err)
+	}
+
+	// With DescribeTable, the table is described instead
+	var described []string
+	var describeErr error
+	describer := &describingClient{
+		mockClient: &mockClient{
+			getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+				t.Error("Expected no GetItem when the client can describe tables")
+				return &dynamodb.GetItemOutput{}, nil
+			},
+		},
+		describeFunc: func(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+			described = append(described, *params.TableName)
+			return &dynamodb.DescribeTableOutput{}, describeErr
+		},
+	}
+
+	service := NewService("TestService", &ServiceConfig{Client: describer})
+	for _, name := range []string{"Task", "Note"} {
+		entitySchema := *schema
+		entitySchema.Entity = name
+		entity, err := NewEntity(&entitySchema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+	if err := service.Ping(ctx); err != nil {
+		t.Fatalf("Expected service ping to succeed, got %v", err)
+	}
+	if len(described) != 1 || described[0] != "TestTable" {
+		t.Errorf("Expected the shared table to be described once, got %v", described)
+	}
+
+	describeErr = notFound
+	if err := service.Ping(ctx); !errors.As(err, &electroErr) || electroErr.Code != ErrTableNotFound {
+		t.Errorf("Expected TableNotFound, got %v", err)
+	}
+	describeErr = errors.New("connection refused")
+	if err := service.Ping(ctx); !errors.As(err, &electroErr) || electroErr.Code != ErrDynamoDB {
+		t.Errorf("Expected DynamoDBError, got %v", err)
+	}
+}
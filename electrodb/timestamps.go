@@ -7,6 +7,11 @@ import (
 // ApplyTimestamps applies automatic timestamps to an item
 // This is called during Put/Create operations
 func ApplyTimestamps(item Item, schema *Schema, isUpdate bool) Item {
+	return applyTimestampsAt(item, schema, isUpdate, time.Now())
+}
+
+// applyTimestampsAt applies automatic timestamps as of now
+func applyTimestampsAt(item Item, schema *Schema, isUpdate bool, now time.Time) Item {
 	if schema.Timestamps == nil {
 		return item
 	}
@@ -16,19 +21,17 @@ func ApplyTimestamps(item Item, schema *Schema, isUpdate bool) Item {
 		result[k] = v
 	}
 
-	now := time.Now().Unix()
-
 	// Set createdAt only on create (not on update)
 	if !isUpdate && schema.Timestamps.CreatedAt != "" {
 		// Only set if not already present (user may have provided it)
 		if _, exists := result[schema.Timestamps.CreatedAt]; !exists {
-			result[schema.Timestamps.CreatedAt] = now
+			result[schema.Timestamps.CreatedAt] = now.Unix()
 		}
 	}
 
 	// Set updatedAt on both create and update
 	if schema.Timestamps.UpdatedAt != "" {
-		result[schema.Timestamps.UpdatedAt] = now
+		result[schema.Timestamps.UpdatedAt] = now.Unix()
 	}
 
 	return result
@@ -37,6 +40,11 @@ func ApplyTimestamps(item Item, schema *Schema, isUpdate bool) Item {
 // ApplyUpdateTimestamps applies automatic timestamps to update operations
 // This adds updatedAt to SET operations
 func ApplyUpdateTimestamps(setOps map[string]interface{}, schema *Schema) map[string]interface{} {
+	return applyUpdateTimestampsAt(setOps, schema, time.Now())
+}
+
+// applyUpdateTimestampsAt applies automatic update timestamps as of now
+func applyUpdateTimestampsAt(setOps map[string]interface{}, schema *Schema, now time.Time) map[string]interface{} {
 	if schema.Timestamps == nil || schema.Timestamps.UpdatedAt == "" {
 		return setOps
 	}
@@ -47,7 +55,7 @@ func ApplyUpdateTimestamps(setOps map[string]interface{}, schema *Schema) map[st
 	}
 
 	// Always set updatedAt on updates
-	result[schema.Timestamps.UpdatedAt] = time.Now().Unix()
+	result[schema.Timestamps.UpdatedAt] = now.Unix()
 
 	return result
 }
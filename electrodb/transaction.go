@@ -53,6 +53,9 @@ func (twb *TransactWriteBuilder) Go() (*TransactWriteResponse, error) {
 
 // GoWithContext executes the transaction write with a context
 func (twb *TransactWriteBuilder) GoWithContext(ctx context.Context) (*TransactWriteResponse, error) {
+	if err := twb.checkWritable(); err != nil {
+		return nil, err
+	}
 	if twb.service.client == nil {
 		return nil, NewElectroError("NoClientProvided",
 			"No DynamoDB client was provided to the service", nil)
@@ -85,19 +88,9 @@ func (twb *TransactWriteBuilder) GoWithContext(ctx context.Context) (*TransactWr
 		// Check if it's a transaction canceled exception
 		var canceledErr *types.TransactionCanceledException
 		if errors.As(err, &canceledErr) {
-			results := make([]TransactResult, len(twb.items))
-			for i, reason := range canceledErr.CancellationReasons {
-				if reason.Code != nil {
-					results[i] = TransactResult{
-						Rejected: true,
-						Code:     *reason.Code,
-						Message:  stringPtrOrEmpty(reason.Message),
-					}
-				}
-			}
 			return &TransactWriteResponse{
 				Canceled: true,
-				Data:     results,
+				Data:     canceledResults(len(twb.items), canceledErr),
 			}, NewElectroError("TransactionCanceled", "Transaction was canceled", err)
 		}
 		return nil, NewElectroError("TransactionError", "Transaction failed", err)
@@ -110,6 +103,47 @@ func (twb *TransactWriteBuilder) GoWithContext(ctx context.Context) (*TransactWr
 	}, nil
 }
 
+// canceledResults reports each item's cancellation reason; items DynamoDB
+// did not reject (code "None" or no reason) are left zero
+func canceledResults(count int, canceledErr *types.TransactionCanceledException) []TransactResult {
+	results := make([]TransactResult, count)
+	for i, reason := range canceledErr.CancellationReasons {
+		if i < count && reason.Code != nil && *reason.Code != "None" {
+			results[i] = TransactResult{
+				Rejected: true,
+				Code:     *reason.Code,
+				Message:  stringPtrOrEmpty(reason.Message),
+			}
+		}
+	}
+	return results
+}
+
+// checkWritable fails with ReadOnlyMode when the service, or the entity of
+// any put, update or delete in the transaction, is read-only
+func (twb *TransactWriteBuilder) checkWritable() error {
+	if err := twb.service.checkWritable(nil, "transaction write"); err != nil {
+		return err
+	}
+	for _, item := range twb.items {
+		var entity *Entity
+		switch write := item.(type) {
+		case *TransactPutItem:
+			entity = write.entity
+		case *TransactUpdateItem:
+			entity = write.entity
+		case *TransactDeleteItem:
+			entity = write.entity
+		}
+		if entity != nil {
+			if err := twb.service.checkWritable(entity, "transaction write"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Params returns the DynamoDB parameters without executing
 func (twb *TransactWriteBuilder) Params() (map[string]interface{}, error) {
 	transactItems := make([]types.TransactWriteItem, 0, len(twb.items))
@@ -192,17 +226,34 @@ func (tgb *TransactGetBuilder) GoWithContext(ctx context.Context) (*TransactGetR
 
 	result, err := tgb.service.client.TransactGetItems(ctx, input)
 	if err != nil {
+		var canceledErr *types.TransactionCanceledException
+		if errors.As(err, &canceledErr) {
+			return &TransactGetResponse{
+				Canceled: true,
+				Data:     canceledResults(len(tgb.items), canceledErr),
+			}, NewElectroError("TransactionCanceled", "Transaction was canceled", err)
+		}
 		return nil, NewElectroError("TransactionError", "Transaction failed", err)
 	}
 
-	// Parse responses
-	results := make([]TransactResult, len(result.Responses))
+	// Responses are in request order, one per item; a missing item has an
+	// empty response and keeps a nil Item without being rejected
+	results := make([]TransactResult, len(tgb.items))
 	for i, response := range result.Responses {
+		if i >= len(results) || len(response.Item) == 0 {
+			continue
+		}
+
 		var item map[string]interface{}
-		if response.Item != nil {
-			err = attributevalue.UnmarshalMap(response.Item, &item)
-			if err != nil {
-				return nil, NewElectroError("UnmarshalError", "Failed to unmarshal response", err)
+		err = attributevalue.UnmarshalMap(response.Item, &item)
+		if err != nil {
+			return nil, NewElectroError("UnmarshalError", "Failed to unmarshal response", err)
+		}
+
+		// Format each item with its entity
+		if getItem, ok := tgb.items[i].(*TransactGetItem); ok {
+			if item, err = NewExecutionHelper(getItem.entity).formatItem(item, false); err != nil {
+				return nil, err
 			}
 		}
 
@@ -247,7 +298,10 @@ func (tgb *TransactGetBuilder) Params() (map[string]interface{}, error) {
 type TransactPutItem struct {
 	entity           *Entity
 	item             Item
+	options          *PutOptions
 	conditionBuilder *ConditionBuilder
+	ctx              context.Context
+	err              error
 }
 
 // Commit prepares a put operation for a transaction
@@ -255,14 +309,20 @@ func (p *PutOperation) Commit() TransactionItem {
 	return &TransactPutItem{
 		entity:           p.entity,
 		item:             p.item,
+		options:          p.options,
 		conditionBuilder: p.conditionBuilder,
+		ctx:              p.ctx,
+		err:              p.err,
 	}
 }
 
 // BuildTransactItem builds the transaction write item
 func (tpi *TransactPutItem) BuildTransactItem() (types.TransactWriteItem, error) {
-	builder := NewParamsBuilder(tpi.entity)
-	params, err := builder.BuildPutItemParams(tpi.item, nil)
+	if tpi.err != nil {
+		return types.TransactWriteItem{}, tpi.err
+	}
+	builder := NewParamsBuilder(tpi.entity).withContext(tpi.ctx)
+	params, err := builder.BuildPutItemParams(tpi.item, tpi.options)
 	if err != nil {
 		return types.TransactWriteItem{}, err
 	}
@@ -332,7 +392,7 @@ func (u *UpdateOperation) Commit() TransactionItem {
 		prependOps:       u.prependOps,
 		subtractOps:      u.subtractOps,
 		dataOps:          u.dataOps,
-		conditionBuilder: u.conditionBuilder,
+		conditionBuilder: u.condition(),
 	}
 }
 
@@ -449,15 +509,19 @@ func (tdi *TransactDeleteItem) BuildTransactGetItem() (types.TransactGetItem, er
 
 // TransactGetItem wraps a get operation for transactions
 type TransactGetItem struct {
-	entity *Entity
-	keys   Keys
+	entity  *Entity
+	keys    Keys
+	options *GetOptions
 }
 
-// Commit prepares a get operation for a transaction
+// Commit prepares a get operation for a transaction. Attributes set with
+// Options are projected; Consistent does not apply, since transactional reads
+// are always serializable.
 func (g *GetOperation) Commit() TransactionItem {
 	return &TransactGetItem{
-		entity: g.entity,
-		keys:   g.keys,
+		entity:  g.entity,
+		keys:    g.keys,
+		options: g.options,
 	}
 }
 
@@ -470,7 +534,7 @@ func (tgi *TransactGetItem) BuildTransactItem() (types.TransactWriteItem, error)
 // BuildTransactGetItem builds the transaction get item
 func (tgi *TransactGetItem) BuildTransactGetItem() (types.TransactGetItem, error) {
 	builder := NewParamsBuilder(tgi.entity)
-	params, err := builder.BuildGetItemParams(tgi.keys, nil)
+	params, err := builder.BuildGetItemParams(tgi.keys, tgi.options)
 	if err != nil {
 		return types.TransactGetItem{}, err
 	}
@@ -480,10 +544,89 @@ func (tgi *TransactGetItem) BuildTransactGetItem() (types.TransactGetItem, error
 		tableName = &tgi.entity.schema.Table
 	}
 
-	return types.TransactGetItem{
-		Get: &types.Get{
-			TableName: tableName,
-			Key:       params["Key"].(map[string]types.AttributeValue),
-		},
+	get := &types.Get{
+		TableName: tableName,
+		Key:       params["Key"].(map[string]types.AttributeValue),
+	}
+	if projExpr, ok := params["ProjectionExpression"].(string); ok && projExpr != "" {
+		get.ProjectionExpression = &projExpr
+		get.ExpressionAttributeNames, _ = params["ExpressionAttributeNames"].(map[string]string)
+	}
+
+	return types.TransactGetItem{Get: get}, nil
+}
+
+// TransactConditionCheckItem checks a condition on an item without writing
+// it; the transaction is canceled if the condition fails
+type TransactConditionCheckItem struct {
+	entity           *Entity
+	keys             Keys
+	conditionBuilder *ConditionBuilder
+}
+
+// ConditionCheck creates a transaction item that requires the condition to
+// hold on the item with the given keys, e.g. to write one item only if a
+// related item is in some state
+func (e *Entity) ConditionCheck(keys Keys, callback WhereCallback) TransactionItem {
+	cb := e.newConditionBuilder()
+	cb.Where(callback)
+	return &TransactConditionCheckItem{
+		entity:           e,
+		keys:             keys,
+		conditionBuilder: cb,
+	}
+}
+
+// MustExist creates a condition check that the item with the given keys
+// exists, e.g. to create an order only if its user exists
+func (e *Entity) MustExist(keys Keys) TransactionItem {
+	return e.ConditionCheck(keys, func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		index := e.primaryIndex()
+		if index == nil {
+			return ""
+		}
+		return ops.Exists(attrs[index.PK.Field])
+	})
+}
+
+// BuildTransactItem builds the transaction condition check item
+func (tci *TransactConditionCheckItem) BuildTransactItem() (types.TransactWriteItem, error) {
+	builder := NewParamsBuilder(tci.entity)
+	params, err := builder.BuildDeleteItemParams(tci.keys, nil)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+
+	condExpr, condNames, condValues := tci.conditionBuilder.Build()
+	if condExpr == "" {
+		return types.TransactWriteItem{}, NewElectroError("InvalidOperation",
+			"Condition checks require a condition", nil)
+	}
+
+	tableName := tci.entity.config.Table
+	if tableName == nil {
+		tableName = &tci.entity.schema.Table
+	}
+
+	check := &types.ConditionCheck{
+		TableName:           tableName,
+		Key:                 params["Key"].(map[string]types.AttributeValue),
+		ConditionExpression: &condExpr,
+	}
+	if len(condNames) > 0 {
+		check.ExpressionAttributeNames = condNames
+	}
+	if len(condValues) > 0 {
+		check.ExpressionAttributeValues = condValues
+	}
+
+	return types.TransactWriteItem{
+		ConditionCheck: check,
 	}, nil
 }
+
+// BuildTransactGetItem is not supported for condition checks
+func (tci *TransactConditionCheckItem) BuildTransactGetItem() (types.TransactGetItem, error) {
+	return types.TransactGetItem{}, NewElectroError("InvalidOperation",
+		"Condition checks cannot be used in TransactGet", nil)
+}
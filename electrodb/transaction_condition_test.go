@@ -3,6 +3,8 @@ package electrodb
 import (
 	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func TestTransactPutWithCondition(t *testing.T) {
@@ -421,3 +423,70 @@ func TestPutConditionExpressionStructure(t *testing.T) {
 		t.Errorf("Expected condition to contain 'attribute_not_exists', got: %s", *item.Put.ConditionExpression)
 	}
 }
+
+func TestConditionBeginsWithOnSortKey(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Order",
+		Table:   "TestTable",
+		Version: "1",
+		Attributes: map[string]*AttributeDefinition{
+			"orderId": {Type: AttributeTypeString, Required: true},
+			"status":  {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}},
+				SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// Only overwrite an item this entity wrote, with a matching status
+	scoped := func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+		return ops.NotExists(attrs["pk"]) + " OR (" + attrs["sk"].Begins("$order_1") + " AND " + attrs["status"].Begins("open") + ")"
+	}
+
+	put, err := entity.Put(Item{"orderId": "o1", "status": "open"}).Condition(scoped).Commit().BuildTransactItem()
+	if err != nil {
+		t.Fatalf("Failed to build transact item: %v", err)
+	}
+	expected := "attribute_not_exists(#cond0) OR (begins_with(#cond1, :cond0) AND begins_with(#cond2, :cond1))"
+	if put.Put.ConditionExpression == nil || *put.Put.ConditionExpression != expected {
+		t.Fatalf("Expected %q, got %v", expected, put.Put.ConditionExpression)
+	}
+	names := put.Put.ExpressionAttributeNames
+	if names["#cond0"] != "pk" || names["#cond1"] != "sk" || names["#cond2"] != "status" {
+		t.Errorf("Unexpected names: %v", names)
+	}
+	if prefix, ok := put.Put.ExpressionAttributeValues[":cond0"].(*types.AttributeValueMemberS); !ok || prefix.Value != "$order_1" {
+		t.Errorf("Expected the sort key prefix value, got %v", put.Put.ExpressionAttributeValues[":cond0"])
+	}
+
+	// In an update the condition merges with the update's own placeholders
+	params, err := entity.Update(Keys{"orderId": "o1"}).
+		Set(map[string]interface{}{"status": "closed"}).
+		Condition(func(attrs map[string]*AttributeRef, ops *OperationBuilder) string {
+			return attrs["sk"].Begins("$order_1")
+		}).
+		Params()
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+	if params["ConditionExpression"] != "begins_with(#cond0, :cond0)" {
+		t.Errorf("Unexpected condition: %v", params["ConditionExpression"])
+	}
+	mergedNames := params["ExpressionAttributeNames"].(map[string]string)
+	mergedValues := params["ExpressionAttributeValues"].(map[string]types.AttributeValue)
+	if mergedNames["#cond0"] != "sk" || mergedValues[":cond0"] == nil {
+		t.Errorf("Expected the condition to merge into the update's names and values, got %v %v", mergedNames, mergedValues)
+	}
+	if !strings.Contains(params["UpdateExpression"].(string), "#attr") || len(mergedValues) < 2 {
+		t.Errorf("Expected the update's own placeholders to survive the merge, got %v", params["UpdateExpression"])
+	}
+}
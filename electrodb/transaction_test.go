@@ -1,7 +1,11 @@
 package electrodb
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func TestTransactWrite(t *testing.T) {
@@ -342,3 +346,292 @@ func TestTransactWriteMixedOperations(t *testing.T) {
 	// Verify all operations are included
 	// In a real scenario, we would check the structure more thoroughly
 }
+
+func TestTransactGetMissingItems(t *testing.T) {
+	client := &mockClient{
+		transactGetItems: func(input *dynamodb.TransactGetItemsInput) (*dynamodb.TransactGetItemsOutput, error) {
+			return &dynamodb.TransactGetItemsOutput{
+				Responses: []types.ItemResponse{
+					{Item: map[string]types.AttributeValue{
+						"pk":   &types.AttributeValueMemberS{Value: "$testservice#id_user1"},
+						"id":   &types.AttributeValueMemberS{Value: "user1"},
+						"name": &types.AttributeValueMemberS{Value: "ada"},
+					}},
+					{},
+					{Item: map[string]types.AttributeValue{
+						"pk":   &types.AttributeValueMemberS{Value: "$testservice#id_user3"},
+						"id":   &types.AttributeValueMemberS{Value: "user3"},
+						"name": &types.AttributeValueMemberS{Value: "grace"},
+					}},
+				},
+			}, nil
+		},
+	}
+	service := NewService("TestService", &ServiceConfig{
+		Client: client,
+		Table:  stringPtr("TestTable"),
+	})
+
+	userEntity, err := NewEntity(&Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id": {Type: AttributeTypeString, Required: true},
+			"name": {
+				Type: AttributeTypeString,
+				Get:  func(value interface{}) interface{} { return strings.ToUpper(value.(string)) },
+			},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create user entity: %v", err)
+	}
+	if err := service.Join(userEntity); err != nil {
+		t.Fatalf("Failed to join user entity: %v", err)
+	}
+
+	result, err := service.TransactGet(func(entities map[string]*Entity) []TransactionItem {
+		user := entities["User"]
+		return []TransactionItem{
+			user.Get(Keys{"id": "user1"}).Commit(),
+			user.Get(Keys{"id": "user2"}).Commit(),
+			user.Get(Keys{"id": "user3"}).Commit(),
+		}
+	}).Go()
+	if err != nil {
+		t.Fatalf("TransactGet failed: %v", err)
+	}
+
+	if len(result.Data) != 3 {
+		t.Fatalf("Expected one result per item, got %d", len(result.Data))
+	}
+	if result.Data[0].Item["name"] != "ADA" || result.Data[2].Item["name"] != "GRACE" {
+		t.Errorf("Expected present items to be transformed, got %v and %v", result.Data[0].Item, result.Data[2].Item)
+	}
+	if _, ok := result.Data[0].Item["pk"]; ok {
+		t.Error("Expected key fields to be stripped")
+	}
+	if missing := result.Data[1]; missing.Item != nil || missing.Rejected {
+		t.Errorf("Expected the missing item to have a nil Item and not be rejected, got %+v", missing)
+	}
+}
+
+func TestTransactGetReadPipeline(t *testing.T) {
+	client := &mockClient{
+		transactGetItems: func(input *dynamodb.TransactGetItemsInput) (*dynamodb.TransactGetItemsOutput, error) {
+			return &dynamodb.TransactGetItemsOutput{
+				Responses: []types.ItemResponse{
+					{Item: map[string]types.AttributeValue{
+						"pk":       &types.AttributeValueMemberS{Value: "$testservice#id_user1"},
+						"id":       &types.AttributeValueMemberS{Value: "user1"},
+						"password": &types.AttributeValueMemberS{Value: "secret"},
+					}},
+					{Item: map[string]types.AttributeValue{
+						"pk":      &types.AttributeValueMemberS{Value: "$testservice#orderid_o1"},
+						"orderId": &types.AttributeValueMemberS{Value: "o1"},
+						"status":  &types.AttributeValueMemberS{Value: "open"},
+					}},
+				},
+			}, nil
+		},
+	}
+	service := NewService("TestService", &ServiceConfig{
+		Client: client,
+		Table:  stringPtr("TestTable"),
+	})
+
+	schemas := []*Schema{
+		{
+			Service: "TestService",
+			Entity:  "User",
+			Table:   "TestTable",
+			Attributes: map[string]*AttributeDefinition{
+				"id":       {Type: AttributeTypeString, Required: true},
+				"password": {Type: AttributeTypeString, Hidden: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {PK: FacetDefinition{Field: "pk", Facets: []string{"id"}}},
+			},
+		},
+		{
+			Service: "TestService",
+			Entity:  "Order",
+			Table:   "TestTable",
+			Attributes: map[string]*AttributeDefinition{
+				"orderId": {Type: AttributeTypeString, Required: true},
+				"status": {
+					Type: AttributeTypeString,
+					Get:  func(value interface{}) interface{} { return strings.ToUpper(value.(string)) },
+				},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {PK: FacetDefinition{Field: "pk", Facets: []string{"orderId"}}},
+			},
+		},
+	}
+	for _, schema := range schemas {
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	result, err := service.TransactGet(func(entities map[string]*Entity) []TransactionItem {
+		return []TransactionItem{
+			entities["User"].Get(Keys{"id": "user1"}).Commit(),
+			entities["Order"].Get(Keys{"orderId": "o1"}).Commit(),
+		}
+	}).Go()
+	if err != nil {
+		t.Fatalf("TransactGet failed: %v", err)
+	}
+
+	user, order := result.Data[0].Item, result.Data[1].Item
+	if _, ok := user["password"]; ok {
+		t.Errorf("Expected the hidden attribute to be stripped, got %v", user)
+	}
+	if _, ok := user["pk"]; ok {
+		t.Errorf("Expected key fields to be stripped, got %v", user)
+	}
+	if order["status"] != "OPEN" {
+		t.Errorf("Expected the order's Get transform to be applied, got %v", order)
+	}
+}
+
+func TestTransactGetProjection(t *testing.T) {
+	service := NewService("TestService", &ServiceConfig{
+		Table: stringPtr("TestTable"),
+	})
+	userEntity, err := NewEntity(&Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":   {Type: AttributeTypeString, Required: true},
+			"name": {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {PK: FacetDefinition{Field: "pk", Facets: []string{"id"}}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create user entity: %v", err)
+	}
+	if err := service.Join(userEntity); err != nil {
+		t.Fatalf("Failed to join user entity: %v", err)
+	}
+
+	params, err := service.TransactGet(func(entities map[string]*Entity) []TransactionItem {
+		return []TransactionItem{
+			entities["User"].Get(Keys{"id": "user1"}).Options(&GetOptions{Attributes: []string{"name"}}).Commit(),
+			entities["User"].Get(Keys{"id": "user2"}).Commit(),
+		}
+	}).Params()
+	if err != nil {
+		t.Fatalf("Failed to generate params: %v", err)
+	}
+
+	items := params["TransactItems"].([]types.TransactGetItem)
+	projected := items[0].Get
+	if projected.ProjectionExpression == nil || *projected.ProjectionExpression != "#proj0, #proj1, #proj2" {
+		t.Fatalf("Expected a projection on the first get, got %v", projected.ProjectionExpression)
+	}
+	names := projected.ExpressionAttributeNames
+	if names["#proj0"] != "name" || names["#proj1"] != "pk" || names["#proj2"] != "id" {
+		t.Errorf("Expected projected names with key fields, got %v", names)
+	}
+	if items[1].Get.ProjectionExpression != nil || items[1].Get.ExpressionAttributeNames != nil {
+		t.Errorf("Expected no projection on the second get, got %+v", items[1].Get)
+	}
+}
+
+func TestTransactWriteMustExist(t *testing.T) {
+	var input *dynamodb.TransactWriteItemsInput
+	client := &mockClient{
+		transactWriteItems: func(in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			input = in
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		},
+	}
+	service := NewService("TestService", &ServiceConfig{
+		Client: client,
+		Table:  stringPtr("TestTable"),
+	})
+
+	schemas := []*Schema{
+		{
+			Service: "TestService",
+			Entity:  "User",
+			Table:   "TestTable",
+			Attributes: map[string]*AttributeDefinition{
+				"userId": {Type: AttributeTypeString, Required: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"userId"}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{}},
+				},
+			},
+		},
+		{
+			Service: "TestService",
+			Entity:  "Order",
+			Table:   "TestTable",
+			Attributes: map[string]*AttributeDefinition{
+				"userId":  {Type: AttributeTypeString, Required: true},
+				"orderId": {Type: AttributeTypeString, Required: true},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"userId"}},
+					SK: &FacetDefinition{Field: "sk", Facets: []string{"orderId"}},
+				},
+			},
+		},
+	}
+	for _, schema := range schemas {
+		entity, err := NewEntity(schema, nil)
+		if err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+		if err := service.Join(entity); err != nil {
+			t.Fatalf("Failed to join entity: %v", err)
+		}
+	}
+
+	_, err := service.TransactWrite(func(entities map[string]*Entity) []TransactionItem {
+		return []TransactionItem{
+			entities["User"].MustExist(Keys{"userId": "u1"}),
+			entities["Order"].Create(Item{"userId": "u1", "orderId": "o1"}).Commit(),
+		}
+	}).Go()
+	if err != nil {
+		t.Fatalf("TransactWrite failed: %v", err)
+	}
+
+	if len(input.TransactItems) != 2 {
+		t.Fatalf("Expected 2 transaction items, got %d", len(input.TransactItems))
+	}
+	check := input.TransactItems[0].ConditionCheck
+	if check == nil {
+		t.Fatal("Expected the first item to be a condition check")
+	}
+	if *check.ConditionExpression != "attribute_exists(#cond0)" || check.ExpressionAttributeNames["#cond0"] != "pk" {
+		t.Errorf("Expected attribute_exists on pk, got %s %v", *check.ConditionExpression, check.ExpressionAttributeNames)
+	}
+	if sk := check.Key["sk"].(*types.AttributeValueMemberS).Value; sk != "$user" {
+		t.Errorf("Expected the user's key, got sk %s", sk)
+	}
+	if input.TransactItems[1].Put == nil {
+		t.Error("Expected the second item to be the order put")
+	}
+}
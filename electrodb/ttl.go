@@ -1,9 +1,45 @@
 package electrodb
 
 import (
+	"fmt"
 	"time"
 )
 
+// maxTTLSeconds is the largest TTL accepted as epoch seconds. It is far
+// beyond any real expiry in seconds, while any current time in epoch
+// milliseconds exceeds it.
+const maxTTLSeconds = 1e11
+
+// validateTTLConfig checks that the TTL attribute is named and, when
+// declared, is a number; DynamoDB ignores TTL values of any other type
+func validateTTLConfig(schema *Schema) error {
+	if schema.TTL == nil {
+		return nil
+	}
+	if schema.TTL.Attribute == "" {
+		return NewElectroError("InvalidTTLConfig", "TTL attribute name is required", nil)
+	}
+	if attr, exists := schema.Attributes[schema.TTL.Attribute]; exists && attr.Type != AttributeTypeNumber {
+		return NewElectroError("InvalidTTLConfig",
+			fmt.Sprintf("TTL attribute '%s' must be of type number, got %s", schema.TTL.Attribute, attr.Type), nil)
+	}
+	return nil
+}
+
+// checkTTLValue rejects a TTL about to be written that is not in epoch
+// seconds, such as epoch milliseconds, which DynamoDB would never expire
+func checkTTLValue(schema *Schema, values map[string]interface{}) error {
+	if schema.TTL == nil {
+		return nil
+	}
+	ttl, ok := toFloat64(values[schema.TTL.Attribute])
+	if !ok || ttl < maxTTLSeconds {
+		return nil
+	}
+	return NewElectroError("InvalidTTLConfig",
+		fmt.Sprintf("TTL attribute '%s' value %.0f is not in epoch seconds; it looks like epoch milliseconds", schema.TTL.Attribute, ttl), nil)
+}
+
 // TTL helper methods for setting time-to-live on items
 
 // WithTTL sets a TTL (Time-To-Live) on a put operation
@@ -15,7 +51,7 @@ func (p *PutOperation) WithTTL(duration time.Duration) *PutOperation {
 	}
 
 	ttlAttribute := p.entity.schema.TTL.Attribute
-	ttlTimestamp := time.Now().Add(duration).Unix()
+	ttlTimestamp := p.entity.now().Add(duration).Unix()
 	p.item[ttlAttribute] = ttlTimestamp
 
 	return p
@@ -41,7 +77,7 @@ func (u *UpdateOperation) WithTTL(duration time.Duration) *UpdateOperation {
 	}
 
 	ttlAttribute := u.entity.schema.TTL.Attribute
-	ttlTimestamp := time.Now().Add(duration).Unix()
+	ttlTimestamp := u.entity.now().Add(duration).Unix()
 	u.setOps[ttlAttribute] = ttlTimestamp
 
 	return u
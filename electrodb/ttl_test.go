@@ -1,8 +1,12 @@
 package electrodb
 
 import (
+	"errors"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func TestPutWithTTL(t *testing.T) {
@@ -326,3 +330,109 @@ func TestCombinedTTLAndSetOperations(t *testing.T) {
 		t.Error("Expected UpdateExpression to be set")
 	}
 }
+
+// fixedClock is a Clock stopped at a single instant
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestClock(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Session",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"sessionId": {Type: AttributeTypeString, Required: true},
+			"ttl":       {Type: AttributeTypeNumber},
+			"createdAt": {Type: AttributeTypeNumber},
+			"updatedAt": {Type: AttributeTypeNumber},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"sessionId"}},
+			},
+		},
+		Timestamps: &TimestampsConfig{CreatedAt: "createdAt", UpdatedAt: "updatedAt"},
+		TTL:        &TTLConfig{Attribute: "ttl"},
+	}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	entity, err := NewEntity(schema, &Config{Clock: fixedClock(now)})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	stamp := strconv.FormatInt(now.Unix(), 10)
+	expires := strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+
+	params, err := entity.Put(Item{"sessionId": "s1"}).WithTTL(time.Hour).Params()
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+	item := params["Item"].(map[string]types.AttributeValue)
+	for attr, want := range map[string]string{"createdAt": stamp, "updatedAt": stamp, "ttl": expires} {
+		if n, ok := item[attr].(*types.AttributeValueMemberN); !ok || n.Value != want {
+			t.Errorf("Expected put %s to be %s, got %v", attr, want, item[attr])
+		}
+	}
+
+	params, err = entity.Update(Keys{"sessionId": "s1"}).WithTTL(time.Hour).Params()
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+	written := make(map[string]bool)
+	for _, value := range params["ExpressionAttributeValues"].(map[string]types.AttributeValue) {
+		if n, ok := value.(*types.AttributeValueMemberN); ok {
+			written[n.Value] = true
+		}
+	}
+	if !written[stamp] || !written[expires] {
+		t.Errorf("Expected update to write updatedAt %s and ttl %s, got %v", stamp, expires, params["ExpressionAttributeValues"])
+	}
+}
+
+func TestTTLValidation(t *testing.T) {
+	newSchema := func(ttlType AttributeType) *Schema {
+		return &Schema{
+			Service: "TestService",
+			Entity:  "Session",
+			Table:   "TestTable",
+			Attributes: map[string]*AttributeDefinition{
+				"sessionId": {Type: AttributeTypeString, Required: true},
+				"expiresAt": {Type: ttlType},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"primary": {
+					PK: FacetDefinition{Field: "pk", Facets: []string{"sessionId"}},
+				},
+			},
+			TTL: &TTLConfig{Attribute: "expiresAt"},
+		}
+	}
+	isTTLError := func(err error) bool {
+		var electroErr *ElectroError
+		return errors.As(err, &electroErr) && electroErr.Code == ErrInvalidTTLConfig
+	}
+
+	if _, err := NewEntity(newSchema(AttributeTypeString), nil); !isTTLError(err) {
+		t.Errorf("Expected InvalidTTLConfig for a string TTL attribute, got %v", err)
+	}
+	missing := newSchema(AttributeTypeNumber)
+	missing.TTL.Attribute = ""
+	if _, err := NewEntity(missing, nil); !isTTLError(err) {
+		t.Errorf("Expected InvalidTTLConfig for an unnamed TTL attribute, got %v", err)
+	}
+
+	entity, err := NewEntity(newSchema(AttributeTypeNumber), nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	millis := time.Now().Add(time.Hour).UnixMilli()
+	if _, err := entity.Put(Item{"sessionId": "s1"}).WithTTLTimestamp(millis).Params(); !isTTLError(err) {
+		t.Errorf("Expected InvalidTTLConfig for a millisecond TTL on put, got %v", err)
+	}
+	if _, err := entity.Update(Keys{"sessionId": "s1"}).WithTTLTimestamp(millis).Params(); !isTTLError(err) {
+		t.Errorf("Expected InvalidTTLConfig for a millisecond TTL on update, got %v", err)
+	}
+	if _, err := entity.Put(Item{"sessionId": "s1"}).WithTTL(time.Hour).Params(); err != nil {
+		t.Errorf("Expected a TTL in seconds to be accepted, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 // AttributeType represents the type of an attribute
@@ -19,6 +20,7 @@ const (
 	AttributeTypeList    AttributeType = "list"
 	AttributeTypeMap     AttributeType = "map"
 	AttributeTypeSet     AttributeType = "set"
+	AttributeTypeBinary  AttributeType = "binary"
 )
 
 // ValidationFunc is a function that validates an attribute value
@@ -27,28 +29,75 @@ type ValidationFunc func(value interface{}) error
 // DefaultFunc is a function that returns a default value for an attribute
 type DefaultFunc func() interface{}
 
+// DefaultFromContextFunc returns a default value for an attribute from the
+// write operation's context, e.g. a request-scoped tenant ID or a clock
+type DefaultFromContextFunc func(ctx context.Context) interface{}
+
 // GetFunc is a function that transforms a value when reading from DynamoDB
 type GetFunc func(value interface{}) interface{}
 
 // SetFunc is a function that transforms a value when writing to DynamoDB
 type SetFunc func(value interface{}) interface{}
 
+// SetWithItemFunc transforms a value like SetFunc and also receives the
+// attributes written with it, so a Watch attribute can derive its value from
+// the attributes it watches
+type SetWithItemFunc func(value interface{}, item Item) interface{}
+
+// AttributeTransformFunc transforms any attribute by name, for cross-cutting
+// concerns such as trimming strings or redacting values
+type AttributeTransformFunc func(name string, value interface{}) interface{}
+
+// BeforeWriteFunc runs before a put ("put"), update ("update") or delete
+// ("delete"). It receives a copy of the item, update set values or keys
+// respectively and returns the value to write; a non-nil error aborts the
+// operation
+type BeforeWriteFunc func(op string, item Item) (Item, error)
+
+// AfterWriteFunc runs after a successful put, update or delete with the
+// operation's response
+type AfterWriteFunc func(op string, resp interface{})
+
 // AttributeDefinition defines a single attribute in the schema
 type AttributeDefinition struct {
-	Type       AttributeType
-	Required   bool
-	Default    DefaultFunc
-	Validate   ValidationFunc
-	Field      string // DynamoDB field name (if different from attribute name)
-	Get        GetFunc
-	Set        SetFunc
-	ReadOnly   bool
-	Watch      []string // Attributes to watch for changes
-	Label      string
-	Cast       string
-	Padding    *PaddingConfig
-	Hidden     bool
-	EnumValues []interface{} // For enum type
+	Type               AttributeType
+	Required           bool
+	Default            DefaultFunc
+	DefaultFromContext DefaultFromContextFunc // Takes precedence over Default
+	Validate           ValidationFunc
+	Field              string // DynamoDB field name (if different from attribute name)
+	Get                GetFunc
+	Set                SetFunc
+	SetWithItem        SetWithItemFunc // Takes precedence over Set; needed to recompute a Watch attribute
+	ReadOnly           bool
+	Watch              []string // Re-run SetWithItem when any of these attributes is written
+	Label              string
+	Cast               string
+	Padding            *PaddingConfig
+	Hidden             bool
+	EnumValues         []interface{} // For enum type
+	Compress           bool          // Store the value gzip-compressed as binary
+	Encrypt            bool          // Store the value encrypted with Config.Encryptor as binary
+	NoWrite            bool          // Never accepted from callers; only computed (defaults, timestamps, transforms)
+	NoRead             bool          // Never returned, not even in Raw mode
+	Aliases            []string      // Former names; stored values under them are read as this attribute
+	Normalize          *Normalization
+	Generate           IDGenerator // Generate a unique ID when a put leaves the attribute unset
+
+	// Declarative constraints, checked on put and update alongside Validate
+	MinLength *int     // Minimum characters of a string, or elements of a list or set
+	MaxLength *int     // Maximum characters of a string, or elements of a list or set
+	Min       *float64 // Minimum of a number
+	Max       *float64 // Maximum of a number
+	Pattern   string   // Regular expression a string must match
+}
+
+// Normalization cleans up a string attribute, typically a key facet, on write
+// and wherever it composes a key, so lookups with stray whitespace or a
+// different case find the item
+type Normalization struct {
+	Trim   bool   // Strip leading and trailing whitespace
+	Casing string // "lower" or "upper"; empty keeps the case. Keys are lowercased regardless
 }
 
 // PaddingConfig defines padding configuration for attributes
@@ -85,6 +134,22 @@ type Schema struct {
 	Filters    map[string]FilterFunc
 	TTL        *TTLConfig        // Time-To-Live configuration
 	Timestamps *TimestampsConfig // Automatic timestamp management
+	Delimiters *KeyDelimiters    // Key delimiter characters (defaults to "$", "#", "_")
+	Padding    *PaddingConfig    // Default padding for numeric key facets; attribute Padding takes precedence
+}
+
+// KeyDelimiters overrides the characters composite keys are built from, for
+// interop with tables written by other tools. Empty fields keep their default.
+type KeyDelimiters struct {
+	Prefix string // Starts every key, "$" by default
+	Facet  string // Precedes each facet label, "#" by default
+	Value  string // Separates a label from its value, "_" by default
+	// Escape backslash-escapes the Facet delimiter (and "\\") inside facet
+	// values so keys always parse back into their facets. It is off by default
+	// because it changes the keys of existing items whose values contain them;
+	// while off, a facet value containing the Facet delimiter is rejected with
+	// InvalidFacetValue.
+	Escape bool
 }
 
 // TTLConfig configures TTL (Time-To-Live) for automatic item expiration
@@ -181,14 +246,137 @@ type Config struct {
 	Listeners   []EventListener
 	Logger      Logger
 	Identifiers *IdentifierConfig
+
+	// WriteTransforms run in order on every attribute written, before the
+	// attribute's own Set transform and validation
+	WriteTransforms []AttributeTransformFunc
+	// ReadTransforms run in order on every attribute read, after the
+	// attribute's own Get transform
+	ReadTransforms []AttributeTransformFunc
+	// TimeFormat controls how time.Time values are serialized in items and
+	// expression values; defaults to TimeFormatRFC3339
+	TimeFormat TimeFormat
+
+	// BeforeWrite and AfterWrite wrap Put, Update and Delete execution
+	BeforeWrite BeforeWriteFunc
+	AfterWrite  AfterWriteFunc
+
+	// Retry enables automatic retries of transient errors for Get, Put,
+	// Update and Delete
+	Retry *RetryConfig
+
+	// EnforceItemSize rejects puts larger than MaxItemSize with ItemTooLarge
+	// before they are sent
+	EnforceItemSize bool
+
+	// Cache, when set, serves repeated Get and Query requests with identical
+	// parameters for CacheTTL. Consistent reads bypass it, and writes do not
+	// invalidate it, so use it only for rarely-changing data.
+	Cache    Cache
+	CacheTTL time.Duration
+
+	// ConsistentRead makes Get and primary-index Query strongly consistent
+	// by default; GetOptions.Consistent and QueryOptions.Consistent override
+	// it, and it is ignored for queries on global secondary indexes
+	ConsistentRead bool
+
+	// Encryptor encrypts attributes marked Encrypt on write and decrypts
+	// them on read
+	Encryptor Encryptor
+
+	// Clock supplies the current time for timestamps and TTLs; defaults to
+	// the system clock
+	Clock Clock
+
+	// ReadOnly rejects every write (put, update, delete, batch and
+	// transaction writes) with ReadOnlyMode before DynamoDB is contacted,
+	// e.g. for read replicas; reads and Params are unaffected
+	ReadOnly bool
+
+	// Capture, when set, records every DynamoDB request instead of sending
+	// it and takes the place of Client; see Capture.Replay for canned
+	// responses
+	Capture *Capture
+}
+
+// Clock tells the current time. Inject a fixed clock to make timestamps and
+// TTLs deterministic in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// Encryptor encrypts and decrypts attribute values, e.g. with a KMS data key
+// or AES-GCM. The attribute name is passed so it can be bound as associated
+// data.
+type Encryptor interface {
+	Encrypt(attribute string, plaintext []byte) ([]byte, error)
+	Decrypt(attribute string, ciphertext []byte) ([]byte, error)
+}
+
+// Cache stores serialized read results, e.g. in memory or in Redis, keyed by
+// the serialized request parameters
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// RetryConfig configures retries of throttling and internal server errors.
+// Conditional check failures and validation errors are never retried.
+type RetryConfig struct {
+	MaxAttempts int           // Total attempts including the first; 1 or less disables retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled on each retry
+	MaxDelay    time.Duration // Upper bound on the delay (0 for none)
+}
+
+// WithClient returns a copy of the config using the given client
+func (c *Config) WithClient(client DynamoDBClient) *Config {
+	next := Config{}
+	if c != nil {
+		next = *c
+	}
+	next.Client = client
+	return &next
 }
 
+// TimeFormat defines how time.Time values are stored in DynamoDB
+type TimeFormat string
+
+const (
+	// TimeFormatRFC3339 stores times as fixed-width UTC RFC3339 strings with
+	// nanoseconds (S), e.g. 2024-01-02T03:04:05.000000000Z, which sort in
+	// time order
+	TimeFormatRFC3339 TimeFormat = "rfc3339"
+	// TimeFormatEpochSeconds stores times as Unix seconds (N)
+	TimeFormatEpochSeconds TimeFormat = "epoch"
+	// TimeFormatEpochMillis stores times as Unix milliseconds (N)
+	TimeFormatEpochMillis TimeFormat = "epoch_ms"
+)
+
+// IDGenerator names a scheme for generating unique string IDs
+type IDGenerator string
+
+const (
+	// GenerateUUID generates random (version 4) UUIDs
+	GenerateUUID IDGenerator = "uuid"
+	// GenerateULID generates ULIDs, which sort by creation time
+	GenerateULID IDGenerator = "ulid"
+	// GenerateKSUID generates KSUIDs, which sort by creation time to the second
+	GenerateKSUID IDGenerator = "ksuid"
+)
+
 // IdentifierConfig defines entity identifiers
 type IdentifierConfig struct {
 	Entity  string
 	Version string
 }
 
+// KeyIdentity is the entity name and version composed into an entity's sort
+// key prefix ($<entity>_<version>); see GetOptions.IdentifierOverride
+type KeyIdentity struct {
+	Entity  string
+	Version string
+}
+
 // DynamoDBClient is an interface for DynamoDB operations
 type DynamoDBClient interface {
 	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
@@ -226,6 +414,20 @@ type QueryOptions struct {
 	Order        *string // "asc" or "desc"
 	Concurrent   *int
 	IgnoreCursor bool
+	// NoEntityFilter skips the automatic begins_with on the entity's sort key
+	// prefix, so the raw partition is queried across all entities
+	NoEntityFilter bool
+	// IncludeKeys keeps the entity's index key fields (e.g. pk, sk, gsi1pk) in
+	// formatted results; ignored when Raw is set
+	IncludeKeys bool
+	// Consistent overrides Config.ConsistentRead; true is rejected on
+	// global secondary indexes
+	Consistent *bool
+	// MaxBytes makes Go follow pages until the next item would take the
+	// stored size of the items returned past this budget, and stops there
+	// with a cursor resuming after the last item returned. The first item
+	// is always returned. Pages, when set, still caps the pages fetched.
+	MaxBytes *int
 }
 
 // PutOptions defines options for put operations
@@ -233,6 +435,9 @@ type PutOptions struct {
 	Response   *string // "none", "all_old", "all_new"
 	Attributes []string
 	Raw        bool
+	// SkipIndexes names secondary indexes (by access pattern name) whose
+	// keys are not written, leaving the item out of those sparse indexes
+	SkipIndexes []string
 }
 
 // UpdateOptions defines options for update operations
@@ -240,6 +445,18 @@ type UpdateOptions struct {
 	Response   *string
 	Attributes []string
 	Raw        bool
+	// RecomputeKeys allows setting secondary index facets and recomputes the
+	// affected index keys in the same update
+	RecomputeKeys bool
+	// SkipUnchanged treats a failed conditional check as a successful no-op
+	// with UpdateResponse.Unchanged set; meant for SetIfChanged. It is
+	// rejected alongside Condition, ConditionWithValues or GuardKeys, whose
+	// failures must not be mistaken for an unchanged item.
+	SkipUnchanged bool
+	// NilMeansRemove removes attributes set to nil instead of storing NULL.
+	// The removals are validated like Remove, so required attributes and
+	// key facets cannot be set to nil.
+	NilMeansRemove bool
 }
 
 // DeleteOptions defines options for delete operations
@@ -253,22 +470,55 @@ type DeleteOptions struct {
 type GetOptions struct {
 	Attributes []string
 	Raw        bool
+	// IncludeKeys keeps the entity's index key fields in the formatted result
+	IncludeKeys bool
+	// Consistent overrides Config.ConsistentRead
+	Consistent *bool
+	// IdentifierOverride replaces the entity name and/or version composed
+	// into the sort key prefix ($<entity>_<version>), e.g. to read items
+	// written under an older schema version; empty fields keep the schema's
+	IdentifierOverride *KeyIdentity
 }
 
 // QueryResponse represents a query response
 type QueryResponse struct {
-	Data   []map[string]interface{}
-	Cursor *string
+	Data     []map[string]interface{}
+	Cursor   *string
+	PageInfo PageInfo
+
+	// itemSizes holds the stored size of each item in Data
+	itemSizes []int
+}
+
+// PageInfo describes the page of results a query or scan returned. Responses
+// spanning several pages sum the counts and describe the last page's key.
+type PageInfo struct {
+	HasMore      bool  // Another page follows; Cursor resumes from it
+	Count        int32 // Items returned, after filters
+	ScannedCount int32 // Items read before filters; zero for cached results
+	// LastEvaluatedKey is the raw key Cursor encodes; nil on the last page
+	LastEvaluatedKey map[string]types.AttributeValue
 }
 
 // PutResponse represents a put response
 type PutResponse struct {
 	Data map[string]interface{}
+	// Keys holds the composed index key fields (pk, sk and GSI keys) that
+	// were written, regardless of Raw
+	Keys map[string]string
+
+	entity *Entity                         // originating entity, for Reload
+	key    map[string]types.AttributeValue // primary key written
 }
 
 // UpdateResponse represents an update response
 type UpdateResponse struct {
 	Data map[string]interface{}
+	// Unchanged is set when SkipUnchanged turned a failed condition into a no-op
+	Unchanged bool
+
+	entity *Entity                         // originating entity, for Reload
+	key    map[string]types.AttributeValue // primary key updated
 }
 
 // DeleteResponse represents a delete response
@@ -283,14 +533,18 @@ type GetResponse struct {
 
 // ScanResponse represents a scan response
 type ScanResponse struct {
-	Data   []map[string]interface{}
-	Cursor *string
+	Data     []map[string]interface{}
+	Cursor   *string
+	PageInfo PageInfo
 }
 
 // BatchGetResponse represents a batch get response
 type BatchGetResponse struct {
 	Data        []map[string]interface{}
 	Unprocessed []Keys
+
+	request     *BatchGetRequest                  // originating request, for Retry
+	unprocessed []map[string]types.AttributeValue // composed unprocessed keys
 }
 
 // BatchWriteResponse represents a batch write response
@@ -299,6 +553,9 @@ type BatchWriteResponse struct {
 		Puts    []Item
 		Deletes []Keys
 	}
+
+	entity      *Entity              // originating entity, for Retry
+	unprocessed []types.WriteRequest // unprocessed requests as sent
 }
 
 // TransactionResponse represents a transaction response
@@ -317,27 +574,50 @@ type UpdateData map[string]interface{}
 
 // Error codes returned by ElectroDB operations
 const (
-	ErrBatchTooLarge       = "BatchTooLarge"
-	ErrCollectionNotFound  = "CollectionNotFound"
-	ErrCursorDecoding      = "CursorDecodingError"
-	ErrCursorEncoding      = "CursorEncodingError"
-	ErrDuplicateEntity     = "DuplicateEntity"
-	ErrDynamoDB            = "DynamoDBError"
-	ErrEntityNotFound      = "EntityNotFound"
-	ErrInvalidEntity       = "InvalidEntity"
-	ErrInvalidEnumValue    = "InvalidEnumValue"
-	ErrInvalidIndex        = "InvalidIndex"
-	ErrInvalidKeys         = "InvalidKeys"
-	ErrInvalidOperation    = "InvalidOperation"
-	ErrInvalidSchema       = "InvalidSchema"
-	ErrMarshal             = "MarshalError"
-	ErrMissingAttribute    = "MissingAttribute"
-	ErrNoClientProvided    = "NoClientProvided"
-	ErrReadOnlyViolation   = "ReadOnlyViolation"
-	ErrTransactionCanceled = "TransactionCanceled"
-	ErrTransaction         = "TransactionError"
-	ErrUnmarshal           = "UnmarshalError"
-	ErrValidation          = "ValidationError"
+	ErrBatchTooLarge           = "BatchTooLarge"
+	ErrCannotRemoveFacet       = "CannotRemoveFacet"
+	ErrCannotRemoveRequired    = "CannotRemoveRequired"
+	ErrCollectionNotFound      = "CollectionNotFound"
+	ErrConstraintViolation     = "ConstraintViolation"
+	ErrCursorDecoding          = "CursorDecodingError"
+	ErrCursorEncoding          = "CursorEncodingError"
+	ErrDecryptionFailed        = "DecryptionFailed"
+	ErrDuplicateEntity         = "DuplicateEntity"
+	ErrDynamoDB                = "DynamoDBError"
+	ErrEmptyFacetValue         = "EmptyFacetValue"
+	ErrEncryption              = "EncryptionError"
+	ErrEntityMismatch          = "EntityMismatch"
+	ErrEntityNotFound          = "EntityNotFound"
+	ErrFacetMutationNotAllowed = "FacetMutationNotAllowed"
+	ErrGeneration              = "GenerationError"
+	ErrInvalidEntity           = "InvalidEntity"
+	ErrInvalidEnumValue        = "InvalidEnumValue"
+	ErrInvalidFacetValue       = "InvalidFacetValue"
+	ErrInvalidIndex            = "InvalidIndex"
+	ErrInvalidKeys             = "InvalidKeys"
+	ErrInvalidOperation        = "InvalidOperation"
+	ErrInvalidSchema           = "InvalidSchema"
+	ErrInvalidSetValue         = "InvalidSetValue"
+	ErrInvalidTTLConfig        = "InvalidTTLConfig"
+	ErrItemTooLarge            = "ItemTooLarge"
+	ErrMalformedExpression     = "MalformedExpression"
+	ErrMarshal                 = "MarshalError"
+	ErrMissingAttribute        = "MissingAttribute"
+	ErrMissingWatchDependency  = "MissingWatchDependency"
+	ErrNoClientProvided        = "NoClientProvided"
+	ErrNoWriteViolation        = "NoWriteViolation"
+	ErrNonContiguousFacets     = "NonContiguousFacets"
+	ErrNotASetAttribute        = "NotASetAttribute"
+	ErrReadOnlyMode            = "ReadOnlyMode"
+	ErrReadOnlyViolation       = "ReadOnlyViolation"
+	ErrSchemaTableMismatch     = "SchemaTableMismatch"
+	ErrTableNotFound           = "TableNotFound"
+	ErrTransactionCanceled     = "TransactionCanceled"
+	ErrTransaction             = "TransactionError"
+	ErrUnknownAttribute        = "UnknownAttribute"
+	ErrUnmarshal               = "UnmarshalError"
+	ErrValidation              = "ValidationError"
+	ErrValidationFailed        = "ValidationFailed"
 )
 
 // ElectroError represents an error from ElectroDB
@@ -346,6 +626,18 @@ type ElectroError struct {
 	Message string
 	Cause   error
 	Time    time.Time
+	Details []FieldError // Per-field failures for ValidationFailed errors
+
+	// AWSErrorType is the error code of the AWS error in Cause, such as
+	// "ProvisionedThroughputExceededException", or "" if there is none
+	AWSErrorType string
+}
+
+// FieldError describes a single attribute validation failure
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
 }
 
 func (e *ElectroError) Error() string {
@@ -355,6 +647,12 @@ func (e *ElectroError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the underlying cause, so errors.As can reach the AWS error
+// type wrapped by a DynamoDBError
+func (e *ElectroError) Unwrap() error {
+	return e.Cause
+}
+
 // NewElectroError creates a new ElectroError
 func NewElectroError(code, message string, cause error) *ElectroError {
 	return &ElectroError{
@@ -362,5 +660,7 @@ func NewElectroError(code, message string, cause error) *ElectroError {
 		Message: message,
 		Cause:   cause,
 		Time:    time.Now(),
+
+		AWSErrorType: awsErrorType(cause),
 	}
 }
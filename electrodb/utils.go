@@ -1,5 +1,7 @@
 package electrodb
 
+import "sort"
+
 // stringPtr returns a pointer to the given string
 func stringPtr(s string) *string {
 	return &s
@@ -22,3 +24,22 @@ func stringPtrOrEmpty(s *string) string {
 	}
 	return *s
 }
+
+// copyOps returns a shallow copy of an update operation map
+func copyOps(ops map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(ops))
+	for k, v := range ops {
+		result[k] = v
+	}
+	return result
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,6 +2,9 @@ package electrodb
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 )
 
 // Validator handles attribute validation and transformation
@@ -16,10 +19,31 @@ func NewValidator(entity *Entity) *Validator {
 
 // ValidateAndTransformForWrite validates and transforms an item before writing to DynamoDB
 // This applies: validation, enum checks, Set transformations, readonly checks
+//
+// Values are validated as supplied and padded afterwards, so constraints and
+// Validate never see padding; Set transforms run after padding, and
+// attributes that Watch a written
+// attribute are recomputed afterwards. Each attribute's Set transform runs at
+// most once per write, so a watcher that is also written explicitly is not
+// transformed twice.
 func (v *Validator) ValidateAndTransformForWrite(item Item, isUpdate bool) (Item, error) {
+	return v.validateAndTransformForWrite(item, isUpdate, &validationCollector{})
+}
+
+// ValidateAndTransformForWriteAll behaves like ValidateAndTransformForWrite but
+// does not stop at the first failure. All attribute validation failures are
+// reported together in a single ValidationFailed error whose Details list
+// every offending field.
+func (v *Validator) ValidateAndTransformForWriteAll(item Item, isUpdate bool) (Item, error) {
+	return v.validateAndTransformForWrite(item, isUpdate, &validationCollector{collect: true})
+}
+
+func (v *Validator) validateAndTransformForWrite(item Item, isUpdate bool, errs *validationCollector) (Item, error) {
 	result := make(Item)
+	transformed := make(map[string]bool)
 
-	for name, value := range item {
+	for _, name := range sortedItemNames(item) {
+		value := v.applyGlobalTransforms(v.entity.config.WriteTransforms, name, item[name])
 		attr, exists := v.entity.schema.Attributes[name]
 		if !exists {
 			// Allow unknown attributes to pass through
@@ -29,37 +53,381 @@ func (v *Validator) ValidateAndTransformForWrite(item Item, isUpdate bool) (Item
 
 		// Check ReadOnly enforcement (only for updates, not creates)
 		if isUpdate && attr.ReadOnly {
-			return nil, NewElectroError("ReadOnlyViolation",
-				fmt.Sprintf("Attribute '%s' is read-only and cannot be updated", name), nil)
+			if err := errs.add(name, NewElectroError("ReadOnlyViolation",
+				fmt.Sprintf("Attribute '%s' is read-only and cannot be updated", name), nil)); err != nil {
+				return nil, err
+			}
 		}
 
 		// Validate enum values
 		if attr.Type == AttributeTypeEnum && len(attr.EnumValues) > 0 {
-			if err := v.validateEnum(name, value, attr.EnumValues); err != nil {
+			if err := errs.add(name, v.validateEnum(name, value, attr.EnumValues)); err != nil {
 				return nil, err
 			}
 		}
 
+		// Check declarative constraints
+		if err := errs.add(name, checkConstraints(name, value, attr)); err != nil {
+			return nil, err
+		}
+
 		// Apply custom validation function
 		if attr.Validate != nil {
-			if err := attr.Validate(value); err != nil {
-				return nil, NewElectroError("ValidationError",
-					fmt.Sprintf("Validation failed for attribute '%s': %v", name, err), err)
+			if err := errs.add(name, v.validateCustom(name, value, attr)); err != nil {
+				return nil, err
 			}
 		}
 
+		// Pad once the value as supplied has been validated
+		if padding := v.entity.schema.storedPaddingFor(name); padding != nil && value != nil {
+			value = padValue(value, padding)
+		}
+
 		// Apply Set transformation (transforms value before writing to DynamoDB)
 		transformedValue := value
-		if attr.Set != nil {
-			transformedValue = attr.Set(value)
+		if attr.hasSet() {
+			transformedValue = attr.applySet(value, item)
+			transformed[name] = true
 		}
 
 		result[name] = transformedValue
 	}
 
+	if field, err := v.checkWatchDependencies(item); err != nil {
+		if err := errs.add(field, err); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	v.applyWatchTransforms(result, item, transformed, false)
+
 	return result, nil
 }
 
+// applyGlobalTransforms runs the configured attribute transforms in order
+func (v *Validator) applyGlobalTransforms(transforms []AttributeTransformFunc, name string, value interface{}) interface{} {
+	for _, transform := range transforms {
+		value = transform(name, value)
+	}
+	return value
+}
+
+// validateCustom runs the attribute's Validate function
+func (v *Validator) validateCustom(attrName string, value interface{}, attr *AttributeDefinition) error {
+	if err := attr.Validate(value); err != nil {
+		return NewElectroError("ValidationError",
+			fmt.Sprintf("Validation failed for attribute '%s': %v", attrName, err), err)
+	}
+	return nil
+}
+
+// validationCollector either fails fast on the first validation error or
+// accumulates every failure into FieldError details
+type validationCollector struct {
+	collect bool
+	details []FieldError
+}
+
+// add records a validation failure for a field. It returns the error when
+// failing fast and nil when collecting, so callers can always write
+// `if err := errs.add(...); err != nil { return err }`.
+func (c *validationCollector) add(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if !c.collect {
+		return err
+	}
+
+	detail := FieldError{Field: field, Code: "ValidationError", Message: err.Error()}
+	if electroErr, ok := err.(*ElectroError); ok {
+		detail.Code = electroErr.Code
+		detail.Message = electroErr.Message
+	}
+	c.details = append(c.details, detail)
+	return nil
+}
+
+// err returns the aggregated ValidationFailed error, if any failures were collected
+func (c *validationCollector) err() error {
+	if len(c.details) == 0 {
+		return nil
+	}
+	fields := make([]string, len(c.details))
+	for i, detail := range c.details {
+		fields[i] = detail.Field
+	}
+	electroErr := NewElectroError("ValidationFailed",
+		fmt.Sprintf("Validation failed for %d field(s): %s", len(c.details), strings.Join(fields, ", ")), nil)
+	electroErr.Details = c.details
+	return electroErr
+}
+
+// applyWatchTransforms re-runs the SetWithItem transform of every attribute
+// that watches one of the written attributes, passing it the item as written.
+// A watcher with only Set is never recomputed, since Set would only see the
+// watcher's own value, which is not being written. Watchers missing from the
+// write receive a nil value and are only stored when SetWithItem returns a
+// value. Attributes already marked as transformed are skipped, so a transform
+// is never applied twice to the same value. With complete set, a watcher is
+// only recomputed when every attribute it watches is written, as partial
+// updates cannot see the stored values of the others.
+func (v *Validator) applyWatchTransforms(written map[string]interface{}, item map[string]interface{}, transformed map[string]bool, complete bool) {
+	for _, name := range sortedAttributeNames(v.entity.schema.Attributes) {
+		attr := v.entity.schema.Attributes[name]
+		if attr.SetWithItem == nil || len(attr.Watch) == 0 || transformed[name] {
+			continue
+		}
+
+		triggered, missing := false, false
+		for _, watched := range attr.Watch {
+			if watched == name {
+				continue
+			}
+			value, ok := written[watched]
+			triggered = triggered || ok
+			missing = missing || value == nil
+		}
+		if !triggered || (complete && missing) {
+			continue
+		}
+
+		if value := attr.SetWithItem(written[name], Item(item)); value != nil {
+			written[name] = value
+		}
+		transformed[name] = true
+	}
+}
+
+// checkWatchDependencies returns MissingWatchDependency, and the missing
+// attribute, when a write would recompute a watcher (see
+// applyWatchTransforms) while another attribute it watches is absent, so the
+// derived value would be computed from partial data
+func (v *Validator) checkWatchDependencies(written map[string]interface{}) (string, error) {
+	for _, name := range sortedAttributeNames(v.entity.schema.Attributes) {
+		attr := v.entity.schema.Attributes[name]
+		if attr.SetWithItem == nil || len(attr.Watch) == 0 {
+			continue
+		}
+		if _, explicit := written[name]; explicit {
+			continue
+		}
+
+		triggered := false
+		var missing []string
+		for _, watched := range attr.Watch {
+			if watched == name {
+				continue
+			}
+			if value, ok := written[watched]; ok && value != nil {
+				triggered = true
+			} else {
+				missing = append(missing, watched)
+			}
+		}
+		if triggered && len(missing) > 0 {
+			return missing[0], NewElectroError("MissingWatchDependency",
+				fmt.Sprintf("Attribute '%s' is recomputed from %s, but '%s' is missing", name, strings.Join(attr.Watch, ", "), missing[0]), nil)
+		}
+	}
+	return "", nil
+}
+
+// ValidateItem checks an item against the schema without transforming or writing it.
+// It verifies required attributes, primary key facets, declared attribute types,
+// enum values and custom Validate functions, and returns the first violation found.
+func (v *Validator) ValidateItem(item Item) error {
+	return v.validateItem(item, &validationCollector{})
+}
+
+// ValidateItemAll is like ValidateItem but reports every violation in a single
+// ValidationFailed error instead of stopping at the first one.
+func (v *Validator) ValidateItemAll(item Item) error {
+	return v.validateItem(item, &validationCollector{collect: true})
+}
+
+func (v *Validator) validateItem(item Item, errs *validationCollector) error {
+	schema := v.entity.schema
+
+	for _, name := range sortedAttributeNames(schema.Attributes) {
+		if schema.Attributes[name].Required {
+			if _, exists := item[name]; !exists {
+				if err := errs.add(name, NewElectroError("MissingAttribute",
+					fmt.Sprintf("Required attribute '%s' is missing", name), nil)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, index := range schema.Indexes {
+		if index.Index != nil {
+			continue
+		}
+		facets := append([]string{}, index.PK.Facets...)
+		if index.SK != nil {
+			facets = append(facets, index.SK.Facets...)
+		}
+		for _, facet := range facets {
+			if _, exists := item[facet]; exists || schema.Attributes[facet].Required {
+				// Required facets are already reported above
+				continue
+			}
+			if err := errs.add(facet, NewElectroError("InvalidKeys",
+				fmt.Sprintf("Primary key facet '%s' is missing", facet), nil)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range sortedItemNames(item) {
+		value := item[name]
+		attr, exists := schema.Attributes[name]
+		if !exists {
+			continue
+		}
+
+		// Skip value checks for a field whose type is already wrong
+		if typeErr := v.validateType(name, value, attr); typeErr != nil {
+			if err := errs.add(name, typeErr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if attr.Type == AttributeTypeEnum && len(attr.EnumValues) > 0 {
+			if err := errs.add(name, v.validateEnum(name, value, attr.EnumValues)); err != nil {
+				return err
+			}
+		}
+
+		if err := errs.add(name, checkConstraints(name, value, attr)); err != nil {
+			return err
+		}
+
+		if attr.Validate != nil {
+			if err := errs.add(name, v.validateCustom(name, value, attr)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return errs.err()
+}
+
+// validateType checks that a value matches the attribute's declared type.
+// Nil values and attributes typed "any" (or untyped) always pass.
+func (v *Validator) validateType(attrName string, value interface{}, attr *AttributeDefinition) error {
+	if value == nil {
+		return nil
+	}
+
+	kind := reflect.TypeOf(value).Kind()
+	valid := true
+	switch attr.Type {
+	case AttributeTypeString:
+		valid = kind == reflect.String
+	case AttributeTypeNumber:
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+		default:
+			valid = false
+		}
+	case AttributeTypeBoolean:
+		valid = kind == reflect.Bool
+	case AttributeTypeList:
+		valid = kind == reflect.Slice || kind == reflect.Array
+	case AttributeTypeSet:
+		valid = (kind == reflect.Slice || kind == reflect.Array) && validSetElements(reflect.ValueOf(value))
+	case AttributeTypeBinary:
+		_, valid = value.([]byte)
+	case AttributeTypeMap:
+		valid = kind == reflect.Map || kind == reflect.Struct
+	}
+
+	if !valid {
+		return NewElectroError("ValidationError",
+			fmt.Sprintf("Attribute '%s' must be of type %s, got %T", attrName, attr.Type, value), nil)
+	}
+	return nil
+}
+
+// validSetElements reports whether every element of a set is a string, a
+// number or binary, and all elements share the same kind
+func validSetElements(set reflect.Value) bool {
+	var want string
+	for i := 0; i < set.Len(); i++ {
+		elem := set.Index(i)
+		for elem.Kind() == reflect.Interface && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+
+		var got string
+		switch elem.Kind() {
+		case reflect.String:
+			got = "S"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			got = "N"
+		case reflect.Slice:
+			if elem.Type().Elem().Kind() != reflect.Uint8 {
+				return false
+			}
+			got = "B"
+		default:
+			return false
+		}
+
+		if want == "" {
+			want = got
+		} else if got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedItemNames returns the item's attribute names in a stable order
+func sortedItemNames(item Item) []string {
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedAttributeNames returns attribute names in a stable order
+func sortedAttributeNames(attributes map[string]*AttributeDefinition) []string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkNoWrite rejects caller-supplied values for NoWrite attributes, which
+// may only be computed (defaults, timestamps, Set and Watch transforms)
+func (v *Validator) checkNoWrite(names ...map[string]interface{}) error {
+	for _, values := range names {
+		for name := range values {
+			if attr, exists := v.entity.schema.Attributes[name]; exists && attr.NoWrite {
+				return NewElectroError("NoWriteViolation",
+					fmt.Sprintf("Attribute '%s' cannot be written", name), nil)
+			}
+		}
+	}
+	return nil
+}
+
 // TransformForRead applies Get transformations and filters hidden attributes when reading from DynamoDB
 func (v *Validator) TransformForRead(item Item) Item {
 	if item == nil {
@@ -68,7 +436,7 @@ func (v *Validator) TransformForRead(item Item) Item {
 
 	result := make(Item)
 
-	for name, value := range item {
+	for name, value := range v.resolveAliases(item) {
 		attr, exists := v.entity.schema.Attributes[name]
 		if !exists {
 			// Allow unknown attributes to pass through
@@ -76,8 +444,8 @@ func (v *Validator) TransformForRead(item Item) Item {
 			continue
 		}
 
-		// Skip hidden attributes
-		if attr.Hidden {
+		// Skip hidden and unreadable attributes
+		if attr.Hidden || attr.NoRead {
 			continue
 		}
 
@@ -87,12 +455,40 @@ func (v *Validator) TransformForRead(item Item) Item {
 			transformedValue = attr.Get(value)
 		}
 
-		result[name] = transformedValue
+		result[name] = v.applyGlobalTransforms(v.entity.config.ReadTransforms, name, transformedValue)
 	}
 
 	return result
 }
 
+// resolveAliases renames values stored under an attribute's former names to
+// the attribute's current name. A value under the current name wins.
+func (v *Validator) resolveAliases(item Item) Item {
+	var renamed Item
+	for name, attr := range v.entity.schema.Attributes {
+		for _, alias := range attr.Aliases {
+			value, ok := item[alias]
+			if !ok {
+				continue
+			}
+			if renamed == nil {
+				renamed = make(Item, len(item))
+				for key, val := range item {
+					renamed[key] = val
+				}
+			}
+			delete(renamed, alias)
+			if _, current := renamed[name]; !current {
+				renamed[name] = value
+			}
+		}
+	}
+	if renamed == nil {
+		return item
+	}
+	return renamed
+}
+
 // validateEnum checks if a value is in the allowed enum values
 func (v *Validator) validateEnum(attrName string, value interface{}, enumValues []interface{}) error {
 	for _, enumVal := range enumValues {
@@ -125,6 +521,10 @@ func (v *Validator) ValidateUpdateOperations(
 			return NewElectroError("ReadOnlyViolation",
 				fmt.Sprintf("Attribute '%s' is read-only and cannot be updated", name), nil)
 		}
+
+		if err := checkConstraints(name, setOps[name], attr); err != nil {
+			return err
+		}
 	}
 
 	// Validate ADD operations (can't add to readonly)
@@ -153,11 +553,26 @@ func (v *Validator) ValidateUpdateOperations(
 		}
 	}
 
-	// Validate REMOVE operations (can't remove readonly)
+	// Validate REMOVE operations: only declared attributes that the item
+	// stays valid without (not facets, not required, not readonly)
 	for _, name := range remOps {
 		attr, exists := v.entity.schema.Attributes[name]
 		if !exists {
-			continue
+			if ttl := v.entity.schema.TTL; ttl != nil && ttl.Attribute == name {
+				continue
+			}
+			return NewElectroError("UnknownAttribute",
+				fmt.Sprintf("Attribute '%s' is not declared in the schema and cannot be removed", name), nil)
+		}
+
+		if v.entity.schema.isKeyFacet(name) {
+			return NewElectroError("CannotRemoveFacet",
+				fmt.Sprintf("Attribute '%s' is a key facet and cannot be removed", name), nil)
+		}
+
+		if attr.Required {
+			return NewElectroError("CannotRemoveRequired",
+				fmt.Sprintf("Attribute '%s' is required and cannot be removed", name), nil)
 		}
 
 		if attr.ReadOnly {
@@ -177,10 +592,13 @@ func (v *Validator) ApplySetTransformations(
 ) (map[string]interface{}, map[string]interface{}, map[string]interface{}) {
 	// Transform SET operations
 	transformedSet := make(map[string]interface{})
+	transformed := make(map[string]bool)
 	for name, value := range setOps {
+		value = v.applyGlobalTransforms(v.entity.config.WriteTransforms, name, value)
 		attr, exists := v.entity.schema.Attributes[name]
-		if exists && attr.Set != nil {
-			transformedSet[name] = attr.Set(value)
+		if exists && attr.hasSet() {
+			transformedSet[name] = attr.applySet(value, setOps)
+			transformed[name] = true
 		} else {
 			transformedSet[name] = value
 		}
@@ -204,12 +622,15 @@ func (v *Validator) ApplySetTransformations(
 		}
 	}
 
+	// Recompute attributes whose watched attributes are all being SET
+	v.applyWatchTransforms(transformedSet, setOps, transformed, true)
+
 	// Transform ADD operations
 	transformedAdd := make(map[string]interface{})
 	for name, value := range addOps {
 		attr, exists := v.entity.schema.Attributes[name]
-		if exists && attr.Set != nil {
-			transformedAdd[name] = attr.Set(value)
+		if exists && attr.hasSet() {
+			transformedAdd[name] = attr.applySet(value, setOps)
 		} else {
 			transformedAdd[name] = value
 		}
@@ -219,8 +640,8 @@ func (v *Validator) ApplySetTransformations(
 	transformedDel := make(map[string]interface{})
 	for name, value := range delOps {
 		attr, exists := v.entity.schema.Attributes[name]
-		if exists && attr.Set != nil {
-			transformedDel[name] = attr.Set(value)
+		if exists && attr.hasSet() {
+			transformedDel[name] = attr.applySet(value, setOps)
 		} else {
 			transformedDel[name] = value
 		}
@@ -228,3 +649,123 @@ func (v *Validator) ApplySetTransformations(
 
 	return transformedSet, transformedAdd, transformedDel
 }
+
+// checkSetOperations verifies that ADD and DELETE target attributes that can
+// take them and that set values hold a single element type. DELETE only
+// applies to sets; ADD also applies to numbers, so a declared set is required
+// only when the attribute is declared with another type or the value is a
+// collection
+func (v *Validator) checkSetOperations(addOps, delOps map[string]interface{}) error {
+	for _, name := range sortedKeys(addOps) {
+		attr, exists := v.entity.schema.Attributes[name]
+		switch {
+		case exists && attr.Type == AttributeTypeSet:
+		case exists && (attr.Type == AttributeTypeNumber || attr.Type == AttributeTypeAny):
+			continue
+		case !exists && !isSetCollection(addOps[name]):
+			continue
+		default:
+			return notASetError(name, "added to")
+		}
+		if err := checkSetElements(name, addOps[name]); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(delOps) {
+		attr, exists := v.entity.schema.Attributes[name]
+		if !exists || attr.Type != AttributeTypeSet {
+			return notASetError(name, "deleted from")
+		}
+		if err := checkSetElements(name, delOps[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func notASetError(name, action string) error {
+	return NewElectroError("NotASetAttribute",
+		fmt.Sprintf("Attribute '%s' is not declared as a set and cannot be %s", name, action), nil)
+}
+
+// isSetCollection reports whether value is a slice or array other than a
+// single binary value
+func isSetCollection(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	if _, ok := value.([]byte); ok {
+		return false
+	}
+	kind := reflect.TypeOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// checkSetElements requires value to be a non-empty collection of strings,
+// numbers or binary values, all of the same kind
+func checkSetElements(name string, value interface{}) error {
+	if !isSetCollection(value) {
+		return NewElectroError("InvalidSetValue",
+			fmt.Sprintf("Set attribute '%s' requires a list of values, got %T", name, value), nil)
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Len() == 0 {
+		return NewElectroError("InvalidSetValue",
+			fmt.Sprintf("Set attribute '%s' requires at least one value", name), nil)
+	}
+	var first string
+	for i := 0; i < rv.Len(); i++ {
+		kind := setElementKind(rv.Index(i))
+		if kind == "" {
+			return NewElectroError("InvalidSetValue",
+				fmt.Sprintf("Set attribute '%s' only holds strings, numbers or binary values, got %v", name, rv.Index(i).Interface()), nil)
+		}
+		if i == 0 {
+			first = kind
+		} else if kind != first {
+			return NewElectroError("InvalidSetValue",
+				fmt.Sprintf("Set attribute '%s' mixes %s and %s values", name, first, kind), nil)
+		}
+	}
+	return nil
+}
+
+// setElementKind returns the name of the set type an element belongs to, or
+// "" if it cannot be stored in a set
+func setElementKind(elem reflect.Value) string {
+	for elem.Kind() == reflect.Interface || elem.Kind() == reflect.Ptr {
+		if elem.IsNil() {
+			return ""
+		}
+		elem = elem.Elem()
+	}
+	switch elem.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		if elem.Type().Elem().Kind() == reflect.Uint8 {
+			return "binary"
+		}
+	}
+	return ""
+}
+
+// hasSet reports whether the attribute transforms values on write
+func (a *AttributeDefinition) hasSet() bool {
+	return a.Set != nil || a.SetWithItem != nil
+}
+
+// applySet runs the attribute's SetWithItem, or else its Set, on a value
+// being written; item holds the attributes written with it
+func (a *AttributeDefinition) applySet(value interface{}, item map[string]interface{}) interface{} {
+	if a.SetWithItem != nil {
+		return a.SetWithItem(value, Item(item))
+	}
+	return a.Set(value)
+}
@@ -2,8 +2,11 @@ package electrodb
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 // Test validation functions
@@ -506,7 +509,7 @@ func TestDefaultValues(t *testing.T) {
 	}
 
 	// Apply defaults
-	enrichedItem := builder.applyDefaults(item)
+	enrichedItem, _ := builder.applyDefaults(item)
 
 	if !defaultCalled {
 		t.Error("Default function should have been called")
@@ -527,7 +530,7 @@ func TestDefaultValues(t *testing.T) {
 		"priority": 5,
 	}
 
-	enrichedItem2 := builder.applyDefaults(item2)
+	enrichedItem2, _ := builder.applyDefaults(item2)
 
 	if enrichedItem2["status"] != "active" {
 		t.Error("Explicit value should not be overridden by default")
@@ -592,3 +595,430 @@ func TestUpdateOperationsWithTransformations(t *testing.T) {
 		t.Errorf("Expected status to be 'active', got %v", transformedSet["status"])
 	}
 }
+
+func TestWatchRecomputeDoesNotReapplySet(t *testing.T) {
+	setCalls := 0
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Account",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":      {Type: AttributeTypeString, Required: true},
+			"deposit": {Type: AttributeTypeNumber},
+			"balance": {
+				Type:  AttributeTypeNumber,
+				Watch: []string{"deposit"},
+				SetWithItem: func(value interface{}, item Item) interface{} {
+					setCalls++
+					if amount, ok := value.(int); ok {
+						return amount * 100
+					}
+					return value
+				},
+			},
+			"depositedAt": {
+				Type:  AttributeTypeString,
+				Watch: []string{"deposit"},
+				SetWithItem: func(value interface{}, item Item) interface{} {
+					return fmt.Sprintf("deposit %v", item["deposit"])
+				},
+			},
+			// Set alone never sees the watched values, so it is not recomputed
+			"memo": {
+				Type:  AttributeTypeString,
+				Watch: []string{"deposit"},
+				Set: func(value interface{}) interface{} {
+					return strings.ToUpper(value.(string))
+				},
+			},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	validator := NewValidator(entity)
+
+	// Put path: balance is written explicitly and also watches deposit
+	item, err := validator.ValidateAndTransformForWrite(Item{"id": "a1", "deposit": 5, "balance": 12}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if item["balance"] != 1200 {
+		t.Errorf("Expected balance 1200 (transformed once), got %v", item["balance"])
+	}
+	if setCalls != 1 {
+		t.Errorf("Expected Set to run once, ran %d times", setCalls)
+	}
+	if item["depositedAt"] != "deposit 5" {
+		t.Errorf("Expected watcher depositedAt to be recomputed from deposit, got %v", item["depositedAt"])
+	}
+	if _, ok := item["memo"]; ok {
+		t.Errorf("Expected memo, which only has Set, not to be recomputed, got %v", item["memo"])
+	}
+
+	// Update path: same guarantee for SET operations
+	setCalls = 0
+	setOps, _, _ := validator.ApplySetTransformations(map[string]interface{}{"deposit": 5, "balance": 12}, nil, nil)
+	if setOps["balance"] != 1200 {
+		t.Errorf("Expected balance 1200 (transformed once), got %v", setOps["balance"])
+	}
+	if setCalls != 1 {
+		t.Errorf("Expected Set to run once, ran %d times", setCalls)
+	}
+	if setOps["depositedAt"] != "deposit 5" {
+		t.Errorf("Expected watcher depositedAt to be recomputed from deposit, got %v", setOps["depositedAt"])
+	}
+	if _, ok := setOps["memo"]; ok {
+		t.Errorf("Expected memo, which only has Set, not to be recomputed, got %v", setOps["memo"])
+	}
+
+	// Watchers are left alone when nothing they watch is written
+	setOps, _, _ = validator.ApplySetTransformations(map[string]interface{}{"id": "a1"}, nil, nil)
+	if _, ok := setOps["depositedAt"]; ok {
+		t.Error("Expected depositedAt to be untouched when deposit is not written")
+	}
+}
+
+func TestEntityValidate(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":    {Type: AttributeTypeString, Required: true},
+			"email": {Type: AttributeTypeString, Required: true},
+			"age":   {Type: AttributeTypeNumber},
+			"role": {
+				Type:       AttributeTypeEnum,
+				EnumValues: []interface{}{"admin", "member"},
+			},
+			"name": {
+				Type: AttributeTypeString,
+				Validate: func(value interface{}) error {
+					if value.(string) == "" {
+						return errors.New("name cannot be empty")
+					}
+					return nil
+				},
+			},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	valid := Item{"id": "u1", "email": "a@example.com", "age": 30, "role": "admin", "name": "Ada"}
+	if err := entity.Validate(valid); err != nil {
+		t.Errorf("Expected valid item to pass, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		item Item
+		code string
+		msg  string
+	}{
+		{"missing required", Item{"id": "u1"}, "MissingAttribute", "'email'"},
+		{"wrong type", Item{"id": "u1", "email": "a@example.com", "age": "thirty"}, "ValidationError", "'age' must be of type number"},
+		{"bad enum", Item{"id": "u1", "email": "a@example.com", "role": "owner"}, "InvalidEnumValue", "'role'"},
+		{"custom validation", Item{"id": "u1", "email": "a@example.com", "name": ""}, "ValidationError", "name cannot be empty"},
+		{"multiple violations", Item{"id": "u1", "age": "thirty", "role": "owner"}, "MissingAttribute", "'email'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := entity.Validate(tt.item)
+			var electroErr *ElectroError
+			if !errors.As(err, &electroErr) {
+				t.Fatalf("Expected ElectroError, got %v", err)
+			}
+			if electroErr.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, electroErr.Code)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected error to mention %q, got %q", tt.msg, err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateAndTransformForWriteAll(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id": {Type: AttributeTypeString, Required: true},
+			"email": {
+				Type: AttributeTypeString,
+				Validate: func(value interface{}) error {
+					if !strings.Contains(value.(string), "@") {
+						return errors.New("invalid email")
+					}
+					return nil
+				},
+			},
+			"role": {
+				Type:       AttributeTypeEnum,
+				EnumValues: []interface{}{"admin", "member"},
+			},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	validator := NewValidator(entity)
+	item := Item{"id": "u1", "email": "not-an-email", "role": "owner"}
+
+	// Default mode fails fast with the first error
+	_, err = validator.ValidateAndTransformForWrite(item, false)
+	var electroErr *ElectroError
+	if !errors.As(err, &electroErr) {
+		t.Fatalf("Expected ElectroError, got %v", err)
+	}
+	if electroErr.Code == ErrValidationFailed || len(electroErr.Details) != 0 {
+		t.Errorf("Expected fail-fast error without details, got %+v", electroErr)
+	}
+
+	// Aggregate mode reports both invalid fields
+	_, err = validator.ValidateAndTransformForWriteAll(item, false)
+	if !errors.As(err, &electroErr) {
+		t.Fatalf("Expected ElectroError, got %v", err)
+	}
+	if electroErr.Code != ErrValidationFailed {
+		t.Errorf("Expected code %s, got %s", ErrValidationFailed, electroErr.Code)
+	}
+	if len(electroErr.Details) != 2 {
+		t.Fatalf("Expected 2 field errors, got %d: %+v", len(electroErr.Details), electroErr.Details)
+	}
+	if electroErr.Details[0].Field != "email" || electroErr.Details[0].Code != ErrValidation {
+		t.Errorf("Unexpected first detail: %+v", electroErr.Details[0])
+	}
+	if electroErr.Details[1].Field != "role" || electroErr.Details[1].Code != ErrInvalidEnumValue {
+		t.Errorf("Unexpected second detail: %+v", electroErr.Details[1])
+	}
+
+	// A valid item passes in aggregate mode and is still transformed
+	result, err := validator.ValidateAndTransformForWriteAll(Item{"id": "u1", "email": "a@b.c", "role": "admin"}, false)
+	if err != nil {
+		t.Fatalf("Expected valid item to pass, got %v", err)
+	}
+	if result["role"] != "admin" {
+		t.Errorf("Expected role to pass through, got %v", result["role"])
+	}
+
+	// Entity.ValidateAll aggregates required and type failures too
+	err = entity.ValidateAll(Item{"email": 42})
+	if !errors.As(err, &electroErr) || electroErr.Code != ErrValidationFailed {
+		t.Fatalf("Expected ValidationFailed, got %v", err)
+	}
+	if len(electroErr.Details) != 2 {
+		t.Errorf("Expected missing id and wrong email type, got %+v", electroErr.Details)
+	}
+}
+
+func TestGlobalAttributeTransforms(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "User",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":    {Type: AttributeTypeString, Required: true},
+			"name":  {Type: AttributeTypeString},
+			"email": {Type: AttributeTypeString},
+			"age":   {Type: AttributeTypeNumber},
+			"ssn":   {Type: AttributeTypeString},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+
+	var order []string
+	trim := func(name string, value interface{}) interface{} {
+		order = append(order, "trim:"+name)
+		if str, ok := value.(string); ok {
+			return strings.TrimSpace(str)
+		}
+		return value
+	}
+	lower := func(name string, value interface{}) interface{} {
+		order = append(order, "lower:"+name)
+		if str, ok := value.(string); ok && name == "email" {
+			return strings.ToLower(str)
+		}
+		return value
+	}
+	redact := func(name string, value interface{}) interface{} {
+		if name == "ssn" {
+			return "***"
+		}
+		return value
+	}
+
+	entity, err := NewEntity(schema, &Config{
+		WriteTransforms: []AttributeTransformFunc{trim, lower},
+		ReadTransforms:  []AttributeTransformFunc{redact},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	validator := NewValidator(entity)
+	result, err := validator.ValidateAndTransformForWrite(Item{
+		"id":    " u1 ",
+		"name":  "  Ada  ",
+		"email": " ADA@Example.com",
+		"age":   36,
+	}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result["id"] != "u1" || result["name"] != "Ada" || result["email"] != "ada@example.com" {
+		t.Errorf("Expected all strings trimmed and email lowered, got %v", result)
+	}
+	if result["age"] != 36 {
+		t.Errorf("Expected non-string values untouched, got %v", result["age"])
+	}
+	// Transforms run in registration order per attribute, attributes in name order
+	if order[0] != "trim:age" || order[1] != "lower:age" {
+		t.Errorf("Expected deterministic transform order, got %v", order)
+	}
+
+	setOps, _, _ := validator.ApplySetTransformations(map[string]interface{}{"name": " Grace "}, nil, nil)
+	if setOps["name"] != "Grace" {
+		t.Errorf("Expected update SET values to be trimmed, got %v", setOps["name"])
+	}
+
+	read := validator.TransformForRead(Item{"id": "u1", "ssn": "123-45-6789"})
+	if read["ssn"] != "***" {
+		t.Errorf("Expected read transform to redact ssn, got %v", read["ssn"])
+	}
+
+	// Service-level transforms are applied to joined entities before entity transforms
+	service := NewService("TestService", &ServiceConfig{
+		WriteTransforms: []AttributeTransformFunc{func(name string, value interface{}) interface{} {
+			if str, ok := value.(string); ok {
+				return str + "!"
+			}
+			return value
+		}},
+	})
+	if err := service.Join(entity); err != nil {
+		t.Fatalf("Failed to join: %v", err)
+	}
+	result, err = validator.ValidateAndTransformForWrite(Item{"id": "u1", "name": "Ada "}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result["name"] != "Ada !" {
+		t.Errorf("Expected service transform before entity trim, got %q", result["name"])
+	}
+}
+
+func TestWatchDependencies(t *testing.T) {
+	schema := &Schema{
+		Service: "TestService",
+		Entity:  "Person",
+		Table:   "TestTable",
+		Attributes: map[string]*AttributeDefinition{
+			"id":        {Type: AttributeTypeString, Required: true},
+			"firstName": {Type: AttributeTypeString},
+			"lastName":  {Type: AttributeTypeString},
+			"title":     {Type: AttributeTypeString, Default: func() interface{} { return "Dr" }},
+			"fullName": {
+				Type:  AttributeTypeString,
+				Watch: []string{"title", "firstName", "lastName"},
+				SetWithItem: func(value interface{}, item Item) interface{} {
+					if value != nil {
+						return value
+					}
+					return item["title"].(string) + " " + item["firstName"].(string) + " " + item["lastName"].(string)
+				},
+			},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"primary": {
+				PK: FacetDefinition{Field: "pk", Facets: []string{"id"}},
+			},
+		},
+	}
+	entity, err := NewEntity(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	isMissingDependency := func(err error) bool {
+		var electroErr *ElectroError
+		return errors.As(err, &electroErr) && electroErr.Code == ErrMissingWatchDependency &&
+			strings.Contains(electroErr.Message, "'lastName'")
+	}
+
+	// Defaulted dependencies count as present and reach the watcher
+	put, err := entity.Put(Item{"id": "1", "firstName": "Ada", "lastName": "Lovelace"}).Params()
+	if err != nil {
+		t.Fatalf("Expected a put with every dependency to succeed, got %v", err)
+	}
+	if fullName, ok := put["Item"].(map[string]types.AttributeValue)["fullName"].(*types.AttributeValueMemberS); !ok || fullName.Value != "Dr Ada Lovelace" {
+		t.Errorf("Expected fullName derived from the item, got %#v", put["Item"].(map[string]types.AttributeValue)["fullName"])
+	}
+	if _, err := entity.Put(Item{"id": "1", "firstName": "Ada"}).Params(); !isMissingDependency(err) {
+		t.Errorf("Expected MissingWatchDependency for lastName on put, got %v", err)
+	}
+
+	// A partial update leaves the watcher alone instead of recomputing it
+	// from some of its inputs, and recomputes it once all are set
+	params, err := entity.Update(Keys{"id": "1"}).Set(map[string]interface{}{"firstName": "Ada", "title": "Ms"}).Params()
+	if err != nil {
+		t.Fatalf("Expected a partial update to succeed, got %v", err)
+	}
+	for _, name := range params["ExpressionAttributeNames"].(map[string]string) {
+		if name == "fullName" {
+			t.Error("Expected a partial update not to recompute fullName")
+		}
+	}
+	params, err = entity.Update(Keys{"id": "1"}).Set(map[string]interface{}{"firstName": "Ada", "title": "Ms", "lastName": "Lovelace"}).Params()
+	if err != nil {
+		t.Fatalf("Expected a full update to succeed, got %v", err)
+	}
+	recomputed := false
+	for _, name := range params["ExpressionAttributeNames"].(map[string]string) {
+		recomputed = recomputed || name == "fullName"
+	}
+	if !recomputed {
+		t.Error("Expected an update setting every dependency to recompute fullName")
+	}
+
+	// Writing the watcher itself, or none of its dependencies, needs nothing
+	if _, err := entity.Put(Item{"id": "1", "firstName": "Ada", "fullName": "Ada"}).Params(); err != nil {
+		t.Errorf("Expected an explicit watcher value to succeed, got %v", err)
+	}
+	if _, err := entity.Update(Keys{"id": "1"}).Set(map[string]interface{}{"fullName": "Ada"}).Params(); err != nil {
+		t.Errorf("Expected an update not touching dependencies to succeed, got %v", err)
+	}
+}